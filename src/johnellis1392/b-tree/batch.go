@@ -0,0 +1,289 @@
+package btree
+
+import (
+	"errors"
+	"iter"
+	"math"
+	"slices"
+)
+
+// ErrUnsorted Returned by BulkLoad when its input is not strictly ascending
+var ErrUnsorted = errors.New("btree: bulk load input is not in ascending order")
+
+// BulkLoad Build a tree of order n from items, which must yield keys in
+// strictly ascending order under compare.
+//
+// Rather than inserting one key at a time, BulkLoad packs the items into
+// leaves and builds each level of BNodes over the one below. fill, in
+// (0, 1], is the fraction of each node to fill; packing below 1 leaves room
+// for later inserts without immediate splits.
+func BulkLoad[K, V any](n int32, compare func(a, b K) int, fill float64, items iter.Seq2[K, V]) (BTree[K, V], error) {
+	if n < 2 {
+		panic("btree: order must be at least 2")
+	}
+	if !(fill > 0 && fill <= 1) {
+		panic("btree: fill factor must be in (0, 1]")
+	}
+
+	var keys []K
+	var values []V
+	for k, v := range items {
+		if len(keys) > 0 && compare(keys[len(keys)-1], k) >= 0 {
+			return nil, ErrUnsorted
+		}
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+
+	min := minKeys(n)
+	per := int(math.Round(fill * float64(n)))
+	children, seps := leaves(n, compare, keys, values, distribute(len(keys), max(per, min, 1), min))
+	per = int(math.Round(fill * float64(n+1)))
+	return root(n, compare, children, seps, func(m int) []int {
+		return distribute(m, max(per, min+1), min+1)
+	}), nil
+}
+
+// distribute Split m items into groups of about per items each, as evenly
+// as possible, with no group below min unless there is only one group.
+// per must be at least min.
+func distribute(m, per, min int) []int {
+	groups := (m + per - 1) / per
+	for groups > 1 && m/groups < min {
+		groups--
+	}
+	if groups == 0 {
+		groups = 1
+	}
+	sizes := make([]int, groups)
+	for i := range sizes {
+		sizes[i] = m / groups
+		if i < m%groups {
+			sizes[i]++
+		}
+	}
+	return sizes
+}
+
+// leaves Cut keys and values into leaves of the given sizes, returning the
+// leaves and the separators between them.
+func leaves[K, V any](n int32, compare func(a, b K) int, keys []K, values []V, sizes []int) ([]BTree[K, V], []K) {
+	var children []BTree[K, V]
+	var seps []K
+	for _, size := range sizes {
+		if len(children) > 0 {
+			seps = append(seps, keys[0])
+		}
+		children = append(children, BLeaf[K, V]{
+			Order:   n,
+			Compare: compare,
+			Keys:    slices.Clip(keys[:size]),
+			Values:  slices.Clip(values[:size]),
+		})
+		keys, values = keys[size:], values[size:]
+	}
+	return children, seps
+}
+
+// nodes Group children into BNodes of the given sizes, returning the new
+// nodes and the separators between them. The separator at each group
+// boundary moves up a level.
+func nodes[K, V any](n int32, compare func(a, b K) int, children []BTree[K, V], seps []K, sizes []int) ([]BTree[K, V], []K) {
+	var parents []BTree[K, V]
+	var up []K
+	for _, size := range sizes {
+		if len(parents) > 0 {
+			up = append(up, seps[0])
+			seps = seps[1:]
+		}
+		parents = append(parents, BNode[K, V]{
+			Order:    n,
+			Compare:  compare,
+			Keys:     slices.Clone(seps[:size-1]),
+			Children: slices.Clone(children[:size]),
+		})
+		children, seps = children[size:], seps[size-1:]
+	}
+	return parents, up
+}
+
+// root Build levels of BNodes over children until one node remains, then
+// drop any chain of single-child nodes above the real root.
+func root[K, V any](n int32, compare func(a, b K) int, children []BTree[K, V], seps []K, sizes func(m int) []int) BTree[K, V] {
+	for len(children) > 1 {
+		children, seps = nodes(n, compare, children, seps, sizes(len(children)))
+	}
+	r := children[0]
+	for {
+		b, ok := r.(BNode[K, V])
+		if !ok || len(b.Keys) > 0 {
+			return r
+		}
+		r = b.Children[0]
+	}
+}
+
+// Batch Set of inserts and deletes to apply to a tree in a single pass
+//
+// Later operations on a key replace earlier ones. Apply sorts the batch and
+// descends the tree once, visiting each affected node one time however
+// many operations land in it, and rebuilding only those nodes.
+type Batch[K, V any] struct {
+	ops []batchOp[K, V]
+}
+
+type batchOp[K, V any] struct {
+	key    K
+	value  V
+	delete bool
+}
+
+// Insert Queue setting key to value
+func (b *Batch[K, V]) Insert(key K, value V) {
+	b.ops = append(b.ops, batchOp[K, V]{key: key, value: value})
+}
+
+// Delete Queue removing key
+func (b *Batch[K, V]) Delete(key K) {
+	b.ops = append(b.ops, batchOp[K, V]{key: key, delete: true})
+}
+
+// Len Number of queued operations
+func (b *Batch[K, V]) Len() int {
+	return len(b.ops)
+}
+
+// Reset Empty the batch for reuse
+func (b *Batch[K, V]) Reset() {
+	b.ops = b.ops[:0]
+}
+
+// Apply Return t with every queued operation applied; like Insert and
+// Delete it leaves t itself unchanged.
+func (b *Batch[K, V]) Apply(t BTree[K, V]) BTree[K, V] {
+	if len(b.ops) == 0 {
+		return t
+	}
+	order, compare := t.(node[K, V]).config()
+
+	ops := slices.Clone(b.ops)
+	slices.SortStableFunc(ops, func(x, y batchOp[K, V]) int {
+		return compare(x.key, y.key)
+	})
+	last := ops[:0]
+	for _, op := range ops {
+		if len(last) > 0 && compare(last[len(last)-1].key, op.key) == 0 {
+			last[len(last)-1] = op
+		} else {
+			last = append(last, op)
+		}
+	}
+
+	a := applier[K, V]{order: order, compare: compare, min: minKeys(order)}
+	children, seps := a.apply(t, last)
+	return root(order, compare, children, seps, func(m int) []int {
+		return distribute(m, int(order)+1, a.min+1)
+	})
+}
+
+// applier Shared state of one Batch.Apply
+type applier[K, V any] struct {
+	order   int32
+	compare func(a, b K) int
+	min     int
+}
+
+// apply Apply ops, which all fall in n's key range, to n. The result is a
+// run of nodes at n's height with the separators between them; when there
+// are several, every one is within the fill bounds, and when there is one
+// it may be underfull.
+func (a applier[K, V]) apply(n BTree[K, V], ops []batchOp[K, V]) ([]BTree[K, V], []K) {
+	switch x := n.(type) {
+	case BLeaf[K, V]:
+		keys, values := a.mergeLeaf(x, ops)
+		return leaves(a.order, a.compare, keys, values, distribute(len(keys), int(a.order), a.min))
+	case BNode[K, V]:
+		var children []BTree[K, V]
+		var seps []K
+		for i, c := range x.Children {
+			if i > 0 {
+				seps = append(seps, x.Keys[i-1])
+			}
+			j := len(ops)
+			if i < len(x.Keys) {
+				j, _ = slices.BinarySearchFunc(ops, x.Keys[i], func(op batchOp[K, V], k K) int {
+					return a.compare(op.key, k)
+				})
+			}
+			if j == 0 {
+				children = append(children, c)
+				continue
+			}
+			cs, ss := a.apply(c, ops[:j])
+			children = append(children, cs...)
+			seps = append(seps, ss...)
+			ops = ops[j:]
+		}
+		children, seps = a.fix(children, seps)
+		return nodes(a.order, a.compare, children, seps, distribute(len(children), int(a.order)+1, a.min+1))
+	}
+	return nil, nil
+}
+
+// mergeLeaf Merge sorted ops into the entries of a leaf
+func (a applier[K, V]) mergeLeaf(l BLeaf[K, V], ops []batchOp[K, V]) ([]K, []V) {
+	keys := make([]K, 0, len(l.Keys)+len(ops))
+	values := make([]V, 0, len(l.Keys)+len(ops))
+	i := 0
+	for _, op := range ops {
+		for i < len(l.Keys) && a.compare(l.Keys[i], op.key) < 0 {
+			keys, values = append(keys, l.Keys[i]), append(values, l.Values[i])
+			i++
+		}
+		if i < len(l.Keys) && a.compare(l.Keys[i], op.key) == 0 {
+			i++
+		}
+		if !op.delete {
+			keys, values = append(keys, op.key), append(values, op.value)
+		}
+	}
+	return append(keys, l.Keys[i:]...), append(values, l.Values[i:]...)
+}
+
+// fix Merge every underfull child with a neighbour, splitting the result
+// again if it is too large, until all children are within bounds or only
+// one remains.
+func (a applier[K, V]) fix(children []BTree[K, V], seps []K) ([]BTree[K, V], []K) {
+	for i := 0; i < len(children) && len(children) > 1; {
+		if children[i].(node[K, V]).size() >= a.min {
+			i++
+			continue
+		}
+		j := i
+		if j == len(children)-1 {
+			j--
+		}
+		cs, ss := a.merge(children[j], seps[j], children[j+1])
+		children = slices.Concat(children[:j], cs, children[j+2:])
+		seps = slices.Concat(seps[:j], ss, seps[j+1:])
+		i = j
+	}
+	return children, seps
+}
+
+// merge Combine two adjacent nodes into one, or two if that is too large
+func (a applier[K, V]) merge(l BTree[K, V], sep K, r BTree[K, V]) ([]BTree[K, V], []K) {
+	switch x := l.(type) {
+	case BLeaf[K, V]:
+		y := r.(BLeaf[K, V])
+		keys, values := slices.Concat(x.Keys, y.Keys), slices.Concat(x.Values, y.Values)
+		return leaves(a.order, a.compare, keys, values, distribute(len(keys), int(a.order), a.min))
+	case BNode[K, V]:
+		y := r.(BNode[K, V])
+		// The left node may be a lone child that could not be fixed at its
+		// own level; its children now have siblings to merge with.
+		children, seps := a.fix(slices.Concat(x.Children, y.Children), slices.Concat(x.Keys, []K{sep}, y.Keys))
+		return nodes(a.order, a.compare, children, seps, distribute(len(children), int(a.order)+1, a.min+1))
+	}
+	return nil, nil
+}
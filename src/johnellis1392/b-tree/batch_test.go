@@ -0,0 +1,245 @@
+package btree
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"math/rand"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// sorted Iterator over the keys of ref in ascending order
+func sorted(ref map[string]int) func(func(string, interface{}) bool) {
+	return func(yield func(string, interface{}) bool) {
+		for _, k := range slices.Sorted(maps.Keys(ref)) {
+			if !yield(k, ref[k]) {
+				return
+			}
+		}
+	}
+}
+
+func Test_BulkLoad_ShouldBuildValidTree(t *testing.T) {
+	for _, order := range []int32{2, 3, 4, 7, 16} {
+		for _, fill := range []float64{0.1, 0.5, 0.7, 1} {
+			for _, n := range []int{0, 1, 2, 5, 17, 100, 1000} {
+				ref := make(map[string]int)
+				for i := 0; i < n; i++ {
+					ref[fmt.Sprintf("%05d", i)] = i
+				}
+				b, err := BulkLoad(order, cmp.Compare[string], fill, sorted(ref))
+				if err != nil {
+					t.Fatal(err)
+				}
+				checkTree(t, b, order, ref)
+			}
+		}
+	}
+}
+
+func Test_BulkLoad_ShouldPackLeavesToFillFactor(t *testing.T) {
+	ref := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		ref[fmt.Sprintf("%05d", i)] = i
+	}
+
+	count := func(fill float64) int {
+		b, _ := BulkLoad(10, cmp.Compare[string], fill, sorted(ref))
+		n := 0
+		var walk func(BTree[string, interface{}])
+		walk = func(x BTree[string, interface{}]) {
+			switch x := x.(type) {
+			case BLeaf[string, interface{}]:
+				n++
+			case BNode[string, interface{}]:
+				for _, c := range x.Children {
+					walk(c)
+				}
+			}
+		}
+		walk(b)
+		return n
+	}
+	if n := count(1); n != 100 {
+		t.Errorf("Expected 100 full leaves, got: %d", n)
+	}
+	if n := count(0.5); n != 200 {
+		t.Errorf("Expected 200 half-full leaves, got: %d", n)
+	}
+}
+
+func Test_BulkLoad_ShouldRejectUnsortedInput(t *testing.T) {
+	items := func(yield func(int, int) bool) {
+		for _, k := range []int{1, 2, 2, 3} {
+			if !yield(k, k) {
+				return
+			}
+		}
+	}
+	if _, err := BulkLoad(4, cmp.Compare[int], 1, items); err != ErrUnsorted {
+		t.Errorf("Expected ErrUnsorted, got: %v", err)
+	}
+}
+
+func Test_Batch_Apply_ShouldMatchReferenceMap(t *testing.T) {
+	for _, order := range []int32{2, 3, 5, 16} {
+		rng := rand.New(rand.NewSource(int64(order)))
+		ref := make(map[string]int)
+		b := New(order)
+		for round := 0; round < 200; round++ {
+			var batch Batch[string, interface{}]
+			size := rng.Intn(400)
+			deletes := rng.Intn(4) // Skew some rounds towards deleting
+			for i := 0; i < size; i++ {
+				k := strconv.Itoa(rng.Intn(3000))
+				if rng.Intn(4) < deletes {
+					batch.Delete(k)
+					delete(ref, k)
+				} else {
+					batch.Insert(k, i)
+					ref[k] = i
+				}
+			}
+
+			oldRef := maps.Clone(ref)
+			b = batch.Apply(b)
+			checkTree(t, b, order, ref)
+			if round%20 == 0 {
+				// Emptying the tree must also leave a valid (empty) tree.
+				var clear Batch[string, interface{}]
+				for k := range ref {
+					clear.Delete(k)
+				}
+				checkTree(t, clear.Apply(b), order, map[string]int{})
+				checkTree(t, b, order, oldRef)
+			}
+		}
+	}
+}
+
+func Test_Batch_Apply_ShouldLeaveTreeUnchanged(t *testing.T) {
+	b := build(3, 200)
+	ref := make(map[string]int)
+	b.Ascend(func(k string, v interface{}) bool {
+		ref[k] = v.(int)
+		return true
+	})
+
+	var batch Batch[string, interface{}]
+	for i := 0; i < 200; i += 3 {
+		batch.Delete(fmt.Sprintf("k%03d", i))
+		batch.Insert(fmt.Sprintf("k%03da", i), i)
+	}
+	nb := batch.Apply(b)
+
+	checkTree(t, b, 3, ref)
+	if nb.Find("k000") != nil || nb.Find("k000a") != 0 {
+		t.Errorf("Expected batch to be applied to the new tree")
+	}
+}
+
+func Test_Batch_ShouldKeepLastOperationPerKey(t *testing.T) {
+	var batch Batch[string, interface{}]
+	batch.Insert("a", 1)
+	batch.Delete("a")
+	batch.Insert("b", 1)
+	batch.Insert("b", 2)
+	batch.Delete("c")
+	batch.Insert("c", 3)
+
+	b := batch.Apply(New(2))
+	if b.Find("a") != nil || b.Find("b") != 2 || b.Find("c") != 3 {
+		t.Errorf("Expected a missing, b=2 and c=3")
+	}
+}
+
+const benchKeys = 100000
+
+func benchItems(yield func(int, int) bool) {
+	for i := 0; i < benchKeys; i++ {
+		if !yield(i, i) {
+			return
+		}
+	}
+}
+
+func Benchmark_Insert_Sorted(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		t := NewOrdered[int, int](32)
+		for i := 0; i < benchKeys; i++ {
+			t = t.Insert(i, i)
+		}
+	}
+}
+
+func Benchmark_BulkLoad(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		BulkLoad(32, cmp.Compare[int], 0.9, benchItems)
+	}
+}
+
+func benchOps() []int {
+	rng := rand.New(rand.NewSource(1))
+	ops := make([]int, 10000)
+	for i := range ops {
+		ops[i] = rng.Intn(2 * benchKeys)
+	}
+	return ops
+}
+
+func Benchmark_Insert_Random(b *testing.B) {
+	base, _ := BulkLoad(32, cmp.Compare[int], 0.9, benchItems)
+	ops := benchOps()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		t := base
+		for i, k := range ops {
+			if i%2 == 0 {
+				t = t.Insert(k, k)
+			} else {
+				t = t.Delete(k)
+			}
+		}
+	}
+}
+
+func Benchmark_Batch_Random(b *testing.B) {
+	base, _ := BulkLoad(32, cmp.Compare[int], 0.9, benchItems)
+	ops := benchOps()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		var batch Batch[int, int]
+		for i, k := range ops {
+			if i%2 == 0 {
+				batch.Insert(k, k)
+			} else {
+				batch.Delete(k)
+			}
+		}
+		batch.Apply(base)
+	}
+}
+
+func Test_Batch_Apply_ShouldRebalanceAfterDeletingRanges(t *testing.T) {
+	for _, order := range []int32{2, 3, 4} {
+		ref := make(map[string]int)
+		for i := 0; i < 5000; i++ {
+			ref[fmt.Sprintf("%05d", i)] = i
+		}
+		b, _ := BulkLoad(order, cmp.Compare[string], 1, sorted(ref))
+
+		// Hollow out whole subtrees, leaving lone survivors scattered
+		// through the key space.
+		var batch Batch[string, interface{}]
+		for i := 0; i < 5000; i++ {
+			if i%997 != 0 && i != 4999 {
+				k := fmt.Sprintf("%05d", i)
+				batch.Delete(k)
+				delete(ref, k)
+			}
+		}
+		checkTree(t, batch.Apply(b), order, ref)
+	}
+}
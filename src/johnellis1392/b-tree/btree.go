@@ -1,5 +1,11 @@
 package btree
 
+import (
+	"cmp"
+	"slices"
+	"sort"
+)
+
 // Go Build Template:
 // https://github.com/thockin/go-build-template
 
@@ -26,59 +32,381 @@ package btree
 // )
 
 // New Construct a new BTree root node
-func New(n int32) BTree {
-	return BLeaf{
-		Order:    n,
-		Elements: make(map[string]interface{}, n),
+//
+// New builds the original string-keyed tree holding interface{} values;
+// use NewOrdered or NewFunc for other key and value types.
+func New(n int32) BTree[string, interface{}] {
+	return NewOrdered[string, interface{}](n)
+}
+
+// NewOrdered Construct a new BTree over keys with a natural ordering
+func NewOrdered[K cmp.Ordered, V any](n int32) BTree[K, V] {
+	return NewFunc[K, V](n, cmp.Compare[K])
+}
+
+// NewFunc Construct a new BTree ordered by compare
+//
+// The order n is the maximum number of keys held by any one node; every
+// node other than the root holds at least n/2 keys. n must be at least 2.
+// compare returns a negative number, zero or a positive number when a is
+// less than, equal to or greater than b.
+func NewFunc[K, V any](n int32, compare func(a, b K) int) BTree[K, V] {
+	if n < 2 {
+		panic("btree: order must be at least 2")
 	}
+	return BLeaf[K, V]{Order: n, Compare: compare}
 }
 
 // BTree BTree utility function interface
-type BTree interface {
-	Insert(key string, value interface{}) BTree
-	Delete(key string) BTree
-	Find(key string) interface{}
+//
+// Trees are persistent: nodes are never modified once built. Insert and
+// Delete copy the path from the root to the affected leaf and return the
+// root of the new tree, which shares every other node with the receiver,
+// so callers must always keep the result and anyone still holding the old
+// root keeps reading the old tree.
+type BTree[K, V any] interface {
+	Insert(key K, value V) BTree[K, V]
+	Delete(key K) BTree[K, V]
+	Clone() BTree[K, V]
+	Find(key K) V
+	Get(key K) (V, bool)
+
+	Ascend(fn Iterator[K, V])
+	Descend(fn Iterator[K, V])
+	AscendRange(from, to K, fn Iterator[K, V])
+	Min() (K, V, bool)
+	Max() (K, V, bool)
+	Seek(key K) *Cursor[K, V]
+
+	Validate() error
+}
+
+// node Internal operations shared by BNode and BLeaf
+type node[K, V any] interface {
+	BTree[K, V]
+
+	// insert Insert into the subtree, returning its new root and, when the
+	// node overflowed, the separator key and right half of the split.
+	insert(key K, value V) (left BTree[K, V], sep K, right BTree[K, V])
+
+	// remove Delete from the subtree, reporting whether the key was found.
+	remove(key K) (BTree[K, V], bool)
+
+	// size Number of keys held directly by the node.
+	size() int
+
+	// config Order and comparator the tree was built with.
+	config() (int32, func(a, b K) int)
+}
+
+// inserted Copy of s with v inserted at i
+func inserted[T any](s []T, i int, v T) []T {
+	return slices.Insert(slices.Clip(s), i, v)
+}
+
+// removed Copy of s without element i
+func removed[T any](s []T, i int) []T {
+	return slices.Concat(s[:i], s[i+1:])
+}
+
+// replaced Copy of s with element i set to v
+func replaced[T any](s []T, i int, v T) []T {
+	s = slices.Clone(s)
+	s[i] = v
+	return s
+}
+
+// minKeys Minimum number of keys in a non-root node of the given order
+func minKeys(order int32) int {
+	return int(order) / 2
 }
 
 // BNode B-Tree node
-type BNode struct {
+//
+// Children[i] holds the keys k with Keys[i-1] <= k < Keys[i], so there is
+// always one more child than there are keys.
+type BNode[K, V any] struct {
 	Order    int32
-	Elements map[string]BTree
+	Compare  func(a, b K) int
+	Keys     []K
+	Children []BTree[K, V]
 }
 
 // Insert ...
-func (b BNode) Insert(key string, value interface{}) BTree {
-	return b
+func (b BNode[K, V]) Insert(key K, value V) BTree[K, V] {
+	left, sep, right := b.insert(key, value)
+	if right == nil {
+		return left
+	}
+	return BNode[K, V]{
+		Order:    b.Order,
+		Compare:  b.Compare,
+		Keys:     []K{sep},
+		Children: []BTree[K, V]{left, right},
+	}
 }
 
 // Delete ...
-func (b BNode) Delete(key string) BTree {
+func (b BNode[K, V]) Delete(key K) BTree[K, V] {
+	root, _ := b.remove(key)
+	if n, ok := root.(BNode[K, V]); ok && len(n.Keys) == 0 {
+		return n.Children[0]
+	}
+	return root
+}
+
+// Clone Snapshot of the tree; nodes are immutable, so this is free
+func (b BNode[K, V]) Clone() BTree[K, V] {
 	return b
 }
 
 // Find ...
-func (b BNode) Find(key string) interface{} {
-	return nil
+func (b BNode[K, V]) Find(key K) V {
+	v, _ := b.Get(key)
+	return v
+}
+
+// Get Value of key, and whether it is present
+func (b BNode[K, V]) Get(key K) (V, bool) {
+	return b.Children[b.child(key)].Get(key)
+}
+
+// child Index of the child whose range contains key
+func (b BNode[K, V]) child(key K) int {
+	return sort.Search(len(b.Keys), func(i int) bool {
+		return b.Compare(key, b.Keys[i]) < 0
+	})
+}
+
+func (b BNode[K, V]) size() int {
+	return len(b.Keys)
+}
+
+func (b BNode[K, V]) config() (int32, func(a, b K) int) {
+	return b.Order, b.Compare
+}
+
+func (b BNode[K, V]) insert(key K, value V) (BTree[K, V], K, BTree[K, V]) {
+	var zero K
+	i := b.child(key)
+	left, sep, right := b.Children[i].(node[K, V]).insert(key, value)
+	b.Children = replaced(b.Children, i, left)
+	if right == nil {
+		return b, zero, nil
+	}
+
+	b.Keys = inserted(b.Keys, i, sep)
+	b.Children = inserted(b.Children, i+1, right)
+	if len(b.Keys) <= int(b.Order) {
+		return b, zero, nil
+	}
+
+	// Split around the middle key, which moves up into the parent.
+	mid := len(b.Keys) / 2
+	r := BNode[K, V]{
+		Order:    b.Order,
+		Compare:  b.Compare,
+		Keys:     slices.Clone(b.Keys[mid+1:]),
+		Children: slices.Clone(b.Children[mid+1:]),
+	}
+	sep = b.Keys[mid]
+	b.Keys = b.Keys[:mid:mid]
+	b.Children = b.Children[: mid+1 : mid+1]
+	return b, sep, r
+}
+
+func (b BNode[K, V]) remove(key K) (BTree[K, V], bool) {
+	i := b.child(key)
+	c, found := b.Children[i].(node[K, V]).remove(key)
+	if !found {
+		return b, false
+	}
+	b.Keys = slices.Clone(b.Keys)
+	b.Children = replaced(b.Children, i, c)
+	if c.(node[K, V]).size() < minKeys(b.Order) {
+		b = b.rebalance(i)
+	}
+	return b, true
+}
+
+// rebalance Restore the minimum fill of child i by borrowing a key from a
+// sibling or, when neither sibling can spare one, merging with a sibling.
+//
+// The receiver's Keys and Children must already be private copies; the
+// children themselves are replaced rather than modified.
+func (b BNode[K, V]) rebalance(i int) BNode[K, V] {
+	if i > 0 && b.Children[i-1].(node[K, V]).size() > minKeys(b.Order) {
+		b.borrowLeft(i)
+		return b
+	}
+	if i < len(b.Children)-1 && b.Children[i+1].(node[K, V]).size() > minKeys(b.Order) {
+		b.borrowRight(i)
+		return b
+	}
+	if i > 0 {
+		return b.merge(i - 1)
+	}
+	return b.merge(i)
+}
+
+// borrowLeft Move the last key of child i-1 into child i
+func (b BNode[K, V]) borrowLeft(i int) {
+	switch c := b.Children[i].(type) {
+	case BLeaf[K, V]:
+		l := b.Children[i-1].(BLeaf[K, V])
+		n := len(l.Keys) - 1
+		c.Keys = inserted(c.Keys, 0, l.Keys[n])
+		c.Values = inserted(c.Values, 0, l.Values[n])
+		l.Keys, l.Values = l.Keys[:n:n], l.Values[:n:n]
+		b.Keys[i-1] = c.Keys[0]
+		b.Children[i-1], b.Children[i] = l, c
+	case BNode[K, V]:
+		l := b.Children[i-1].(BNode[K, V])
+		n := len(l.Keys) - 1
+		c.Keys = inserted(c.Keys, 0, b.Keys[i-1])
+		c.Children = inserted(c.Children, 0, l.Children[n+1])
+		b.Keys[i-1] = l.Keys[n]
+		l.Keys, l.Children = l.Keys[:n:n], l.Children[:n+1:n+1]
+		b.Children[i-1], b.Children[i] = l, c
+	}
+}
+
+// borrowRight Move the first key of child i+1 into child i
+func (b BNode[K, V]) borrowRight(i int) {
+	switch c := b.Children[i].(type) {
+	case BLeaf[K, V]:
+		r := b.Children[i+1].(BLeaf[K, V])
+		c.Keys = inserted(c.Keys, len(c.Keys), r.Keys[0])
+		c.Values = inserted(c.Values, len(c.Values), r.Values[0])
+		r.Keys, r.Values = r.Keys[1:], r.Values[1:]
+		b.Keys[i] = r.Keys[0]
+		b.Children[i], b.Children[i+1] = c, r
+	case BNode[K, V]:
+		r := b.Children[i+1].(BNode[K, V])
+		c.Keys = inserted(c.Keys, len(c.Keys), b.Keys[i])
+		c.Children = inserted(c.Children, len(c.Children), r.Children[0])
+		b.Keys[i] = r.Keys[0]
+		r.Keys, r.Children = r.Keys[1:], r.Children[1:]
+		b.Children[i], b.Children[i+1] = c, r
+	}
+}
+
+// merge Fold child i+1 and the separator between them into child i
+func (b BNode[K, V]) merge(i int) BNode[K, V] {
+	switch l := b.Children[i].(type) {
+	case BLeaf[K, V]:
+		r := b.Children[i+1].(BLeaf[K, V])
+		l.Keys = slices.Concat(l.Keys, r.Keys)
+		l.Values = slices.Concat(l.Values, r.Values)
+		b.Children[i] = l
+	case BNode[K, V]:
+		r := b.Children[i+1].(BNode[K, V])
+		l.Keys = slices.Concat(l.Keys, b.Keys[i:i+1], r.Keys)
+		l.Children = slices.Concat(l.Children, r.Children)
+		b.Children[i] = l
+	}
+	b.Keys = removed(b.Keys, i)
+	b.Children = removed(b.Children, i+1)
+	return b
 }
 
 // BLeaf B-Tree Leaf Node
-type BLeaf struct {
-	Order    int32
-	Elements map[string]interface{}
+//
+// Keys are kept in ascending order, with Values[i] holding the value of
+// Keys[i].
+type BLeaf[K, V any] struct {
+	Order   int32
+	Compare func(a, b K) int
+	Keys    []K
+	Values  []V
 }
 
 // Insert ...
-func (b BLeaf) Insert(key string, value interface{}) BTree {
-	b.Elements[key] = value
-	return b
+func (b BLeaf[K, V]) Insert(key K, value V) BTree[K, V] {
+	left, sep, right := b.insert(key, value)
+	if right == nil {
+		return left
+	}
+	return BNode[K, V]{
+		Order:    b.Order,
+		Compare:  b.Compare,
+		Keys:     []K{sep},
+		Children: []BTree[K, V]{left, right},
+	}
 }
 
 // Delete ...
-func (b BLeaf) Delete(key string) BTree {
+func (b BLeaf[K, V]) Delete(key K) BTree[K, V] {
+	root, _ := b.remove(key)
+	return root
+}
+
+// Clone Snapshot of the tree; nodes are immutable, so this is free
+func (b BLeaf[K, V]) Clone() BTree[K, V] {
 	return b
 }
 
 // Find ...
-func (b BLeaf) Find(key string) interface{} {
-	return nil
+func (b BLeaf[K, V]) Find(key K) V {
+	v, _ := b.Get(key)
+	return v
+}
+
+// Get Value of key, and whether it is present
+func (b BLeaf[K, V]) Get(key K) (V, bool) {
+	if i, ok := b.search(key); ok {
+		return b.Values[i], true
+	}
+	var zero V
+	return zero, false
+}
+
+// search Position of key in the leaf, or where it would be inserted
+func (b BLeaf[K, V]) search(key K) (int, bool) {
+	return slices.BinarySearchFunc(b.Keys, key, b.Compare)
+}
+
+func (b BLeaf[K, V]) size() int {
+	return len(b.Keys)
+}
+
+func (b BLeaf[K, V]) config() (int32, func(a, b K) int) {
+	return b.Order, b.Compare
+}
+
+func (b BLeaf[K, V]) insert(key K, value V) (BTree[K, V], K, BTree[K, V]) {
+	var zero K
+	i, ok := b.search(key)
+	if ok {
+		b.Values = replaced(b.Values, i, value)
+		return b, zero, nil
+	}
+
+	b.Keys = inserted(b.Keys, i, key)
+	b.Values = inserted(b.Values, i, value)
+	if len(b.Keys) <= int(b.Order) {
+		return b, zero, nil
+	}
+
+	// Split in half; the first key of the right half becomes the separator.
+	mid := len(b.Keys) / 2
+	r := BLeaf[K, V]{
+		Order:   b.Order,
+		Compare: b.Compare,
+		Keys:    slices.Clone(b.Keys[mid:]),
+		Values:  slices.Clone(b.Values[mid:]),
+	}
+	b.Keys = b.Keys[:mid:mid]
+	b.Values = b.Values[:mid:mid]
+	return b, r.Keys[0], r
+}
+
+func (b BLeaf[K, V]) remove(key K) (BTree[K, V], bool) {
+	i, ok := b.search(key)
+	if !ok {
+		return b, false
+	}
+	b.Keys = removed(b.Keys, i)
+	b.Values = removed(b.Values, i)
+	return b, true
 }
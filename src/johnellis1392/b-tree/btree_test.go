@@ -1,6 +1,7 @@
 package btree
 
 import (
+	"math/rand"
 	"strconv"
 	"testing"
 )
@@ -8,7 +9,7 @@ import (
 func Test_BTree_New_ShouldCreateNewBTree(t *testing.T) {
 	b := New(10)
 	switch x := b.(type) {
-	case BLeaf:
+	case BLeaf[string, interface{}]:
 		if x.Order != 10 {
 			t.Errorf("Expected order=10, got: %q", x.Order)
 		}
@@ -19,29 +20,250 @@ func Test_BTree_New_ShouldCreateNewBTree(t *testing.T) {
 }
 
 func Test_BLeaf_Insert_ShouldInsertANewValue(t *testing.T) {
-	b := New(10).(BLeaf)
+	b := New(10).(BLeaf[string, interface{}])
 	const (
 		key   = "key1"
 		value = "value1"
 	)
 
-	b.Insert(key, value)
-	if b.Elements[key] != value {
-		t.Errorf("Insert value failed, values: %q", b.Elements)
+	b = b.Insert(key, value).(BLeaf[string, interface{}])
+	if b.Find(key) != value {
+		t.Errorf("Insert value failed, values: %q", b.Values)
 	}
 }
 
 func Test_Bleaf_Insert_ShouldReturnNewBNodeWhenOrderIsExceeded(t *testing.T) {
-	var b BTree = New(2)
+	var b BTree[string, interface{}] = New(2)
 	for i := 1; i <= 3; i++ {
 		b = b.Insert(strconv.Itoa(i), i)
 	}
 
 	switch b.(type) {
-	case BNode:
+	case BNode[string, interface{}]:
 		// Pass
 		break
 	default:
 		t.Errorf("Expected element of type BNode, got: %q", b)
 	}
 }
+
+func Test_BLeaf_Insert_ShouldKeepKeysSorted(t *testing.T) {
+	var b BTree[string, interface{}] = New(10)
+	for _, k := range []string{"d", "b", "e", "a", "c"} {
+		b = b.Insert(k, k)
+	}
+
+	keys := b.(BLeaf[string, interface{}]).Keys
+	for i := 1; i < len(keys); i++ {
+		if keys[i-1] >= keys[i] {
+			t.Errorf("Expected sorted keys, got: %q", keys)
+		}
+	}
+}
+
+func Test_BTree_Delete_ShouldCollapseToLeaf(t *testing.T) {
+	var b BTree[string, interface{}] = New(2)
+	for i := 0; i < 10; i++ {
+		b = b.Insert(strconv.Itoa(i), i)
+	}
+	for i := 0; i < 9; i++ {
+		b = b.Delete(strconv.Itoa(i))
+	}
+
+	if _, ok := b.(BLeaf[string, interface{}]); !ok {
+		t.Errorf("Expected element of type BLeaf, got: %v", b)
+	}
+	if b.Find("9") != 9 {
+		t.Errorf("Expected 9, got: %v", b.Find("9"))
+	}
+}
+
+// Test_BTree_ShouldMatchReferenceMap Apply random operations to trees of
+// several orders and a map in lockstep, checking every lookup and the
+// structure of the tree as it goes.
+func Test_BTree_ShouldMatchReferenceMap(t *testing.T) {
+	ops := 1000000
+	if testing.Short() {
+		ops = 20000
+	}
+
+	for _, order := range []int32{2, 3, 4, 7, 32} {
+		rng := rand.New(rand.NewSource(int64(order)))
+		ref := make(map[string]int)
+		b := New(order)
+		for i := 0; i < ops; i++ {
+			k := strconv.Itoa(rng.Intn(4096))
+			switch rng.Intn(3) {
+			case 0:
+				b = b.Insert(k, i)
+				ref[k] = i
+			case 1:
+				b = b.Delete(k)
+				delete(ref, k)
+			case 2:
+				v, ok := ref[k]
+				if got := b.Find(k); (ok && got != v) || (!ok && got != nil) {
+					t.Fatalf("order %d, op %d: Find(%q) = %v, expected %v", order, i, k, got, v)
+				}
+			}
+
+			if i%50000 == 0 {
+				checkTree(t, b, order, ref)
+			}
+		}
+		checkTree(t, b, order, ref)
+	}
+}
+
+// checkTree Verify that b is well-formed for its order and holds exactly
+// the contents of ref, in order.
+func checkTree(t *testing.T, b BTree[string, interface{}], order int32, ref map[string]int) {
+	t.Helper()
+	if err := b.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if o, _ := b.(node[string, interface{}]).config(); o != order {
+		t.Fatalf("tree has order %d, expected %d", o, order)
+	}
+
+	n := 0
+	b.Ascend(func(k string, v interface{}) bool {
+		if r, ok := ref[k]; !ok || v != r {
+			t.Fatalf("key %q holds %v, expected %v", k, v, r)
+		}
+		n++
+		return true
+	})
+	if n != len(ref) {
+		t.Fatalf("tree holds %d keys, expected %d", n, len(ref))
+	}
+}
+
+func Test_BTree_NewOrdered_ShouldSupportOtherKeyTypes(t *testing.T) {
+	b := NewOrdered[int, float64](3)
+	for i := 100; i > 0; i-- {
+		b = b.Insert(i, float64(i)/2)
+	}
+
+	if v, ok := b.Get(42); !ok || v != 21 {
+		t.Errorf("Expected 21, got: %v", v)
+	}
+	if _, ok := b.Get(0); ok {
+		t.Errorf("Expected 0 to be missing")
+	}
+	if k, _, _ := b.Min(); k != 1 {
+		t.Errorf("Expected min 1, got: %d", k)
+	}
+}
+
+func Test_BTree_NewFunc_ShouldOrderByComparator(t *testing.T) {
+	reverse := func(a, b int) int { return b - a }
+	b := NewFunc[int, struct{}](2, reverse)
+	for i := 0; i < 50; i++ {
+		b = b.Insert(i, struct{}{})
+	}
+
+	prev := 50
+	b.Ascend(func(key int, _ struct{}) bool {
+		if key != prev-1 {
+			t.Errorf("Expected %d after %d, got: %d", prev-1, prev, key)
+		}
+		prev = key
+		return true
+	})
+	if prev != 0 {
+		t.Errorf("Expected scan to end at 0, got: %d", prev)
+	}
+}
+
+func Test_BTree_Insert_ShouldLeaveOldRootUnchanged(t *testing.T) {
+	var b BTree[string, interface{}] = New(3)
+	ref := make(map[string]int)
+	for i := 0; i < 500; i++ {
+		b = b.Insert(strconv.Itoa(i), i)
+		ref[strconv.Itoa(i)] = i
+	}
+
+	old := b.Clone()
+	for i := 0; i < 500; i += 2 {
+		b = b.Delete(strconv.Itoa(i))
+	}
+	for i := 1; i < 500; i += 2 {
+		b = b.Insert(strconv.Itoa(i), -i)
+	}
+	for i := 500; i < 1000; i++ {
+		b = b.Insert(strconv.Itoa(i), i)
+	}
+
+	checkTree(t, old, 3, ref)
+	if b.Find("0") != nil || b.Find("1") != -1 || b.Find("999") != 999 {
+		t.Errorf("Expected new tree to hold the updates")
+	}
+}
+
+// Test_BTree_Snapshots_ShouldMatchReferenceMaps Keep snapshots taken
+// throughout a random workload and check each still matches the reference
+// map as it was when the snapshot was taken.
+func Test_BTree_Snapshots_ShouldMatchReferenceMaps(t *testing.T) {
+	ops := 200000
+	if testing.Short() {
+		ops = 20000
+	}
+
+	type snapshot struct {
+		tree BTree[string, interface{}]
+		ref  map[string]int
+	}
+	var snapshots []snapshot
+
+	rng := rand.New(rand.NewSource(1))
+	ref := make(map[string]int)
+	b := New(4)
+	for i := 0; i < ops; i++ {
+		k := strconv.Itoa(rng.Intn(2048))
+		if rng.Intn(2) == 0 {
+			b = b.Insert(k, i)
+			ref[k] = i
+		} else {
+			b = b.Delete(k)
+			delete(ref, k)
+		}
+
+		if i%(ops/20) == 0 {
+			saved := make(map[string]int, len(ref))
+			for k, v := range ref {
+				saved[k] = v
+			}
+			snapshots = append(snapshots, snapshot{b.Clone(), saved})
+		}
+	}
+
+	for _, s := range snapshots {
+		checkTree(t, s.tree, 4, s.ref)
+	}
+}
+
+func Test_BTree_Snapshot_ShouldAllowReadsDuringWrites(t *testing.T) {
+	var b BTree[string, interface{}] = New(3)
+	for i := 0; i < 1000; i++ {
+		b = b.Insert(strconv.Itoa(i), i)
+	}
+	snap := b.Clone()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			b = b.Delete(strconv.Itoa(i))
+		}
+	}()
+
+	for n := 0; n < 10; n++ {
+		for i := 0; i < 1000; i++ {
+			if snap.Find(strconv.Itoa(i)) != i {
+				t.Fatalf("Expected snapshot to hold %d", i)
+			}
+		}
+	}
+	<-done
+}
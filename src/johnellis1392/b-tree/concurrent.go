@@ -0,0 +1,388 @@
+package btree
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// ConcurrentBTree B-Tree that is safe for use by many goroutines at once
+//
+// Every node carries its own read-write latch and operations use latch
+// crabbing: a goroutine latches a child before letting go of its parent,
+// so readers only ever hold two latches at a time, and writers release
+// every ancestor as soon as they reach a node that cannot split (for
+// Insert) or underflow (for Delete). Writers on different subtrees, and
+// readers anywhere outside a writer's latched path, proceed in parallel.
+//
+// Unlike BTree this is a mutable structure, shared by reference.
+type ConcurrentBTree[K, V any] struct {
+	order   int32
+	compare func(a, b K) int
+
+	// mu Latch on the root pointer, which changes on a root split or
+	// collapse. Writers take it exclusively, readers shared.
+	mu   sync.RWMutex
+	root *cnode[K, V]
+	size atomic.Int64
+}
+
+// cnode Node of a ConcurrentBTree; keys follow the BNode and BLeaf layout
+type cnode[K, V any] struct {
+	mu       sync.RWMutex
+	leaf     bool
+	keys     []K
+	values   []V
+	children []*cnode[K, V]
+}
+
+// NewConcurrent Construct an empty ConcurrentBTree ordered by compare
+func NewConcurrent[K, V any](n int32, compare func(a, b K) int) *ConcurrentBTree[K, V] {
+	if n < 2 {
+		panic("btree: order must be at least 2")
+	}
+	return &ConcurrentBTree[K, V]{
+		order:   n,
+		compare: compare,
+		root:    &cnode[K, V]{leaf: true},
+	}
+}
+
+// NewConcurrentOrdered Construct an empty ConcurrentBTree over keys with a
+// natural ordering
+func NewConcurrentOrdered[K cmp.Ordered, V any](n int32) *ConcurrentBTree[K, V] {
+	return NewConcurrent[K, V](n, cmp.Compare[K])
+}
+
+// Len Number of keys in the tree
+func (t *ConcurrentBTree[K, V]) Len() int {
+	return int(t.size.Load())
+}
+
+// Get Value of key, and whether it is present
+func (t *ConcurrentBTree[K, V]) Get(key K) (V, bool) {
+	n, _ := t.leaf(key)
+	defer n.mu.RUnlock()
+	if i, ok := n.search(t.compare, key); ok {
+		return n.values[i], true
+	}
+	var zero V
+	return zero, false
+}
+
+// leaf Read-latched leaf whose range contains key, and the lower bound of
+// the next leaf to the right, or nil if this is the last leaf.
+func (t *ConcurrentBTree[K, V]) leaf(key K) (*cnode[K, V], *K) {
+	var next *K
+	t.mu.RLock()
+	n := t.root
+	n.mu.RLock()
+	t.mu.RUnlock()
+	for !n.leaf {
+		i := n.child(t.compare, key)
+		if i < len(n.keys) {
+			k := n.keys[i]
+			next = &k
+		}
+		c := n.children[i]
+		c.mu.RLock()
+		n.mu.RUnlock()
+		n = c
+	}
+	return n, next
+}
+
+// Ascend Visit every key in ascending order
+func (t *ConcurrentBTree[K, V]) Ascend(fn Iterator[K, V]) {
+	t.scan(nil, nil, fn)
+}
+
+// AscendRange Visit the keys in [from, to) in ascending order
+//
+// Scans latch one leaf at a time and call fn with no latches held, so fn
+// may use the tree. Keys are visited in strictly ascending order; writes
+// that land behind the scan position are not seen.
+func (t *ConcurrentBTree[K, V]) AscendRange(from, to K, fn Iterator[K, V]) {
+	t.scan(&from, &to, fn)
+}
+
+func (t *ConcurrentBTree[K, V]) scan(from, to *K, fn Iterator[K, V]) {
+	var last *K
+	for {
+		var (
+			n    *cnode[K, V]
+			next *K
+		)
+		if from == nil {
+			n, next = t.first()
+		} else {
+			n, next = t.leaf(*from)
+		}
+
+		var keys []K
+		var values []V
+		for i, k := range n.keys {
+			if (from != nil && t.compare(k, *from) < 0) || (last != nil && t.compare(k, *last) <= 0) {
+				continue
+			}
+			keys = append(keys, k)
+			values = append(values, n.values[i])
+		}
+		n.mu.RUnlock()
+
+		for i, k := range keys {
+			if to != nil && t.compare(k, *to) >= 0 {
+				return
+			}
+			if !fn(k, values[i]) {
+				return
+			}
+			last = &keys[i]
+		}
+		if next == nil {
+			return
+		}
+		from = next
+	}
+}
+
+// first Read-latched leftmost leaf and the lower bound of the leaf after it
+func (t *ConcurrentBTree[K, V]) first() (*cnode[K, V], *K) {
+	var next *K
+	t.mu.RLock()
+	n := t.root
+	n.mu.RLock()
+	t.mu.RUnlock()
+	for !n.leaf {
+		if len(n.keys) > 0 {
+			k := n.keys[0]
+			next = &k
+		}
+		c := n.children[0]
+		c.mu.RLock()
+		n.mu.RUnlock()
+		n = c
+	}
+	return n, next
+}
+
+// latched Write latches held by a writer, from the highest down
+type latched[K, V any] struct {
+	t     *ConcurrentBTree[K, V]
+	root  bool
+	nodes []*cnode[K, V]
+}
+
+// push Latch n, first releasing every ancestor if n is safe. safe is told
+// whether n is the root, since the root has no minimum fill.
+func (l *latched[K, V]) push(n *cnode[K, V], root bool, safe func(n *cnode[K, V], root bool) bool) {
+	n.mu.Lock()
+	if safe(n, root) {
+		l.release()
+	}
+	l.nodes = append(l.nodes, n)
+}
+
+// release Unlatch everything held
+func (l *latched[K, V]) release() {
+	if l.root {
+		l.t.mu.Unlock()
+		l.root = false
+	}
+	for _, n := range l.nodes {
+		n.mu.Unlock()
+	}
+	l.nodes = l.nodes[:0]
+}
+
+// Insert Set the value of key
+func (t *ConcurrentBTree[K, V]) Insert(key K, value V) {
+	safe := func(n *cnode[K, V], root bool) bool {
+		return len(n.keys) < int(t.order)
+	}
+
+	t.mu.Lock()
+	l := &latched[K, V]{t: t, root: true}
+	n := t.root
+	l.push(n, true, safe)
+	for !n.leaf {
+		n = n.children[n.child(t.compare, key)]
+		l.push(n, false, safe)
+	}
+	defer l.release()
+
+	i, ok := n.search(t.compare, key)
+	if ok {
+		n.values[i] = value
+		return
+	}
+	n.keys = slices.Insert(n.keys, i, key)
+	n.values = slices.Insert(n.values, i, value)
+	t.size.Add(1)
+
+	// Every latched node but the highest is full, so splits stop there.
+	for d := len(l.nodes) - 1; d >= 0; d-- {
+		n := l.nodes[d]
+		if len(n.keys) <= int(t.order) {
+			return
+		}
+		sep, r := n.split()
+		if d == 0 {
+			// Only reachable when the root itself was full, in which case
+			// the root latch was never released.
+			t.root = &cnode[K, V]{
+				keys:     []K{sep},
+				children: []*cnode[K, V]{n, r},
+			}
+			return
+		}
+		p := l.nodes[d-1]
+		j := p.child(t.compare, sep)
+		p.keys = slices.Insert(p.keys, j, sep)
+		p.children = slices.Insert(p.children, j+1, r)
+	}
+}
+
+// Delete Remove key, reporting whether it was present
+func (t *ConcurrentBTree[K, V]) Delete(key K) bool {
+	min := minKeys(t.order)
+	safe := func(n *cnode[K, V], root bool) bool {
+		if root {
+			return n.leaf || len(n.keys) > 1
+		}
+		return len(n.keys) > min
+	}
+
+	t.mu.Lock()
+	l := &latched[K, V]{t: t, root: true}
+	n := t.root
+	l.push(n, true, safe)
+	for !n.leaf {
+		n = n.children[n.child(t.compare, key)]
+		l.push(n, false, safe)
+	}
+	defer l.release()
+
+	i, ok := n.search(t.compare, key)
+	if !ok {
+		return false
+	}
+	n.keys = slices.Delete(n.keys, i, i+1)
+	n.values = slices.Delete(n.values, i, i+1)
+	t.size.Add(-1)
+
+	for d := len(l.nodes) - 1; d > 0; d-- {
+		n, p := l.nodes[d], l.nodes[d-1]
+		if len(n.keys) >= min {
+			return true
+		}
+		p.rebalance(t.compare, t.order, p.child(t.compare, key))
+	}
+	// Only a root with a single key can empty, and such a root is unsafe,
+	// so the root latch is still held whenever this applies.
+	if r := l.nodes[0]; l.root && !r.leaf && len(r.keys) == 0 {
+		t.root = r.children[0]
+	}
+	return true
+}
+
+func (n *cnode[K, V]) child(compare func(a, b K) int, key K) int {
+	return sort.Search(len(n.keys), func(i int) bool {
+		return compare(key, n.keys[i]) < 0
+	})
+}
+
+func (n *cnode[K, V]) search(compare func(a, b K) int, key K) (int, bool) {
+	return slices.BinarySearchFunc(n.keys, key, compare)
+}
+
+// split Move the upper half of an overfull node into a new right sibling
+func (n *cnode[K, V]) split() (K, *cnode[K, V]) {
+	mid := len(n.keys) / 2
+	r := &cnode[K, V]{leaf: n.leaf}
+	var sep K
+	if n.leaf {
+		r.keys, r.values = slices.Clone(n.keys[mid:]), slices.Clone(n.values[mid:])
+		n.keys, n.values = slices.Clip(n.keys[:mid]), slices.Clip(n.values[:mid])
+		sep = r.keys[0]
+	} else {
+		r.keys, r.children = slices.Clone(n.keys[mid+1:]), slices.Clone(n.children[mid+1:])
+		sep = n.keys[mid]
+		n.keys, n.children = slices.Clip(n.keys[:mid]), slices.Clip(n.children[:mid+1])
+	}
+	return sep, r
+}
+
+// rebalance Restore the minimum fill of child i, as BNode.rebalance does.
+// The caller holds n's write latch; the siblings involved are latched here.
+func (n *cnode[K, V]) rebalance(compare func(a, b K) int, order int32, i int) {
+	c := n.children[i]
+	if i > 0 {
+		l := n.children[i-1]
+		l.mu.Lock()
+		if len(l.keys) > minKeys(order) {
+			last := len(l.keys) - 1
+			if c.leaf {
+				c.keys = slices.Insert(c.keys, 0, l.keys[last])
+				c.values = slices.Insert(c.values, 0, l.values[last])
+				l.keys, l.values = l.keys[:last], l.values[:last]
+				n.keys[i-1] = c.keys[0]
+			} else {
+				c.keys = slices.Insert(c.keys, 0, n.keys[i-1])
+				c.children = slices.Insert(c.children, 0, l.children[last+1])
+				n.keys[i-1] = l.keys[last]
+				l.keys, l.children = l.keys[:last], l.children[:last+1]
+			}
+			l.mu.Unlock()
+			return
+		}
+		l.mu.Unlock()
+	}
+	if i < len(n.children)-1 {
+		r := n.children[i+1]
+		r.mu.Lock()
+		if len(r.keys) > minKeys(order) {
+			if c.leaf {
+				c.keys = append(c.keys, r.keys[0])
+				c.values = append(c.values, r.values[0])
+				r.keys, r.values = slices.Delete(r.keys, 0, 1), slices.Delete(r.values, 0, 1)
+				n.keys[i] = r.keys[0]
+			} else {
+				c.keys = append(c.keys, n.keys[i])
+				c.children = append(c.children, r.children[0])
+				n.keys[i] = r.keys[0]
+				r.keys, r.children = slices.Delete(r.keys, 0, 1), slices.Delete(r.children, 0, 1)
+			}
+			r.mu.Unlock()
+			return
+		}
+		r.mu.Unlock()
+	}
+
+	// Merge child j+1 into child j. The right node is unlinked while
+	// latched, so a reader that reached it before the merge finishes
+	// reading a consistent, if stale, node.
+	j := i
+	if i > 0 {
+		j = i - 1
+	}
+	l, r := n.children[j], n.children[j+1]
+	if l != c {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	} else {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+	}
+	if l.leaf {
+		l.keys = append(l.keys, r.keys...)
+		l.values = append(l.values, r.values...)
+	} else {
+		l.keys = append(append(l.keys, n.keys[j]), r.keys...)
+		l.children = append(l.children, r.children...)
+	}
+	n.keys = slices.Delete(n.keys, j, j+1)
+	n.children = slices.Delete(n.children, j+1, j+2)
+}
@@ -0,0 +1,193 @@
+package btree
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func Test_ConcurrentBTree_ShouldMatchReferenceMap(t *testing.T) {
+	for _, order := range []int32{2, 3, 8} {
+		rng := rand.New(rand.NewSource(int64(order)))
+		b := NewConcurrentOrdered[int, int](order)
+		ref := make(map[int]int)
+		for i := 0; i < 100000; i++ {
+			k := rng.Intn(1000)
+			switch rng.Intn(3) {
+			case 0:
+				b.Insert(k, i)
+				ref[k] = i
+			case 1:
+				_, ok := ref[k]
+				if b.Delete(k) != ok {
+					t.Fatalf("order %d, op %d: Delete(%d) disagrees with reference", order, i, k)
+				}
+				delete(ref, k)
+			case 2:
+				v, ok := b.Get(k)
+				if r, inRef := ref[k]; ok != inRef || v != r {
+					t.Fatalf("order %d, op %d: Get(%d) = %d, %v", order, i, k, v, ok)
+				}
+			}
+		}
+
+		if b.Len() != len(ref) {
+			t.Fatalf("order %d: Len() = %d, expected %d", order, b.Len(), len(ref))
+		}
+		prev, n := -1, 0
+		b.Ascend(func(k, v int) bool {
+			if k <= prev || ref[k] != v {
+				t.Fatalf("order %d: scan returned %d=%d after %d", order, k, v, prev)
+			}
+			prev = k
+			n++
+			return true
+		})
+		if n != len(ref) {
+			t.Fatalf("order %d: scan returned %d keys, expected %d", order, n, len(ref))
+		}
+	}
+}
+
+// Test_ConcurrentBTree_ShouldBeLinearizable Each key has a single writer
+// that stores increasing versions. A Get must return a version no older
+// than the last write completed before it began, and no newer than the
+// last write begun before it ended.
+func Test_ConcurrentBTree_ShouldBeLinearizable(t *testing.T) {
+	const (
+		writers = 4
+		readers = 4
+		keys    = 512
+	)
+	ops := 20000
+	if testing.Short() {
+		ops = 2000
+	}
+
+	b := NewConcurrentOrdered[int, int64](3)
+	var started, done [keys]atomic.Int64
+
+	var wg sync.WaitGroup
+	var stop atomic.Bool
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(w)))
+			for i := 1; i <= ops; i++ {
+				k := rng.Intn(keys/writers)*writers + w
+				v := started[k].Load() + 1
+				started[k].Store(v)
+				b.Insert(k, v)
+				done[k].Store(v)
+			}
+		}(w)
+	}
+
+	var rg sync.WaitGroup
+	for r := 0; r < readers; r++ {
+		rg.Add(1)
+		go func(r int) {
+			defer rg.Done()
+			rng := rand.New(rand.NewSource(int64(100 + r)))
+			for !stop.Load() {
+				k := rng.Intn(keys)
+				lo := done[k].Load()
+				v, _ := b.Get(k)
+				hi := started[k].Load()
+				if v < lo || v > hi {
+					t.Errorf("Get(%d) = %d, outside [%d, %d]", k, v, lo, hi)
+					return
+				}
+			}
+		}(r)
+	}
+
+	wg.Wait()
+	stop.Store(true)
+	rg.Wait()
+
+	for k := 0; k < keys; k++ {
+		if v, _ := b.Get(k); v != done[k].Load() {
+			t.Errorf("key %d holds %d, expected %d", k, v, done[k].Load())
+		}
+	}
+}
+
+// Test_ConcurrentBTree_ShouldSurviveMixedWorkload Writers insert and
+// delete disjoint key sets while readers look up and scan, then the final
+// tree must equal the union of the writers' reference maps.
+func Test_ConcurrentBTree_ShouldSurviveMixedWorkload(t *testing.T) {
+	const writers = 4
+	ops := 20000
+	if testing.Short() {
+		ops = 2000
+	}
+
+	b := NewConcurrentOrdered[int, int](2)
+	refs := make([]map[int]int, writers)
+
+	var wg sync.WaitGroup
+	var stop atomic.Bool
+	for w := 0; w < writers; w++ {
+		refs[w] = make(map[int]int)
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(w)))
+			ref := refs[w]
+			for i := 0; i < ops; i++ {
+				k := rng.Intn(500)*writers + w
+				if rng.Intn(2) == 0 {
+					b.Insert(k, k*10)
+					ref[k] = k * 10
+				} else {
+					b.Delete(k)
+					delete(ref, k)
+				}
+				r, inRef := ref[k]
+				if v, ok := b.Get(k); ok != inRef || v != r {
+					t.Errorf("writer %d lost its own write to %d", w, k)
+					return
+				}
+			}
+		}(w)
+	}
+
+	var rg sync.WaitGroup
+	for r := 0; r < 2; r++ {
+		rg.Add(1)
+		go func() {
+			defer rg.Done()
+			for !stop.Load() {
+				prev := -1
+				b.AscendRange(100, 1500, func(k, v int) bool {
+					if k <= prev || k < 100 || k >= 1500 || v != k*10 {
+						t.Errorf("scan returned %d=%d after %d", k, v, prev)
+						return false
+					}
+					prev = k
+					return true
+				})
+			}
+		}()
+	}
+
+	wg.Wait()
+	stop.Store(true)
+	rg.Wait()
+
+	n := 0
+	for _, ref := range refs {
+		for k, v := range ref {
+			if got, ok := b.Get(k); !ok || got != v {
+				t.Errorf("key %d holds %d, expected %d", k, got, v)
+			}
+		}
+		n += len(ref)
+	}
+	if b.Len() != n {
+		t.Errorf("Len() = %d, expected %d", b.Len(), n)
+	}
+}
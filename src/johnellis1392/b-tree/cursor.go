@@ -0,0 +1,269 @@
+package btree
+
+import "strings"
+
+// Iterator Callback for ordered scans; returning false stops the scan
+type Iterator[K, V any] func(key K, value V) bool
+
+// Cursor Position within the ordered key sequence of a BTree
+//
+// A cursor records the path from the root down to its current leaf, so it
+// can step across leaf boundaries in either direction. Because trees are
+// persistent, a cursor keeps reading the tree it was created from even
+// while newer versions are being built from it.
+//
+// Stepping past either end leaves the cursor invalid but still anchored at
+// that end, so Prev after running off the end returns the last key, and
+// Next after running off the start returns the first.
+type Cursor[K, V any] struct {
+	path []frame[K, V]
+}
+
+// frame One level of a cursor's path; for a BNode i is the index of the
+// child being visited, and for a BLeaf it is the index of the current key.
+type frame[K, V any] struct {
+	node BTree[K, V]
+	i    int
+}
+
+// seek Cursor at the first key >= key
+func seek[K, V any](root BTree[K, V], key K) *Cursor[K, V] {
+	c := &Cursor[K, V]{}
+	n := root
+	for {
+		switch x := n.(type) {
+		case BNode[K, V]:
+			i := x.child(key)
+			c.path = append(c.path, frame[K, V]{x, i})
+			n = x.Children[i]
+		case BLeaf[K, V]:
+			i, _ := x.search(key)
+			c.path = append(c.path, frame[K, V]{x, i})
+			if i == len(x.Keys) && i > 0 {
+				// Every key in this leaf is smaller; the answer is the first
+				// key of the next leaf, if there is one.
+				c.leaf().i = i - 1
+				c.Next()
+			}
+			return c
+		}
+	}
+}
+
+// first Cursor at the smallest key
+func first[K, V any](root BTree[K, V]) *Cursor[K, V] {
+	c := &Cursor[K, V]{}
+	c.descend(root, false)
+	return c
+}
+
+// last Cursor at the largest key
+func last[K, V any](root BTree[K, V]) *Cursor[K, V] {
+	c := &Cursor[K, V]{}
+	c.descend(root, true)
+	return c
+}
+
+// descend Extend the path from n down to its leftmost or rightmost key
+func (c *Cursor[K, V]) descend(n BTree[K, V], right bool) {
+	for {
+		switch x := n.(type) {
+		case BNode[K, V]:
+			i := 0
+			if right {
+				i = len(x.Children) - 1
+			}
+			c.path = append(c.path, frame[K, V]{x, i})
+			n = x.Children[i]
+		case BLeaf[K, V]:
+			i := 0
+			if right {
+				i = len(x.Keys) - 1
+			}
+			c.path = append(c.path, frame[K, V]{x, i})
+			return
+		}
+	}
+}
+
+// leaf Frame of the leaf the cursor is in
+func (c *Cursor[K, V]) leaf() *frame[K, V] {
+	return &c.path[len(c.path)-1]
+}
+
+// Valid Whether the cursor is positioned at a key
+func (c *Cursor[K, V]) Valid() bool {
+	f := c.leaf()
+	return f.i >= 0 && f.i < len(f.node.(BLeaf[K, V]).Keys)
+}
+
+// Key Key at the cursor; only meaningful while Valid
+func (c *Cursor[K, V]) Key() K {
+	f := c.leaf()
+	return f.node.(BLeaf[K, V]).Keys[f.i]
+}
+
+// Value Value at the cursor; only meaningful while Valid
+func (c *Cursor[K, V]) Value() V {
+	f := c.leaf()
+	return f.node.(BLeaf[K, V]).Values[f.i]
+}
+
+// Next Advance to the next larger key, reporting whether there is one
+func (c *Cursor[K, V]) Next() bool {
+	f := c.leaf()
+	n := len(f.node.(BLeaf[K, V]).Keys)
+	if f.i+1 < n {
+		f.i++
+		return true
+	}
+
+	// Climb to the deepest node with a child to the right of our path.
+	for d := len(c.path) - 2; d >= 0; d-- {
+		p := &c.path[d]
+		if p.i+1 < len(p.node.(BNode[K, V]).Children) {
+			p.i++
+			c.path = c.path[:d+1]
+			c.descend(p.node.(BNode[K, V]).Children[p.i], false)
+			return true
+		}
+	}
+	f.i = n
+	return false
+}
+
+// Prev Step back to the next smaller key, reporting whether there is one
+func (c *Cursor[K, V]) Prev() bool {
+	f := c.leaf()
+	if f.i > 0 {
+		f.i--
+		return true
+	}
+
+	// Climb to the deepest node with a child to the left of our path.
+	for d := len(c.path) - 2; d >= 0; d-- {
+		p := &c.path[d]
+		if p.i > 0 {
+			p.i--
+			c.path = c.path[:d+1]
+			c.descend(p.node.(BNode[K, V]).Children[p.i], true)
+			return true
+		}
+	}
+	f.i = -1
+	return false
+}
+
+// ascend Visit keys from c onwards while they are < to, or all of them
+// when to is nil.
+func ascend[K, V any](c *Cursor[K, V], to *K, fn Iterator[K, V]) {
+	for ok := c.Valid(); ok; ok = c.Next() {
+		if to != nil && c.leaf().node.(BLeaf[K, V]).Compare(c.Key(), *to) >= 0 {
+			return
+		}
+		if !fn(c.Key(), c.Value()) {
+			return
+		}
+	}
+}
+
+// descend Visit keys from c backwards
+func descend[K, V any](c *Cursor[K, V], fn Iterator[K, V]) {
+	for ok := c.Valid(); ok; ok = c.Prev() {
+		if !fn(c.Key(), c.Value()) {
+			return
+		}
+	}
+}
+
+// AscendPrefix Visit the keys of a string-keyed tree that start with
+// prefix, in ascending order
+func AscendPrefix[V any](b BTree[string, V], prefix string, fn Iterator[string, V]) {
+	ascend(b.Seek(prefix), nil, func(key string, value V) bool {
+		return strings.HasPrefix(key, prefix) && fn(key, value)
+	})
+}
+
+// minimum Smallest key in the tree and its value
+func minimum[K, V any](root BTree[K, V]) (K, V, bool) {
+	if c := first(root); c.Valid() {
+		return c.Key(), c.Value(), true
+	}
+	var (
+		k K
+		v V
+	)
+	return k, v, false
+}
+
+// maximum Largest key in the tree and its value
+func maximum[K, V any](root BTree[K, V]) (K, V, bool) {
+	if c := last(root); c.Valid() {
+		return c.Key(), c.Value(), true
+	}
+	var (
+		k K
+		v V
+	)
+	return k, v, false
+}
+
+// Ascend Visit every key in ascending order
+func (b BNode[K, V]) Ascend(fn Iterator[K, V]) {
+	ascend(first(b), nil, fn)
+}
+
+// Descend Visit every key in descending order
+func (b BNode[K, V]) Descend(fn Iterator[K, V]) {
+	descend(last(b), fn)
+}
+
+// AscendRange Visit the keys in [from, to) in ascending order
+func (b BNode[K, V]) AscendRange(from, to K, fn Iterator[K, V]) {
+	ascend(seek(b, from), &to, fn)
+}
+
+// Min ...
+func (b BNode[K, V]) Min() (K, V, bool) {
+	return minimum(b)
+}
+
+// Max ...
+func (b BNode[K, V]) Max() (K, V, bool) {
+	return maximum(b)
+}
+
+// Seek Cursor at the first key >= key
+func (b BNode[K, V]) Seek(key K) *Cursor[K, V] {
+	return seek(b, key)
+}
+
+// Ascend Visit every key in ascending order
+func (b BLeaf[K, V]) Ascend(fn Iterator[K, V]) {
+	ascend(first(b), nil, fn)
+}
+
+// Descend Visit every key in descending order
+func (b BLeaf[K, V]) Descend(fn Iterator[K, V]) {
+	descend(last(b), fn)
+}
+
+// AscendRange Visit the keys in [from, to) in ascending order
+func (b BLeaf[K, V]) AscendRange(from, to K, fn Iterator[K, V]) {
+	ascend(seek(b, from), &to, fn)
+}
+
+// Min ...
+func (b BLeaf[K, V]) Min() (K, V, bool) {
+	return minimum(b)
+}
+
+// Max ...
+func (b BLeaf[K, V]) Max() (K, V, bool) {
+	return maximum(b)
+}
+
+// Seek Cursor at the first key >= key
+func (b BLeaf[K, V]) Seek(key K) *Cursor[K, V] {
+	return seek(b, key)
+}
@@ -0,0 +1,131 @@
+package btree
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// build Tree of the given order holding keys k000 .. k(n-1), valued by index
+func build(order int32, n int) BTree[string, interface{}] {
+	b := New(order)
+	for i := 0; i < n; i++ {
+		b = b.Insert(fmt.Sprintf("k%03d", i), i)
+	}
+	return b
+}
+
+func collect(scan func(Iterator[string, interface{}])) []string {
+	var keys []string
+	scan(func(key string, value interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+func Test_BTree_Ascend_ShouldVisitKeysInOrder(t *testing.T) {
+	for _, order := range []int32{2, 3, 5} {
+		b := build(order, 200)
+		keys := collect(b.Ascend)
+		if len(keys) != 200 || !sort.StringsAreSorted(keys) {
+			t.Errorf("order %d: Expected 200 sorted keys, got: %q", order, keys)
+		}
+	}
+}
+
+func Test_BTree_Descend_ShouldVisitKeysInReverseOrder(t *testing.T) {
+	b := build(3, 200)
+	keys := collect(b.Descend)
+	if len(keys) != 200 || !sort.IsSorted(sort.Reverse(sort.StringSlice(keys))) {
+		t.Errorf("Expected 200 keys in reverse order, got: %q", keys)
+	}
+}
+
+func Test_BTree_Ascend_ShouldStopWhenIteratorReturnsFalse(t *testing.T) {
+	b := build(3, 50)
+	n := 0
+	b.Ascend(func(key string, value interface{}) bool {
+		n++
+		return n < 10
+	})
+	if n != 10 {
+		t.Errorf("Expected 10 visits, got: %d", n)
+	}
+}
+
+func Test_BTree_AscendRange_ShouldVisitHalfOpenRange(t *testing.T) {
+	b := build(3, 200)
+	keys := collect(func(fn Iterator[string, interface{}]) { b.AscendRange("k050", "k060", fn) })
+	expected := []string{"k050", "k051", "k052", "k053", "k054", "k055", "k056", "k057", "k058", "k059"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected %q, got: %q", expected, keys)
+	}
+}
+
+func Test_BTree_AscendPrefix_ShouldVisitMatchingKeys(t *testing.T) {
+	b := build(4, 200)
+	keys := collect(func(fn Iterator[string, interface{}]) { AscendPrefix(b, "k12", fn) })
+	if len(keys) != 10 || keys[0] != "k120" || keys[9] != "k129" {
+		t.Errorf("Expected k120 .. k129, got: %q", keys)
+	}
+}
+
+func Test_BTree_MinMax(t *testing.T) {
+	b := build(3, 100)
+	if k, v, ok := b.Min(); !ok || k != "k000" || v != 0 {
+		t.Errorf("Expected min k000=0, got: %q=%v", k, v)
+	}
+	if k, v, ok := b.Max(); !ok || k != "k099" || v != 99 {
+		t.Errorf("Expected max k099=99, got: %q=%v", k, v)
+	}
+	if _, _, ok := New(3).Min(); ok {
+		t.Errorf("Expected no min in empty tree")
+	}
+}
+
+func Test_Cursor_Seek_ShouldFindFirstKeyNotLess(t *testing.T) {
+	b := build(2, 100)
+	cases := map[string]string{
+		"":      "k000",
+		"k042":  "k042",
+		"k042a": "k043",
+		"k0":    "k000",
+	}
+	for key, expected := range cases {
+		if c := b.Seek(key); !c.Valid() || c.Key() != expected {
+			t.Errorf("Seek(%q): Expected %q", key, expected)
+		}
+	}
+	if c := b.Seek("z"); c.Valid() {
+		t.Errorf("Seek past end: Expected invalid cursor, got: %q", c.Key())
+	}
+}
+
+func Test_Cursor_NextPrev_ShouldCrossLeaves(t *testing.T) {
+	b := build(2, 100)
+	c := b.Seek("k050")
+	for i := 50; i < 99; i++ {
+		if !c.Next() || c.Key() != fmt.Sprintf("k%03d", i+1) {
+			t.Fatalf("Next from k%03d: got %q", i, c.Key())
+		}
+	}
+	if c.Next() || c.Valid() {
+		t.Fatalf("Expected Next past the end to fail")
+	}
+	if !c.Prev() || c.Key() != "k099" {
+		t.Fatalf("Expected Prev from the end to return k099")
+	}
+	for i := 99; i > 0; i-- {
+		if !c.Prev() || c.Value() != i-1 {
+			t.Fatalf("Prev from k%03d: got %q", i, c.Key())
+		}
+	}
+	if c.Prev() || c.Valid() {
+		t.Fatalf("Expected Prev past the start to fail")
+	}
+	if !c.Next() || c.Key() != "k000" {
+		t.Fatalf("Expected Next from the start to return k000")
+	}
+}
@@ -0,0 +1,95 @@
+package btree
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// debugNode JSON shape of a node in DumpJSON output
+type debugNode[K, V any] struct {
+	Leaf     bool              `json:"leaf"`
+	Keys     []K               `json:"keys"`
+	Values   []V               `json:"values,omitempty"`
+	Children []debugNode[K, V] `json:"children,omitempty"`
+}
+
+// DumpJSON Indented JSON rendering of the structure of t, for debugging
+func DumpJSON[K, V any](t BTree[K, V]) ([]byte, error) {
+	order, _ := t.(node[K, V]).config()
+	return json.MarshalIndent(struct {
+		Order int32           `json:"order"`
+		Root  debugNode[K, V] `json:"root"`
+	}{order, toDebug(t)}, "", "  ")
+}
+
+func toDebug[K, V any](t BTree[K, V]) debugNode[K, V] {
+	switch x := t.(type) {
+	case BLeaf[K, V]:
+		return debugNode[K, V]{Leaf: true, Keys: x.Keys, Values: x.Values}
+	case BNode[K, V]:
+		d := debugNode[K, V]{Keys: x.Keys}
+		for _, c := range x.Children {
+			d.Children = append(d.Children, toDebug(c))
+		}
+		return d
+	}
+	return debugNode[K, V]{}
+}
+
+// Graphviz Write t to w as a Graphviz digraph, one record per node with
+// an edge from each gap between keys to the child it leads to.
+func Graphviz[K, V any](w io.Writer, t BTree[K, V]) error {
+	g := &graph{w: w}
+	g.printf("digraph btree {\n\tnode [shape=record];\n")
+	graphNode(g, t)
+	g.printf("}\n")
+	return g.err
+}
+
+// graph Output state for Graphviz, remembering the first write error
+type graph struct {
+	w   io.Writer
+	n   int
+	err error
+}
+
+func (g *graph) printf(format string, args ...interface{}) {
+	if g.err == nil {
+		_, g.err = fmt.Fprintf(g.w, format, args...)
+	}
+}
+
+// graphNode Emit t and its subtree, returning t's node name
+func graphNode[K, V any](g *graph, t BTree[K, V]) string {
+	name := fmt.Sprintf("n%d", g.n)
+	g.n++
+
+	var fields []string
+	switch x := t.(type) {
+	case BLeaf[K, V]:
+		for i, k := range x.Keys {
+			fields = append(fields, escapeRecord(fmt.Sprintf("%v: %v", k, x.Values[i])))
+		}
+		g.printf("\t%s [label=\"%s\", style=filled, fillcolor=lightgrey];\n", name, strings.Join(fields, "|"))
+	case BNode[K, V]:
+		for i, k := range x.Keys {
+			fields = append(fields, fmt.Sprintf("<c%d>", i), escapeRecord(fmt.Sprint(k)))
+		}
+		fields = append(fields, fmt.Sprintf("<c%d>", len(x.Keys)))
+		g.printf("\t%s [label=\"%s\"];\n", name, strings.Join(fields, "|"))
+		for i, c := range x.Children {
+			g.printf("\t%s:c%d -> %s;\n", name, i, graphNode(g, c))
+		}
+	}
+	return name
+}
+
+// escapeRecord Escape characters that are special in record labels
+func escapeRecord(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`, `"`, `\"`, `|`, `\|`,
+		`{`, `\{`, `}`, `\}`, `<`, `\<`, `>`, `\>`,
+	).Replace(s)
+}
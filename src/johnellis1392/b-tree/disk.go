@@ -0,0 +1,532 @@
+package btree
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Options Settings for a disk-backed tree
+//
+// Order and PageSize only apply when the file is created; an existing file
+// keeps the values recorded in its header page.
+type Options struct {
+	Order     int32
+	PageSize  int
+	CacheSize int
+}
+
+// DiskTree B-Tree of string keys and byte values stored in a paged file
+//
+// Each BNode and BLeaf occupies one page. Changes are staged in memory and
+// become durable together on Commit, which goes through the write-ahead
+// log, so after a crash the file reopens in the state of the last commit.
+type DiskTree struct {
+	p *pager
+}
+
+// pageNode Decoded contents of a node page
+//
+//	[kind byte][key count uint16]
+//	leaf: ([key length uint16][key][value length uint32][value])...
+//	node: [child uint32] ([key length uint16][key][child uint32])...
+type pageNode struct {
+	leaf     bool
+	keys     []string
+	values   [][]byte
+	children []pageID
+}
+
+const (
+	kindLeaf = 1
+	kindNode = 2
+
+	// nodeHeader Bytes before the first entry of a node page
+	nodeHeader = 3
+)
+
+// DefaultOrder Order used when Options.Order is zero
+const DefaultOrder = 32
+
+// Open Open the tree stored at path, creating it if it does not exist
+func Open(path string, opts Options) (*DiskTree, error) {
+	if opts.Order == 0 {
+		opts.Order = DefaultOrder
+	}
+	if opts.Order < 2 {
+		return nil, fmt.Errorf("btree: order must be at least 2, got %d", opts.Order)
+	}
+	if opts.PageSize == 0 {
+		opts.PageSize = DefaultPageSize
+	}
+	if opts.CacheSize == 0 {
+		opts.CacheSize = DefaultCacheSize
+	}
+
+	p, err := openPager(path, opts.Order, opts.PageSize, opts.CacheSize)
+	if err != nil {
+		return nil, err
+	}
+	t := &DiskTree{p: p}
+	if p.meta.root != 0 {
+		return t, nil
+	}
+
+	// New file: write an empty root leaf.
+	root, err := p.allocate()
+	if err != nil {
+		p.close()
+		return nil, err
+	}
+	p.meta.root = root
+	if err := t.store(root, &pageNode{leaf: true}); err != nil {
+		p.close()
+		return nil, err
+	}
+	if err := p.commit(); err != nil {
+		p.close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// Order Maximum keys per node, as recorded in the file
+func (t *DiskTree) Order() int32 {
+	return t.p.meta.order
+}
+
+// Get Value of key, and whether it is present
+func (t *DiskTree) Get(key string) ([]byte, bool, error) {
+	if t.p == nil {
+		return nil, false, ErrClosed
+	}
+	id := t.p.meta.root
+	for {
+		n, err := t.load(id)
+		if err != nil {
+			return nil, false, err
+		}
+		if !n.leaf {
+			id = n.children[n.child(key)]
+			continue
+		}
+		if i, ok := n.search(key); ok {
+			return n.values[i], true, nil
+		}
+		return nil, false, nil
+	}
+}
+
+// Insert Set the value of key
+//
+// If Insert or Delete fail part way through, every change since the last
+// commit is discarded so the tree is never left half-modified.
+func (t *DiskTree) Insert(key string, value []byte) error {
+	if t.p == nil {
+		return ErrClosed
+	}
+	if !t.fits(key, value) {
+		return ErrTooLarge
+	}
+
+	root := t.p.meta.root
+	sep, right, err := t.insert(root, key, value)
+	if err != nil {
+		t.p.rollback()
+		return err
+	}
+	if right == 0 {
+		return nil
+	}
+
+	id, err := t.p.allocate()
+	if err != nil {
+		t.p.rollback()
+		return err
+	}
+	t.p.meta.root = id
+	if err := t.store(id, &pageNode{keys: []string{sep}, children: []pageID{root, right}}); err != nil {
+		t.p.rollback()
+		return err
+	}
+	return nil
+}
+
+// Delete Remove key, reporting whether it was present
+func (t *DiskTree) Delete(key string) (bool, error) {
+	if t.p == nil {
+		return false, ErrClosed
+	}
+
+	root := t.p.meta.root
+	found, err := t.remove(root, key)
+	if err != nil || !found {
+		if err != nil {
+			t.p.rollback()
+		}
+		return false, err
+	}
+
+	n, err := t.load(root)
+	if err != nil {
+		t.p.rollback()
+		return false, err
+	}
+	if !n.leaf && len(n.keys) == 0 {
+		t.p.meta.root = n.children[0]
+		t.p.release(root)
+	}
+	return true, nil
+}
+
+// Ascend Visit every key in ascending order
+func (t *DiskTree) Ascend(fn func(key string, value []byte) bool) error {
+	if t.p == nil {
+		return ErrClosed
+	}
+	_, err := t.ascend(t.p.meta.root, fn)
+	return err
+}
+
+func (t *DiskTree) ascend(id pageID, fn func(key string, value []byte) bool) (bool, error) {
+	n, err := t.load(id)
+	if err != nil {
+		return false, err
+	}
+	if n.leaf {
+		for i, k := range n.keys {
+			if !fn(k, n.values[i]) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	for _, c := range n.children {
+		if more, err := t.ascend(c, fn); !more || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Commit Durably write every change since the last commit
+func (t *DiskTree) Commit() error {
+	if t.p == nil {
+		return ErrClosed
+	}
+	return t.p.commit()
+}
+
+// Rollback Discard every change since the last commit
+func (t *DiskTree) Rollback() {
+	if t.p != nil {
+		t.p.rollback()
+	}
+}
+
+// Close Commit outstanding changes and close the file
+func (t *DiskTree) Close() error {
+	if t.p == nil {
+		return ErrClosed
+	}
+	err := t.p.commit()
+	if e := t.p.close(); err == nil {
+		err = e
+	}
+	t.p = nil
+	return err
+}
+
+// fits Whether an entry is small enough that a full node of entries like
+// it still fits in one page.
+func (t *DiskTree) fits(key string, value []byte) bool {
+	room := (t.p.pageSize - nodeHeader - 4) / int(t.p.meta.order)
+	return len(key) <= 0xFFFF && 6+len(key)+len(value) <= room
+}
+
+func (t *DiskTree) insert(id pageID, key string, value []byte) (string, pageID, error) {
+	n, err := t.load(id)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if n.leaf {
+		i, ok := n.search(key)
+		if ok {
+			n.values[i] = value
+			return "", 0, t.store(id, n)
+		}
+		n.keys = slices.Insert(n.keys, i, key)
+		n.values = slices.Insert(n.values, i, value)
+	} else {
+		i := n.child(key)
+		sep, right, err := t.insert(n.children[i], key, value)
+		if err != nil || right == 0 {
+			return "", 0, err
+		}
+		n.keys = slices.Insert(n.keys, i, sep)
+		n.children = slices.Insert(n.children, i+1, right)
+	}
+	if len(n.keys) <= int(t.p.meta.order) {
+		return "", 0, t.store(id, n)
+	}
+
+	// Split, writing the right half to a new page.
+	r := &pageNode{leaf: n.leaf}
+	mid := len(n.keys) / 2
+	var sep string
+	if n.leaf {
+		r.keys, r.values = slices.Clone(n.keys[mid:]), slices.Clone(n.values[mid:])
+		n.keys, n.values = n.keys[:mid], n.values[:mid]
+		sep = r.keys[0]
+	} else {
+		r.keys, r.children = slices.Clone(n.keys[mid+1:]), slices.Clone(n.children[mid+1:])
+		sep = n.keys[mid]
+		n.keys, n.children = n.keys[:mid], n.children[:mid+1]
+	}
+	rid, err := t.p.allocate()
+	if err != nil {
+		return "", 0, err
+	}
+	if err := t.store(id, n); err != nil {
+		return "", 0, err
+	}
+	return sep, rid, t.store(rid, r)
+}
+
+func (t *DiskTree) remove(id pageID, key string) (bool, error) {
+	n, err := t.load(id)
+	if err != nil {
+		return false, err
+	}
+
+	if n.leaf {
+		i, ok := n.search(key)
+		if !ok {
+			return false, nil
+		}
+		n.keys = slices.Delete(n.keys, i, i+1)
+		n.values = slices.Delete(n.values, i, i+1)
+		return true, t.store(id, n)
+	}
+
+	i := n.child(key)
+	found, err := t.remove(n.children[i], key)
+	if err != nil || !found {
+		return found, err
+	}
+	c, err := t.load(n.children[i])
+	if err != nil {
+		return false, err
+	}
+	if len(c.keys) >= minKeys(t.p.meta.order) {
+		return true, nil
+	}
+	return true, t.rebalance(id, n, i, c)
+}
+
+// rebalance Restore the minimum fill of child i of n, as in
+// BNode.rebalance, writing every page it changes.
+func (t *DiskTree) rebalance(id pageID, n *pageNode, i int, c *pageNode) error {
+	min := minKeys(t.p.meta.order)
+	if i > 0 {
+		l, err := t.load(n.children[i-1])
+		if err != nil {
+			return err
+		}
+		if len(l.keys) > min {
+			last := len(l.keys) - 1
+			if c.leaf {
+				c.keys = slices.Insert(c.keys, 0, l.keys[last])
+				c.values = slices.Insert(c.values, 0, l.values[last])
+				l.keys, l.values = l.keys[:last], l.values[:last]
+				n.keys[i-1] = c.keys[0]
+			} else {
+				c.keys = slices.Insert(c.keys, 0, n.keys[i-1])
+				c.children = slices.Insert(c.children, 0, l.children[last+1])
+				n.keys[i-1] = l.keys[last]
+				l.keys, l.children = l.keys[:last], l.children[:last+1]
+			}
+			return t.storeEach([]pageID{id, n.children[i-1], n.children[i]}, n, l, c)
+		}
+	}
+	if i < len(n.children)-1 {
+		r, err := t.load(n.children[i+1])
+		if err != nil {
+			return err
+		}
+		if len(r.keys) > min {
+			if c.leaf {
+				c.keys = append(c.keys, r.keys[0])
+				c.values = append(c.values, r.values[0])
+				r.keys, r.values = r.keys[1:], r.values[1:]
+				n.keys[i] = r.keys[0]
+			} else {
+				c.keys = append(c.keys, n.keys[i])
+				c.children = append(c.children, r.children[0])
+				n.keys[i] = r.keys[0]
+				r.keys, r.children = r.keys[1:], r.children[1:]
+			}
+			return t.storeEach([]pageID{id, n.children[i], n.children[i+1]}, n, c, r)
+		}
+	}
+
+	// Merge child j+1 into child j and free its page.
+	j := i
+	if i > 0 {
+		j = i - 1
+	}
+	l, err := t.load(n.children[j])
+	if err != nil {
+		return err
+	}
+	r, err := t.load(n.children[j+1])
+	if err != nil {
+		return err
+	}
+	if l.leaf {
+		l.keys = append(l.keys, r.keys...)
+		l.values = append(l.values, r.values...)
+	} else {
+		l.keys = append(append(l.keys, n.keys[j]), r.keys...)
+		l.children = append(l.children, r.children...)
+	}
+	t.p.release(n.children[j+1])
+	n.keys = slices.Delete(n.keys, j, j+1)
+	n.children = slices.Delete(n.children, j+1, j+2)
+	return t.storeEach([]pageID{id, n.children[j]}, n, l)
+}
+
+// storeEach Encode each node into the matching page
+func (t *DiskTree) storeEach(ids []pageID, nodes ...*pageNode) error {
+	for i, n := range nodes {
+		if err := t.store(ids[i], n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// load Decode the node in page id
+func (t *DiskTree) load(id pageID) (*pageNode, error) {
+	buf, err := t.p.read(id)
+	if err != nil {
+		return nil, err
+	}
+	return decodePageNode(buf)
+}
+
+// store Encode n into page id
+func (t *DiskTree) store(id pageID, n *pageNode) error {
+	buf, err := n.encode(t.p.pageSize)
+	if err != nil {
+		return err
+	}
+	t.p.write(id, buf)
+	return nil
+}
+
+func (n *pageNode) child(key string) int {
+	return sort.Search(len(n.keys), func(i int) bool {
+		return key < n.keys[i]
+	})
+}
+
+func (n *pageNode) search(key string) (int, bool) {
+	i := sort.SearchStrings(n.keys, key)
+	return i, i < len(n.keys) && n.keys[i] == key
+}
+
+func (n *pageNode) encode(pageSize int) ([]byte, error) {
+	buf := make([]byte, nodeHeader, pageSize)
+	buf[0] = kindNode
+	if n.leaf {
+		buf[0] = kindLeaf
+	}
+	binary.LittleEndian.PutUint16(buf[1:], uint16(len(n.keys)))
+
+	if n.leaf {
+		for i, k := range n.keys {
+			buf = binary.LittleEndian.AppendUint16(buf, uint16(len(k)))
+			buf = append(buf, k...)
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(len(n.values[i])))
+			buf = append(buf, n.values[i]...)
+		}
+	} else {
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(n.children[0]))
+		for i, k := range n.keys {
+			buf = binary.LittleEndian.AppendUint16(buf, uint16(len(k)))
+			buf = append(buf, k...)
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(n.children[i+1]))
+		}
+	}
+	if len(buf) > pageSize {
+		return nil, ErrTooLarge
+	}
+	return buf, nil
+}
+
+func decodePageNode(buf []byte) (*pageNode, error) {
+	if len(buf) < nodeHeader || (buf[0] != kindLeaf && buf[0] != kindNode) {
+		return nil, ErrCorrupt
+	}
+	n := &pageNode{leaf: buf[0] == kindLeaf}
+	count := int(binary.LittleEndian.Uint16(buf[1:]))
+	p := nodeHeader
+
+	// next Read the next length-prefixed field
+	next := func(width int) ([]byte, bool) {
+		if p+width > len(buf) {
+			return nil, false
+		}
+		var size int
+		if width == 2 {
+			size = int(binary.LittleEndian.Uint16(buf[p:]))
+		} else {
+			size = int(binary.LittleEndian.Uint32(buf[p:]))
+		}
+		p += width
+		if p+size > len(buf) {
+			return nil, false
+		}
+		p += size
+		return buf[p-size : p], true
+	}
+	childAt := func() (pageID, bool) {
+		if p+4 > len(buf) {
+			return 0, false
+		}
+		p += 4
+		return pageID(binary.LittleEndian.Uint32(buf[p-4:])), true
+	}
+
+	if !n.leaf {
+		c, ok := childAt()
+		if !ok {
+			return nil, ErrCorrupt
+		}
+		n.children = append(n.children, c)
+	}
+	for i := 0; i < count; i++ {
+		k, ok := next(2)
+		if !ok {
+			return nil, ErrCorrupt
+		}
+		n.keys = append(n.keys, string(k))
+		if n.leaf {
+			v, ok := next(4)
+			if !ok {
+				return nil, ErrCorrupt
+			}
+			n.values = append(n.values, slices.Clone(v))
+		} else {
+			c, ok := childAt()
+			if !ok {
+				return nil, ErrCorrupt
+			}
+			n.children = append(n.children, c)
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,280 @@
+package btree
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func openTemp(t *testing.T, opts Options) (*DiskTree, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tree.db")
+	d, err := Open(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, path
+}
+
+// checkDisk Verify that d holds exactly the contents of ref, in order
+func checkDisk(t *testing.T, d *DiskTree, ref map[string][]byte) {
+	t.Helper()
+	n := 0
+	prev := ""
+	err := d.Ascend(func(key string, value []byte) bool {
+		if n > 0 && key <= prev {
+			t.Fatalf("key %q after %q", key, prev)
+		}
+		if !bytes.Equal(value, ref[key]) {
+			t.Fatalf("key %q holds %q, expected %q", key, value, ref[key])
+		}
+		prev = key
+		n++
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(ref) {
+		t.Fatalf("tree holds %d keys, expected %d", n, len(ref))
+	}
+}
+
+func Test_DiskTree_ShouldReopenWithCommittedContents(t *testing.T) {
+	d, path := openTemp(t, Options{Order: 4, PageSize: 256})
+	ref := make(map[string][]byte)
+	for i := 0; i < 1000; i++ {
+		k, v := strconv.Itoa(i), []byte("v"+strconv.Itoa(i))
+		if err := d.Insert(k, v); err != nil {
+			t.Fatal(err)
+		}
+		ref[k] = v
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := Open(path, Options{Order: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if d.Order() != 4 {
+		t.Errorf("Expected order from header 4, got: %d", d.Order())
+	}
+	checkDisk(t, d, ref)
+	if v, ok, err := d.Get("500"); err != nil || !ok || string(v) != "v500" {
+		t.Errorf("Expected v500, got: %q %v %v", v, ok, err)
+	}
+}
+
+func Test_DiskTree_ShouldMatchReferenceMap(t *testing.T) {
+	ops := 100000
+	if testing.Short() {
+		ops = 10000
+	}
+
+	// A tiny cache forces pages in and out of the file throughout.
+	d, path := openTemp(t, Options{Order: 5, PageSize: 512, CacheSize: 8})
+	rng := rand.New(rand.NewSource(1))
+	ref := make(map[string][]byte)
+	for i := 0; i < ops; i++ {
+		k := strconv.Itoa(rng.Intn(2000))
+		switch rng.Intn(3) {
+		case 0:
+			v := []byte(strconv.Itoa(i))
+			if err := d.Insert(k, v); err != nil {
+				t.Fatal(err)
+			}
+			ref[k] = v
+		case 1:
+			_, inRef := ref[k]
+			found, err := d.Delete(k)
+			if err != nil || found != inRef {
+				t.Fatalf("op %d: Delete(%q) = %v, %v; expected %v", i, k, found, err, inRef)
+			}
+			delete(ref, k)
+		case 2:
+			v, ok, err := d.Get(k)
+			if err != nil || !bytes.Equal(v, ref[k]) || ok != (ref[k] != nil) {
+				t.Fatalf("op %d: Get(%q) = %q, %v, %v", i, k, v, ok, err)
+			}
+		}
+		if i%1000 == 0 {
+			if err := d.Commit(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	checkDisk(t, d, ref)
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := Open(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	checkDisk(t, d, ref)
+}
+
+func Test_DiskTree_Rollback_ShouldDiscardUncommittedChanges(t *testing.T) {
+	d, _ := openTemp(t, Options{Order: 3, PageSize: 256})
+	defer d.Close()
+	ref := make(map[string][]byte)
+	for i := 0; i < 100; i++ {
+		k := strconv.Itoa(i)
+		d.Insert(k, []byte(k))
+		ref[k] = []byte(k)
+	}
+	d.Commit()
+
+	for i := 0; i < 100; i += 2 {
+		d.Delete(strconv.Itoa(i))
+	}
+	for i := 100; i < 200; i++ {
+		d.Insert(strconv.Itoa(i), nil)
+	}
+	d.Rollback()
+	checkDisk(t, d, ref)
+}
+
+func Test_DiskTree_Delete_ShouldReusePagesFromFreeList(t *testing.T) {
+	d, path := openTemp(t, Options{Order: 4, PageSize: 256})
+	fill := func() {
+		for i := 0; i < 2000; i++ {
+			if err := d.Insert(strconv.Itoa(i), []byte("value")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		d.Commit()
+	}
+
+	fill()
+	pages := d.p.meta.pages
+	for i := 0; i < 2000; i++ {
+		d.Delete(strconv.Itoa(i))
+	}
+	d.Commit()
+	if d.p.meta.free == 0 {
+		t.Fatalf("Expected freed pages on the free list")
+	}
+	fill()
+	if d.p.meta.pages != pages {
+		t.Errorf("Expected %d pages after refilling, got: %d", pages, d.p.meta.pages)
+	}
+	d.Close()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != int64(pages)*256 {
+		t.Errorf("Expected file of %d pages, got %d bytes", pages, info.Size())
+	}
+}
+
+func Test_DiskTree_Insert_ShouldRejectOversizedEntries(t *testing.T) {
+	d, _ := openTemp(t, Options{Order: 4, PageSize: 256})
+	defer d.Close()
+	if err := d.Insert("k", make([]byte, 100)); err != ErrTooLarge {
+		t.Errorf("Expected ErrTooLarge, got: %v", err)
+	}
+}
+
+// crash Simulate the process dying after the WAL is synced but before the
+// database file is updated.
+func crash(t *testing.T, d *DiskTree) {
+	t.Helper()
+	frames := []walFrame{{0, d.p.meta.encode(d.p.pageSize)}}
+	for id, data := range d.p.dirty {
+		frames = append(frames, walFrame{id, data})
+	}
+	if err := d.p.log.append(frames); err != nil {
+		t.Fatal(err)
+	}
+	d.p.close()
+}
+
+func Test_DiskTree_Open_ShouldReplayCommittedLog(t *testing.T) {
+	d, path := openTemp(t, Options{Order: 3, PageSize: 256})
+	ref := make(map[string][]byte)
+	for i := 0; i < 300; i++ {
+		k := strconv.Itoa(i)
+		d.Insert(k, []byte(k))
+		ref[k] = []byte(k)
+	}
+	crash(t, d)
+
+	d, err := Open(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	checkDisk(t, d, ref)
+	if info, _ := os.Stat(path + "-wal"); info.Size() != 0 {
+		t.Errorf("Expected empty log after recovery, got %d bytes", info.Size())
+	}
+}
+
+func Test_DiskTree_Open_ShouldDiscardTornLog(t *testing.T) {
+	d, path := openTemp(t, Options{Order: 3, PageSize: 256})
+	ref := make(map[string][]byte)
+	for i := 0; i < 100; i++ {
+		k := strconv.Itoa(i)
+		d.Insert(k, []byte(k))
+		ref[k] = []byte(k)
+	}
+	d.Commit()
+	for i := 100; i < 200; i++ {
+		d.Insert(strconv.Itoa(i), nil)
+	}
+	crash(t, d)
+
+	// Lose the tail of the log, commit frame included.
+	info, _ := os.Stat(path + "-wal")
+	if err := os.Truncate(path+"-wal", info.Size()-10); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := Open(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	checkDisk(t, d, ref)
+}
+
+func Test_DiskTree_Open_ShouldReplayLogOverTornHeader(t *testing.T) {
+	d, path := openTemp(t, Options{Order: 3, PageSize: 256})
+	ref := make(map[string][]byte)
+	for i := 0; i < 300; i++ {
+		k := strconv.Itoa(i)
+		d.Insert(k, []byte(k))
+		ref[k] = []byte(k)
+	}
+	crash(t, d)
+
+	// Die part way through writing the header page back.
+	if err := os.Truncate(path, 20); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := Open(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	checkDisk(t, d, ref)
+}
+
+func Test_DiskTree_Open_ShouldRejectBadOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+	if _, err := Open(path, Options{Order: 1}); err == nil {
+		t.Error("Expected an error for order 1")
+	}
+}
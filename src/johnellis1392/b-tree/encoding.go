@@ -0,0 +1,266 @@
+package btree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Binary tree encoding
+//
+//	[magic "BTRE"][version byte][order uvarint] node
+//
+// where each node is
+//
+//	leaf: [0][count uvarint] (key value)...
+//	node: [1][count uvarint] key... child...
+//
+// and keys and values are written by the caller's Codecs. Children follow
+// their parent depth-first, so the encoding streams in one pass each way.
+
+// encodingMagic First bytes of an encoded tree
+var encodingMagic = []byte("BTRE")
+
+// EncodingVersion Version written by Marshal; Unmarshal rejects others
+const EncodingVersion = 1
+
+// ErrEncoding Returned by Unmarshal for malformed input
+var ErrEncoding = errors.New("btree: malformed encoding")
+
+// Codec Binary encoding for keys or values
+//
+// Append writes v to the end of buf, failing if v cannot be encoded. Decode
+// reads one value from the start of buf, returning it and the number of
+// bytes consumed.
+type Codec[T any] interface {
+	Append(buf []byte, v T) ([]byte, error)
+	Decode(buf []byte) (T, int, error)
+}
+
+// StringCodec Length-prefixed strings
+type StringCodec struct{}
+
+// Append ...
+func (StringCodec) Append(buf []byte, v string) ([]byte, error) {
+	return append(binary.AppendUvarint(buf, uint64(len(v))), v...), nil
+}
+
+// Decode ...
+func (StringCodec) Decode(buf []byte) (string, int, error) {
+	b, n, err := decodeBytes(buf)
+	return string(b), n, err
+}
+
+// BytesCodec Length-prefixed byte slices
+type BytesCodec struct{}
+
+// Append ...
+func (BytesCodec) Append(buf []byte, v []byte) ([]byte, error) {
+	return append(binary.AppendUvarint(buf, uint64(len(v))), v...), nil
+}
+
+// Decode ...
+func (BytesCodec) Decode(buf []byte) ([]byte, int, error) {
+	b, n, err := decodeBytes(buf)
+	return bytes.Clone(b), n, err
+}
+
+func decodeBytes(buf []byte) ([]byte, int, error) {
+	size, n := binary.Uvarint(buf)
+	if n <= 0 || size > uint64(len(buf)-n) {
+		return nil, 0, ErrEncoding
+	}
+	return buf[n : n+int(size)], n + int(size), nil
+}
+
+// Integer Integer types supported by IntCodec
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// IntCodec Zig-zag varint integers
+type IntCodec[T Integer] struct{}
+
+// Append ...
+func (IntCodec[T]) Append(buf []byte, v T) ([]byte, error) {
+	return binary.AppendVarint(buf, int64(v)), nil
+}
+
+// Decode ...
+func (IntCodec[T]) Decode(buf []byte) (T, int, error) {
+	v, n := binary.Varint(buf)
+	if n <= 0 {
+		return 0, 0, ErrEncoding
+	}
+	return T(v), n, nil
+}
+
+// JSONCodec Length-prefixed JSON, for any type encoding/json supports
+type JSONCodec[T any] struct{}
+
+// Append ...
+func (JSONCodec[T]) Append(buf []byte, v T) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("btree: JSONCodec: %v", err)
+	}
+	return append(binary.AppendUvarint(buf, uint64(len(b))), b...), nil
+}
+
+// Decode ...
+func (JSONCodec[T]) Decode(buf []byte) (T, int, error) {
+	var v T
+	b, n, err := decodeBytes(buf)
+	if err != nil {
+		return v, 0, err
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return v, 0, err
+	}
+	return v, n, nil
+}
+
+// Marshal Encode t using kc and vc for its keys and values
+func Marshal[K, V any](t BTree[K, V], kc Codec[K], vc Codec[V]) ([]byte, error) {
+	order, _ := t.(node[K, V]).config()
+	buf := append([]byte(nil), encodingMagic...)
+	buf = append(buf, EncodingVersion)
+	buf = binary.AppendUvarint(buf, uint64(order))
+	return marshalNode(buf, t, kc, vc)
+}
+
+func marshalNode[K, V any](buf []byte, t BTree[K, V], kc Codec[K], vc Codec[V]) ([]byte, error) {
+	var err error
+	switch x := t.(type) {
+	case BLeaf[K, V]:
+		buf = append(buf, 0)
+		buf = binary.AppendUvarint(buf, uint64(len(x.Keys)))
+		for i, k := range x.Keys {
+			if buf, err = kc.Append(buf, k); err != nil {
+				return nil, err
+			}
+			if buf, err = vc.Append(buf, x.Values[i]); err != nil {
+				return nil, err
+			}
+		}
+	case BNode[K, V]:
+		buf = append(buf, 1)
+		buf = binary.AppendUvarint(buf, uint64(len(x.Keys)))
+		for _, k := range x.Keys {
+			if buf, err = kc.Append(buf, k); err != nil {
+				return nil, err
+			}
+		}
+		for _, c := range x.Children {
+			if buf, err = marshalNode(buf, c, kc, vc); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("btree: cannot marshal node of type %T", t)
+	}
+	return buf, nil
+}
+
+// Unmarshal Decode a tree written by Marshal, ordering its keys by compare
+//
+// The decoded tree is checked with Validate, so corrupt input that still
+// parses is reported rather than returned as a broken tree.
+func Unmarshal[K, V any](data []byte, compare func(a, b K) int, kc Codec[K], vc Codec[V]) (BTree[K, V], error) {
+	if !bytes.HasPrefix(data, encodingMagic) || len(data) < len(encodingMagic)+1 {
+		return nil, ErrEncoding
+	}
+	if v := data[len(encodingMagic)]; v != EncodingVersion {
+		return nil, fmt.Errorf("btree: unsupported encoding version %d", v)
+	}
+	data = data[len(encodingMagic)+1:]
+	order, n := binary.Uvarint(data)
+	if n <= 0 || order < 2 || order > 1<<31-1 {
+		return nil, ErrEncoding
+	}
+
+	d := decoder[K, V]{data: data[n:], order: int32(order), compare: compare, kc: kc, vc: vc}
+	t, err := d.node(0)
+	if err != nil {
+		return nil, err
+	}
+	if len(d.data) != 0 {
+		return nil, ErrEncoding
+	}
+	if err := t.(node[K, V]).Validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// maxDecodeDepth Bound on nesting, far beyond any real tree's height
+const maxDecodeDepth = 64
+
+// decoder Remaining input and settings for Unmarshal
+type decoder[K, V any] struct {
+	data    []byte
+	order   int32
+	compare func(a, b K) int
+	kc      Codec[K]
+	vc      Codec[V]
+}
+
+func (d *decoder[K, V]) node(depth int) (BTree[K, V], error) {
+	if depth > maxDecodeDepth || len(d.data) < 1 {
+		return nil, ErrEncoding
+	}
+	kind := d.data[0]
+	count, n := binary.Uvarint(d.data[1:])
+	// Every key takes at least one byte, which bounds count before any
+	// allocation is sized from it.
+	if n <= 0 || count > uint64(d.order) || count > uint64(len(d.data)) {
+		return nil, ErrEncoding
+	}
+	d.data = d.data[1+n:]
+
+	keys := make([]K, count)
+	switch kind {
+	case 0:
+		values := make([]V, count)
+		for i := range keys {
+			var err error
+			if keys[i], err = d.key(); err != nil {
+				return nil, err
+			}
+			v, m, err := d.vc.Decode(d.data)
+			if err != nil {
+				return nil, err
+			}
+			values[i], d.data = v, d.data[m:]
+		}
+		return BLeaf[K, V]{Order: d.order, Compare: d.compare, Keys: keys, Values: values}, nil
+	case 1:
+		for i := range keys {
+			var err error
+			if keys[i], err = d.key(); err != nil {
+				return nil, err
+			}
+		}
+		children := make([]BTree[K, V], count+1)
+		for i := range children {
+			var err error
+			if children[i], err = d.node(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return BNode[K, V]{Order: d.order, Compare: d.compare, Keys: keys, Children: children}, nil
+	}
+	return nil, ErrEncoding
+}
+
+func (d *decoder[K, V]) key() (K, error) {
+	k, m, err := d.kc.Decode(d.data)
+	if err != nil {
+		return k, err
+	}
+	d.data = d.data[m:]
+	return k, nil
+}
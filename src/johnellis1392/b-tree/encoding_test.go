@@ -0,0 +1,158 @@
+package btree
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_Marshal_ShouldRoundTrip(t *testing.T) {
+	for _, order := range []int32{2, 5, 32} {
+		b := NewOrdered[string, int](order)
+		for i := 0; i < 1000; i++ {
+			b = b.Insert(strconv.Itoa(i*7), -i)
+		}
+
+		data, err := Marshal(b, StringCodec{}, IntCodec[int]{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Unmarshal(data, cmp.Compare[string], StringCodec{}, IntCodec[int]{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		again, _ := Marshal(got, StringCodec{}, IntCodec[int]{})
+		if !bytes.Equal(data, again) {
+			t.Errorf("order %d: Expected identical encoding after round trip", order)
+		}
+		for i := 0; i < 1000; i++ {
+			if v, ok := got.Get(strconv.Itoa(i * 7)); !ok || v != -i {
+				t.Fatalf("order %d: Expected %d, got: %d", order, -i, v)
+			}
+		}
+	}
+}
+
+func Test_Marshal_ShouldSupportCustomValueCodecs(t *testing.T) {
+	type point struct{ X, Y int }
+	b := NewOrdered[int64, point](3)
+	for i := int64(0); i < 50; i++ {
+		b = b.Insert(i, point{int(i), int(-i)})
+	}
+	data, _ := Marshal(b, IntCodec[int64]{}, JSONCodec[point]{})
+	got, err := Unmarshal(data, cmp.Compare[int64], IntCodec[int64]{}, JSONCodec[point]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := got.Find(42); p != (point{42, -42}) {
+		t.Errorf("Expected {42 -42}, got: %v", p)
+	}
+}
+
+func Test_Marshal_ShouldReturnCodecErrors(t *testing.T) {
+	b := NewOrdered[int, float64](3)
+	for i := 0; i < 20; i++ {
+		b = b.Insert(i, float64(i))
+	}
+	b = b.Insert(7, math.NaN())
+	if _, err := Marshal(b, IntCodec[int]{}, JSONCodec[float64]{}); err == nil {
+		t.Error("Expected an error for a NaN value")
+	}
+}
+
+func Test_Unmarshal_ShouldRejectBadInput(t *testing.T) {
+	b := NewOrdered[string, []byte](3)
+	for i := 0; i < 100; i++ {
+		b = b.Insert(strconv.Itoa(i), []byte("v"))
+	}
+	data, _ := Marshal(b, StringCodec{}, BytesCodec{})
+
+	unmarshal := func(data []byte) error {
+		_, err := Unmarshal(data, cmp.Compare[string], StringCodec{}, BytesCodec{})
+		return err
+	}
+	if err := unmarshal(data[:len(data)-1]); err == nil {
+		t.Errorf("Expected error for truncated input")
+	}
+	if err := unmarshal(append(bytes.Clone(data), 0)); err == nil {
+		t.Errorf("Expected error for trailing bytes")
+	}
+	if err := unmarshal([]byte("JUNK")); err != ErrEncoding {
+		t.Errorf("Expected ErrEncoding for bad magic, got: %v", err)
+	}
+
+	future := bytes.Clone(data)
+	future[len(encodingMagic)] = EncodingVersion + 1
+	if err := unmarshal(future); err == nil || !strings.Contains(err.Error(), "version") {
+		t.Errorf("Expected version error, got: %v", err)
+	}
+
+	// Reversing the comparator makes every node out of order.
+	reverse := func(a, b string) int { return strings.Compare(b, a) }
+	_, err := Unmarshal(data, reverse, StringCodec{}, BytesCodec{})
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Errorf("Expected ValidationError, got: %v", err)
+	}
+}
+
+func Test_DumpJSON_ShouldDescribeStructure(t *testing.T) {
+	b := build(2, 5)
+	data, err := DumpJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dump struct {
+		Order int32
+		Root  struct {
+			Leaf     bool
+			Keys     []string
+			Children []json.RawMessage
+		}
+	}
+	if err := json.Unmarshal(data, &dump); err != nil {
+		t.Fatal(err)
+	}
+	root := b.(BNode[string, interface{}])
+	if dump.Order != 2 || dump.Root.Leaf || len(dump.Root.Keys) != len(root.Keys) || len(dump.Root.Children) != len(root.Children) {
+		t.Errorf("Unexpected dump: %s", data)
+	}
+}
+
+func Test_Graphviz_ShouldEmitEveryNode(t *testing.T) {
+	b := build(2, 20)
+	var buf bytes.Buffer
+	if err := Graphviz(&buf, b); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "digraph btree {") || !strings.HasSuffix(out, "}\n") {
+		t.Errorf("Expected a digraph, got: %s", out)
+	}
+	nodes, edges := 0, 0
+	var walk func(BTree[string, interface{}])
+	walk = func(x BTree[string, interface{}]) {
+		nodes++
+		if n, ok := x.(BNode[string, interface{}]); ok {
+			edges += len(n.Children)
+			for _, c := range n.Children {
+				walk(c)
+			}
+		}
+	}
+	walk(b)
+	if got := strings.Count(out, "[label="); got != nodes {
+		t.Errorf("Expected %d nodes, got: %d", nodes, got)
+	}
+	if got := strings.Count(out, " -> "); got != edges {
+		t.Errorf("Expected %d edges, got: %d", edges, got)
+	}
+}
@@ -0,0 +1,299 @@
+package btree
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/binary"
+	"errors"
+	"os"
+	"sort"
+)
+
+// Errors returned by the disk-backed tree
+var (
+	ErrCorrupt  = errors.New("btree: corrupt database file")
+	ErrTooLarge = errors.New("btree: entry too large for page size and order")
+	ErrClosed   = errors.New("btree: database closed")
+)
+
+// magic First bytes of every database file
+var magic = []byte("GOBTREE\x00")
+
+const (
+	fileVersion = 1
+
+	// DefaultPageSize Page size used when Options.PageSize is zero
+	DefaultPageSize = 4096
+
+	// DefaultCacheSize Pages cached when Options.CacheSize is zero
+	DefaultCacheSize = 256
+)
+
+// pageID Index of a page in the database file; page 0 is the header, so 0
+// also serves as the nil page.
+type pageID uint32
+
+// meta Contents of the header page
+//
+//	[magic 8][version uint32][page size uint32][order int32]
+//	[root uint32][page count uint32][free list head uint32]
+type meta struct {
+	order int32
+	root  pageID
+	pages uint32
+	free  pageID
+}
+
+func (m meta) encode(pageSize int) []byte {
+	buf := make([]byte, pageSize)
+	copy(buf, magic)
+	binary.LittleEndian.PutUint32(buf[8:], fileVersion)
+	binary.LittleEndian.PutUint32(buf[12:], uint32(pageSize))
+	binary.LittleEndian.PutUint32(buf[16:], uint32(m.order))
+	binary.LittleEndian.PutUint32(buf[20:], uint32(m.root))
+	binary.LittleEndian.PutUint32(buf[24:], m.pages)
+	binary.LittleEndian.PutUint32(buf[28:], uint32(m.free))
+	return buf
+}
+
+// decodeMeta Parse a header page, returning its meta and page size
+func decodeMeta(buf []byte) (meta, int, error) {
+	if len(buf) < 32 || !bytes.Equal(buf[:8], magic) {
+		return meta{}, 0, ErrCorrupt
+	}
+	if binary.LittleEndian.Uint32(buf[8:]) != fileVersion {
+		return meta{}, 0, ErrCorrupt
+	}
+	m := meta{
+		order: int32(binary.LittleEndian.Uint32(buf[16:])),
+		root:  pageID(binary.LittleEndian.Uint32(buf[20:])),
+		pages: binary.LittleEndian.Uint32(buf[24:]),
+		free:  pageID(binary.LittleEndian.Uint32(buf[28:])),
+	}
+	return m, int(binary.LittleEndian.Uint32(buf[12:])), nil
+}
+
+// pager Fixed-size page store over a database file
+//
+// Clean pages are kept in an LRU cache. Pages written since the last commit
+// are held in memory until Commit logs them to the WAL and writes them back,
+// so the database file only ever changes by whole committed batches.
+type pager struct {
+	f        *os.File
+	log      *wal
+	pageSize int
+
+	meta      meta
+	committed meta
+
+	dirty    map[pageID][]byte
+	cache    map[pageID]*list.Element
+	lru      *list.List
+	capacity int
+}
+
+// cached Entry in the LRU list
+type cached struct {
+	id   pageID
+	data []byte
+}
+
+// openPager Open the database at path, creating it with the given order
+// and page size if it does not exist. Recovery from the WAL happens before
+// the header is read, since an interrupted commit may have torn it.
+func openPager(path string, order int32, pageSize, capacity int) (*pager, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	p := &pager{
+		f:        f,
+		dirty:    make(map[pageID][]byte),
+		cache:    make(map[pageID]*list.Element),
+		lru:      list.New(),
+		capacity: capacity,
+	}
+
+	// The page size comes from the header page logged in the WAL, else the
+	// file's own header, else the options.
+	if p.log, err = openWAL(path+"-wal", 0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	head := make([]byte, 32)
+	if size := p.log.headerPageSize(); size > 0 {
+		pageSize = size
+	} else if _, err := f.ReadAt(head, 0); err == nil {
+		if _, size, err := decodeMeta(head); err == nil {
+			pageSize = size
+		}
+	}
+	p.pageSize, p.log.pageSize = pageSize, pageSize
+
+	if err := p.recover(); err != nil {
+		p.close()
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		p.close()
+		return nil, err
+	}
+	if info.Size() == 0 {
+		p.meta = meta{order: order, pages: 1}
+		p.committed = p.meta
+		return p, nil
+	}
+	if _, err := f.ReadAt(head, 0); err != nil {
+		p.close()
+		return nil, ErrCorrupt
+	}
+	var size int
+	if p.meta, size, err = decodeMeta(head); err != nil {
+		p.close()
+		return nil, err
+	}
+	if size != pageSize {
+		p.close()
+		return nil, ErrCorrupt
+	}
+	p.committed = p.meta
+	return p, nil
+}
+
+// recover Replay a committed batch left behind by an interrupted commit
+func (p *pager) recover() error {
+	frames, err := p.log.recover()
+	if err != nil {
+		return err
+	}
+	if err := p.apply(frames); err != nil {
+		return err
+	}
+	return p.log.reset()
+}
+
+// apply Write page images to the database file and sync it
+func (p *pager) apply(frames []walFrame) error {
+	if len(frames) == 0 {
+		return nil
+	}
+	for _, fr := range frames {
+		if _, err := p.f.WriteAt(fr.data, int64(fr.id)*int64(p.pageSize)); err != nil {
+			return err
+		}
+	}
+	return p.f.Sync()
+}
+
+// read Contents of page id; callers must not modify the returned slice
+func (p *pager) read(id pageID) ([]byte, error) {
+	if data, ok := p.dirty[id]; ok {
+		return data, nil
+	}
+	if e, ok := p.cache[id]; ok {
+		p.lru.MoveToFront(e)
+		return e.Value.(*cached).data, nil
+	}
+	if uint32(id) >= p.meta.pages {
+		return nil, ErrCorrupt
+	}
+
+	data := make([]byte, p.pageSize)
+	if _, err := p.f.ReadAt(data, int64(id)*int64(p.pageSize)); err != nil {
+		return nil, err
+	}
+	p.remember(id, data)
+	return data, nil
+}
+
+// remember Add a clean page to the cache, evicting the least recently used
+func (p *pager) remember(id pageID, data []byte) {
+	p.cache[id] = p.lru.PushFront(&cached{id, data})
+	for p.lru.Len() > p.capacity {
+		e := p.lru.Back()
+		p.lru.Remove(e)
+		delete(p.cache, e.Value.(*cached).id)
+	}
+}
+
+// write Stage new contents for page id until the next commit
+func (p *pager) write(id pageID, data []byte) {
+	if e, ok := p.cache[id]; ok {
+		p.lru.Remove(e)
+		delete(p.cache, id)
+	}
+	buf := make([]byte, p.pageSize)
+	copy(buf, data)
+	p.dirty[id] = buf
+}
+
+// allocate Take a page from the free list, or grow the file by one page
+func (p *pager) allocate() (pageID, error) {
+	if p.meta.free == 0 {
+		id := pageID(p.meta.pages)
+		p.meta.pages++
+		return id, nil
+	}
+
+	id := p.meta.free
+	data, err := p.read(id)
+	if err != nil {
+		return 0, err
+	}
+	p.meta.free = pageID(binary.LittleEndian.Uint32(data))
+	return id, nil
+}
+
+// release Return page id to the free list
+func (p *pager) release(id pageID) {
+	buf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(buf, uint32(p.meta.free))
+	p.write(id, buf)
+	p.meta.free = id
+}
+
+// commit Make every staged page durable
+func (p *pager) commit() error {
+	if len(p.dirty) == 0 && p.meta == p.committed {
+		return nil
+	}
+
+	frames := []walFrame{{0, p.meta.encode(p.pageSize)}}
+	for id, data := range p.dirty {
+		frames = append(frames, walFrame{id, data})
+	}
+	sort.Slice(frames, func(i, j int) bool { return frames[i].id < frames[j].id })
+
+	if err := p.log.append(frames); err != nil {
+		return err
+	}
+	if err := p.apply(frames); err != nil {
+		return err
+	}
+	if err := p.log.reset(); err != nil {
+		return err
+	}
+
+	for _, fr := range frames {
+		p.remember(fr.id, fr.data)
+	}
+	p.dirty = make(map[pageID][]byte)
+	p.committed = p.meta
+	return nil
+}
+
+// rollback Discard every page staged since the last commit
+func (p *pager) rollback() {
+	p.dirty = make(map[pageID][]byte)
+	p.meta = p.committed
+}
+
+func (p *pager) close() error {
+	err := p.log.close()
+	if e := p.f.Close(); err == nil {
+		err = e
+	}
+	return err
+}
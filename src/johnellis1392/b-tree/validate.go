@@ -0,0 +1,125 @@
+package btree
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError Structural problem found by Validate
+//
+// Path lists the child indices leading from the root to the offending
+// node, so an empty Path is the root itself.
+type ValidationError struct {
+	Path []int
+	Msg  string
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Path))
+	for i, p := range e.Path {
+		parts[i] = fmt.Sprint(p)
+	}
+	return fmt.Sprintf("btree: node at [%s]: %s", strings.Join(parts, " "), e.Msg)
+}
+
+// validator State for one Validate walk
+type validator[K, V any] struct {
+	order   int32
+	compare func(a, b K) int
+	depth   int
+}
+
+// validate Check the invariants of the tree rooted at root
+func validate[K, V any](root BTree[K, V]) error {
+	order, compare := root.(node[K, V]).config()
+	if order < 2 {
+		return &ValidationError{Msg: fmt.Sprintf("order %d is below 2", order)}
+	}
+	if compare == nil {
+		return &ValidationError{Msg: "no comparator"}
+	}
+	v := validator[K, V]{order: order, compare: compare, depth: -1}
+	return v.walk(root, nil, nil, nil)
+}
+
+// walk Check n, whose keys must lie in [lo, hi) where those are non-nil
+func (v *validator[K, V]) walk(n BTree[K, V], path []int, lo, hi *K) error {
+	fail := func(format string, args ...interface{}) error {
+		return &ValidationError{Path: path, Msg: fmt.Sprintf(format, args...)}
+	}
+
+	order, _ := n.(node[K, V]).config()
+	if order != v.order {
+		return fail("order %d differs from root order %d", order, v.order)
+	}
+
+	var keys []K
+	switch x := n.(type) {
+	case BLeaf[K, V]:
+		keys = x.Keys
+		if len(x.Values) != len(x.Keys) {
+			return fail("%d keys but %d values", len(x.Keys), len(x.Values))
+		}
+		if v.depth < 0 {
+			v.depth = len(path)
+		} else if v.depth != len(path) {
+			return fail("leaf at depth %d, expected depth %d", len(path), v.depth)
+		}
+	case BNode[K, V]:
+		keys = x.Keys
+		if len(x.Children) != len(x.Keys)+1 {
+			return fail("%d keys but %d children", len(x.Keys), len(x.Children))
+		}
+		if len(path) == 0 && len(x.Keys) == 0 {
+			return fail("root node has no keys")
+		}
+	default:
+		return fail("unknown node type %T", n)
+	}
+
+	if len(keys) > int(v.order) {
+		return fail("%d keys exceeds order %d", len(keys), v.order)
+	}
+	if len(path) > 0 && len(keys) < minKeys(v.order) {
+		return fail("%d keys is below the minimum of %d", len(keys), minKeys(v.order))
+	}
+	for i, k := range keys {
+		if i > 0 && v.compare(keys[i-1], k) >= 0 {
+			return fail("key %v at index %d is not above key %v", k, i, keys[i-1])
+		}
+		if lo != nil && v.compare(k, *lo) < 0 {
+			return fail("key %v at index %d is below the lower bound %v", k, i, *lo)
+		}
+		if hi != nil && v.compare(k, *hi) >= 0 {
+			return fail("key %v at index %d is not below the upper bound %v", k, i, *hi)
+		}
+	}
+
+	if x, ok := n.(BNode[K, V]); ok {
+		for i, c := range x.Children {
+			l, h := lo, hi
+			if i > 0 {
+				l = &x.Keys[i-1]
+			}
+			if i < len(x.Keys) {
+				h = &x.Keys[i]
+			}
+			if err := v.walk(c, append(path[:len(path):len(path)], i), l, h); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Validate Check key ordering, fill bounds and leaf depth throughout the
+// tree, returning a *ValidationError describing the first problem found.
+func (b BNode[K, V]) Validate() error {
+	return validate[K, V](b)
+}
+
+// Validate Check key ordering, fill bounds and leaf depth throughout the
+// tree, returning a *ValidationError describing the first problem found.
+func (b BLeaf[K, V]) Validate() error {
+	return validate[K, V](b)
+}
@@ -0,0 +1,87 @@
+package btree
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type leaf = BLeaf[string, interface{}]
+type inner = BNode[string, interface{}]
+
+func mkLeaf(keys ...string) leaf {
+	return leaf{Order: 3, Compare: strings.Compare, Keys: keys, Values: make([]interface{}, len(keys))}
+}
+
+func mkNode(keys []string, children ...BTree[string, interface{}]) inner {
+	return inner{Order: 3, Compare: strings.Compare, Keys: keys, Children: children}
+}
+
+func Test_Validate_ShouldAcceptBuiltTrees(t *testing.T) {
+	for _, order := range []int32{2, 3, 10} {
+		if err := build(order, 500).Validate(); err != nil {
+			t.Errorf("order %d: %v", order, err)
+		}
+	}
+	if err := New(3).Validate(); err != nil {
+		t.Errorf("empty tree: %v", err)
+	}
+}
+
+func Test_Validate_ShouldReportProblems(t *testing.T) {
+	cases := []struct {
+		name string
+		tree BTree[string, interface{}]
+		path []int
+		msg  string
+	}{
+		{
+			"unsorted leaf",
+			mkLeaf("b", "a"),
+			nil, "key a at index 1 is not above key b",
+		},
+		{
+			"overfull leaf",
+			mkLeaf("a", "b", "c", "d"),
+			nil, "4 keys exceeds order 3",
+		},
+		{
+			"underfull child",
+			mkNode([]string{"m"}, mkLeaf("a", "b"), mkLeaf()),
+			[]int{1}, "0 keys is below the minimum of 1",
+		},
+		{
+			"key outside parent range",
+			mkNode([]string{"m"}, mkLeaf("a", "n"), mkLeaf("p")),
+			[]int{0}, "key n at index 1 is not below the upper bound m",
+		},
+		{
+			"uneven depth",
+			mkNode([]string{"m"}, mkLeaf("a"), mkNode([]string{"p"}, mkLeaf("n"), mkLeaf("q"))),
+			[]int{1, 0}, "leaf at depth 2, expected depth 1",
+		},
+		{
+			"missing child",
+			mkNode([]string{"m", "p"}, mkLeaf("a"), mkLeaf("n")),
+			nil, "2 keys but 2 children",
+		},
+		{
+			"mixed order",
+			mkNode([]string{"m"}, mkLeaf("a"), leaf{Order: 4, Compare: strings.Compare, Keys: []string{"n"}, Values: []interface{}{nil}}),
+			[]int{1}, "order 4 differs from root order 3",
+		},
+	}
+
+	for _, c := range cases {
+		err := c.tree.Validate()
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("%s: Expected ValidationError, got: %v", c.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(verr.Path, c.path) || verr.Msg != c.msg {
+			t.Errorf("%s: Expected %v %q, got: %v %q", c.name, c.path, c.msg, verr.Path, verr.Msg)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package btree
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// Write-ahead log
+//
+// A commit appends one frame per changed page followed by a commit frame,
+// and syncs the log before any page is written back to the database file.
+// Each frame is
+//
+//	[page id uint32][page bytes][crc32 uint32]
+//
+// and the commit frame carries commitMarker in place of a page id, the
+// number of frames in the batch, and a checksum over the batch's frame
+// checksums. If the process dies while the database file is being updated,
+// reopening replays the committed batch; a batch without a valid commit
+// frame was never acknowledged and is discarded.
+
+// commitMarker Page id of the frame ending a committed batch
+const commitMarker = ^uint32(0)
+
+// walFrame One page image in the log
+type walFrame struct {
+	id   pageID
+	data []byte
+}
+
+// wal Log file paired with a database file
+type wal struct {
+	f        *os.File
+	pageSize int
+}
+
+// openWAL Open or create the log at path
+func openWAL(path string, pageSize int) (*wal, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &wal{f: f, pageSize: pageSize}, nil
+}
+
+// append Durably log a batch of page images as one commit
+func (w *wal) append(frames []walFrame) error {
+	buf := make([]byte, 0, len(frames)*(w.pageSize+8)+12)
+	batch := crc32.NewIEEE()
+	for _, fr := range frames {
+		start := len(buf)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(fr.id))
+		buf = append(buf, fr.data...)
+		sum := crc32.ChecksumIEEE(buf[start:])
+		buf = binary.LittleEndian.AppendUint32(buf, sum)
+		batch.Write(buf[len(buf)-4:])
+	}
+	buf = binary.LittleEndian.AppendUint32(buf, commitMarker)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(frames)))
+	buf = binary.LittleEndian.AppendUint32(buf, batch.Sum32())
+
+	if _, err := w.f.WriteAt(buf, 0); err != nil {
+		return err
+	}
+	return w.f.Sync()
+}
+
+// headerPageSize Page size recorded in the header page the log starts
+// with, or 0 if it does not start with one. Every batch logs page 0 first,
+// so this tells recovery how to read a log whose database file is torn.
+func (w *wal) headerPageSize() int {
+	buf := make([]byte, 4+32)
+	if _, err := w.f.ReadAt(buf, 0); err != nil {
+		return 0
+	}
+	if binary.LittleEndian.Uint32(buf) != 0 {
+		return 0
+	}
+	_, size, err := decodeMeta(buf[4:])
+	if err != nil {
+		return 0
+	}
+	return size
+}
+
+// recover Committed batch left in the log, if any
+func (w *wal) recover() ([]walFrame, error) {
+	r := io.NewSectionReader(w.f, 0, 1<<62)
+	var frames []walFrame
+	batch := crc32.NewIEEE()
+	head := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(r, head); err != nil {
+			return nil, nil
+		}
+		id := binary.LittleEndian.Uint32(head)
+		if id == commitMarker {
+			tail := make([]byte, 8)
+			if _, err := io.ReadFull(r, tail); err != nil {
+				return nil, nil
+			}
+			n := binary.LittleEndian.Uint32(tail)
+			if int(n) != len(frames) || binary.LittleEndian.Uint32(tail[4:]) != batch.Sum32() {
+				return nil, nil
+			}
+			return frames, nil
+		}
+
+		body := make([]byte, w.pageSize+4)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return nil, nil
+		}
+		data, sum := body[:w.pageSize], body[w.pageSize:]
+		c := crc32.NewIEEE()
+		c.Write(head)
+		c.Write(data)
+		if c.Sum32() != binary.LittleEndian.Uint32(sum) {
+			return nil, nil
+		}
+		batch.Write(sum)
+		frames = append(frames, walFrame{pageID(id), data})
+	}
+}
+
+// reset Empty the log once its batch is safely in the database file
+func (w *wal) reset() error {
+	if err := w.f.Truncate(0); err != nil {
+		return err
+	}
+	return w.f.Sync()
+}
+
+func (w *wal) close() error {
+	return w.f.Close()
+}
@@ -5,33 +5,242 @@ import (
 )
 
 type Inst interface {
-	Exec(System)
+	Exec(*System) error
 	String() string
 }
 
-var _ Inst = (*AddInst)(nil)
+var (
+	_ Inst = (*HltInst)(nil)
+	_ Inst = (*MovInst)(nil)
+	_ Inst = (*AddInst)(nil)
+	_ Inst = (*SubInst)(nil)
+	_ Inst = (*CmpInst)(nil)
+	_ Inst = (*JmpInst)(nil)
+	_ Inst = (*JccInst)(nil)
+	_ Inst = (*PushInst)(nil)
+	_ Inst = (*PopInst)(nil)
+	_ Inst = (*CallInst)(nil)
+	_ Inst = (*RetInst)(nil)
+	_ Inst = (*LoadInst)(nil)
+	_ Inst = (*StoreInst)(nil)
+	_ Inst = (*IntInst)(nil)
+)
+
+// HltInst Stop the Machine
+type HltInst struct{}
+
+func (i HltInst) Exec(s *System) error {
+	s.CPU.Halted = true
+	return nil
+}
+
+func (i HltInst) String() string  { return "HltInst{}" }
+func (i HltInst) encode() []int16 { return words(OpHlt, 0, Operand{}) }
+
+// MovInst Target = Src
+type MovInst struct {
+	Target Reg
+	Src    Operand
+}
+
+func (i MovInst) Exec(s *System) error {
+	*s.CPU.reg(i.Target) = s.CPU.value(i.Src)
+	return nil
+}
 
+func (i MovInst) String() string  { return fmt.Sprintf("MovInst{%s, %s}", i.Target, i.Src) }
+func (i MovInst) encode() []int16 { return words(OpMov, uint8(i.Target), i.Src) }
+
+// AddInst Target += Val
 type AddInst struct {
-	Target string
-	Val    int16
-}
-
-func (i AddInst) Exec(s System) {
-	switch i.Target {
-	case "eax":
-		s.CPU.Eax += i.Val
-	case "ebx":
-		s.CPU.Ebx += i.Val
-	case "ecx":
-		s.CPU.Ecx += i.Val
-	case "edx":
-		s.CPU.Edx += i.Val
-	default:
-		// Unknown Instruction
-		break
+	Target Reg
+	Val    Operand
+}
+
+func (i AddInst) Exec(s *System) error {
+	r := s.CPU.reg(i.Target)
+	*r += s.CPU.value(i.Val)
+	s.CPU.setFlags(*r)
+	return nil
+}
+
+func (i AddInst) String() string  { return fmt.Sprintf("AddInst{%s, %s}", i.Target, i.Val) }
+func (i AddInst) encode() []int16 { return words(OpAdd, uint8(i.Target), i.Val) }
+
+// SubInst Target -= Val
+type SubInst struct {
+	Target Reg
+	Val    Operand
+}
+
+func (i SubInst) Exec(s *System) error {
+	r := s.CPU.reg(i.Target)
+	*r -= s.CPU.value(i.Val)
+	s.CPU.setFlags(*r)
+	return nil
+}
+
+func (i SubInst) String() string  { return fmt.Sprintf("SubInst{%s, %s}", i.Target, i.Val) }
+func (i SubInst) encode() []int16 { return words(OpSub, uint8(i.Target), i.Val) }
+
+// CmpInst Set the Flags from Target Against Val Without Changing Either
+type CmpInst struct {
+	Target Reg
+	Val    Operand
+}
+
+func (i CmpInst) Exec(s *System) error {
+	a, b := *s.CPU.reg(i.Target), s.CPU.value(i.Val)
+	s.CPU.Zf, s.CPU.Sf = a == b, a < b
+	return nil
+}
+
+func (i CmpInst) String() string  { return fmt.Sprintf("CmpInst{%s, %s}", i.Target, i.Val) }
+func (i CmpInst) encode() []int16 { return words(OpCmp, uint8(i.Target), i.Val) }
+
+// JmpInst Continue at Instruction Number Target
+type JmpInst struct {
+	Target Operand
+}
+
+func (i JmpInst) Exec(s *System) error {
+	return s.jump(s.CPU.value(i.Target))
+}
+
+func (i JmpInst) String() string  { return fmt.Sprintf("JmpInst{%s}", i.Target) }
+func (i JmpInst) encode() []int16 { return words(OpJmp, 0, i.Target) }
+
+// JccInst Jump if Cond Holds
+type JccInst struct {
+	Cond   Cond
+	Target Operand
+}
+
+func (i JccInst) Exec(s *System) error {
+	c := &s.CPU
+	var taken bool
+	switch i.Cond {
+	case CondE:
+		taken = c.Zf
+	case CondNE:
+		taken = !c.Zf
+	case CondL:
+		taken = c.Sf
+	case CondGE:
+		taken = !c.Sf
+	case CondG:
+		taken = !c.Zf && !c.Sf
+	case CondLE:
+		taken = c.Zf || c.Sf
+	}
+	if !taken {
+		return nil
+	}
+	return s.jump(c.value(i.Target))
+}
+
+func (i JccInst) String() string  { return fmt.Sprintf("JccInst{%s, %s}", i.Cond, i.Target) }
+func (i JccInst) encode() []int16 { return words(OpJcc, uint8(i.Cond), i.Target) }
+
+// PushInst Push Src onto the Stack
+type PushInst struct {
+	Src Operand
+}
+
+func (i PushInst) Exec(s *System) error {
+	return s.push(s.CPU.value(i.Src))
+}
+
+func (i PushInst) String() string  { return fmt.Sprintf("PushInst{%s}", i.Src) }
+func (i PushInst) encode() []int16 { return words(OpPush, 0, i.Src) }
+
+// PopInst Pop the Stack into Target
+type PopInst struct {
+	Target Reg
+}
+
+func (i PopInst) Exec(s *System) error {
+	v, err := s.pop()
+	*s.CPU.reg(i.Target) = v
+	return err
+}
+
+func (i PopInst) String() string  { return fmt.Sprintf("PopInst{%s}", i.Target) }
+func (i PopInst) encode() []int16 { return words(OpPop, uint8(i.Target), Operand{}) }
+
+// CallInst Push the Return Instruction Number and Jump to Target
+type CallInst struct {
+	Target Operand
+}
+
+func (i CallInst) Exec(s *System) error {
+	if err := s.push(int16(s.CPU.Ptr)); err != nil {
+		return err
 	}
+	return s.jump(s.CPU.value(i.Target))
 }
 
-func (i AddInst) String() string {
-	return fmt.Sprintf("AddInst{%s, %s}", i.Target, i.Val)
+func (i CallInst) String() string  { return fmt.Sprintf("CallInst{%s}", i.Target) }
+func (i CallInst) encode() []int16 { return words(OpCall, 0, i.Target) }
+
+// RetInst Return to the Instruction Number on the Stack
+type RetInst struct{}
+
+func (i RetInst) Exec(s *System) error {
+	v, err := s.pop()
+	if err != nil {
+		return err
+	}
+	return s.jump(v)
 }
+
+func (i RetInst) String() string  { return "RetInst{}" }
+func (i RetInst) encode() []int16 { return words(OpRet, 0, Operand{}) }
+
+// LoadInst Target = Mem[Addr]
+type LoadInst struct {
+	Target Reg
+	Addr   Operand
+}
+
+func (i LoadInst) Exec(s *System) error {
+	p, err := s.mem(s.CPU.value(i.Addr))
+	if err != nil {
+		return err
+	}
+	*s.CPU.reg(i.Target) = *p
+	return nil
+}
+
+func (i LoadInst) String() string  { return fmt.Sprintf("LoadInst{%s, [%s]}", i.Target, i.Addr) }
+func (i LoadInst) encode() []int16 { return words(OpLoad, uint8(i.Target), i.Addr) }
+
+// StoreInst Mem[Addr] = Src
+type StoreInst struct {
+	Addr Operand
+	Src  Reg
+}
+
+func (i StoreInst) Exec(s *System) error {
+	p, err := s.mem(s.CPU.value(i.Addr))
+	if err != nil {
+		return err
+	}
+	*p = *s.CPU.reg(i.Src)
+	return nil
+}
+
+func (i StoreInst) String() string  { return fmt.Sprintf("StoreInst{[%s], %s}", i.Addr, i.Src) }
+func (i StoreInst) encode() []int16 { return words(OpStore, uint8(i.Src), i.Addr) }
+
+// IntInst Raise a Software Interrupt
+type IntInst struct {
+	Code InterruptCode
+}
+
+func (i IntInst) Exec(s *System) error {
+	return s.interrupt(i.Code)
+}
+
+func (i IntInst) String() string  { return fmt.Sprintf("IntInst{%d}", i.Code) }
+func (i IntInst) encode() []int16 { return words(OpInt, 0, Imm(int16(i.Code))) }
@@ -1,6 +1,13 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+)
 
 type InterruptCode uint8
 
@@ -16,17 +23,101 @@ type Interrupt interface {
 
 var _ Inst = (*PrintInt)(nil)
 var _ Interrupt = (*PrintInt)(nil)
+var _ Interrupt = (*DrawPixelInt)(nil)
+
+// Register Install i as the Handler INT i.Code() Runs, Replacing Any
+// Earlier One
+func (s *System) Register(i Interrupt) {
+	if s.Interrupts == nil {
+		s.Interrupts = map[InterruptCode]Interrupt{}
+	}
+	s.Interrupts[i.Code()] = i
+}
 
-type PrintInt struct{}
+// interrupt Run the Handler for code
+func (s *System) interrupt(code InterruptCode) error {
+	h, ok := s.Interrupts[code]
+	if !ok {
+		return fmt.Errorf("no handler for interrupt %d", code)
+	}
+	return h.Exec(s)
+}
+
+// PrintInt Console Device; Prints the Registers to Out, or Stdout if Nil
+type PrintInt struct {
+	Out io.Writer
+}
 
-func (i PrintInt) Exec(s System) {
-	// TODO
+func (i PrintInt) Exec(s *System) error {
+	out := i.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	c := &s.CPU
+	_, err := fmt.Fprintf(out, "eax=%d ebx=%d ecx=%d edx=%d\n", c.Eax, c.Ebx, c.Ecx, c.Edx)
+	return err
 }
 
 func (i PrintInt) String() string {
-	return fmt.Sprintf("PrintInt{%s}", i.Code())
+	return fmt.Sprintf("PrintInt{%d}", i.Code())
 }
 
 func (i PrintInt) Code() InterruptCode {
 	return PrintCode
 }
+
+// DrawPixelInt Framebuffer Device; Sets Pixel (eax, ebx) to the RGB565
+// Color in ecx
+type DrawPixelInt struct {
+	Image *image.RGBA
+}
+
+// NewDrawPixelInt Framebuffer of w x h Black Pixels
+func NewDrawPixelInt(w, h int) DrawPixelInt {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 0xFF
+	}
+	return DrawPixelInt{img}
+}
+
+func (i DrawPixelInt) Exec(s *System) error {
+	x, y := int(s.CPU.Eax), int(s.CPU.Ebx)
+	if !image.Pt(x, y).In(i.Image.Bounds()) {
+		return fmt.Errorf("pixel (%d, %d) off screen", x, y)
+	}
+	i.Image.SetRGBA(x, y, rgb565(uint16(s.CPU.Ecx)))
+	return nil
+}
+
+func (i DrawPixelInt) String() string {
+	return fmt.Sprintf("DrawPixelInt{%d}", i.Code())
+}
+
+func (i DrawPixelInt) Code() InterruptCode {
+	return DrawPixelCode
+}
+
+// WritePNG Encode the Framebuffer
+func (i DrawPixelInt) WritePNG(w io.Writer) error {
+	return png.Encode(w, i.Image)
+}
+
+// SavePNG Write the Framebuffer to a File
+func (i DrawPixelInt) SavePNG(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := i.WritePNG(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// rgb565 Expand a 5:6:5 Color Word to 8 Bits per Channel
+func rgb565(c uint16) color.RGBA {
+	r, g, b := c>>11&0x1F, c>>5&0x3F, c&0x1F
+	return color.RGBA{uint8(r * 255 / 31), uint8(g * 255 / 63), uint8(b * 255 / 31), 0xFF}
+}
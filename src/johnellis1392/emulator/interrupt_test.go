@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func Test_System_Interrupt_ShouldDispatchToHandler(t *testing.T) {
+	var out bytes.Buffer
+	s := NewSystem(Program{Insts: []Inst{
+		MovInst{Eax, Imm(1)},
+		MovInst{Edx, Imm(-4)},
+		IntInst{PrintCode},
+		AddInst{Eax, Imm(1)},
+		IntInst{PrintCode},
+	}})
+	s.Register(PrintInt{&out})
+	if err := s.Run(); err != nil {
+		t.Fatal(err)
+	}
+	want := "eax=1 ebx=0 ecx=0 edx=-4\neax=2 ebx=0 ecx=0 edx=-4\n"
+	if out.String() != want {
+		t.Errorf("Expected %q, got %q", want, out.String())
+	}
+}
+
+func Test_System_Interrupt_ShouldFailWithoutHandler(t *testing.T) {
+	s := NewSystem(Program{Insts: []Inst{IntInst{DrawPixelCode}}})
+	s.Register(PrintInt{})
+	if err := s.Run(); err == nil {
+		t.Error("Expected an error for an unhandled interrupt")
+	}
+}
+
+func Test_DrawPixelInt_ShouldDrawAndEncode(t *testing.T) {
+	p, err := Assemble("draw.s", `
+		mov eax, 2
+		mov ebx, 1
+		mov ecx, 0xF800   ; Red
+		int 1
+		mov eax, 3
+		mov ecx, 0x07E0   ; Green
+		int 1
+		mov ebx, 4        ; Off Screen
+		int 1
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSystem(p)
+	screen := NewDrawPixelInt(4, 4)
+	s.Register(screen)
+	if err := s.Run(); err == nil || s.CPU.Ptr != 9 {
+		t.Errorf("Expected instruction 8 to fail off screen, got %v with Ptr %d", err, s.CPU.Ptr)
+	}
+
+	var buf bytes.Buffer
+	if err := screen.WritePNG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{2, 1, color.RGBA{0xFF, 0, 0, 0xFF}},
+		{3, 1, color.RGBA{0, 0xFF, 0, 0xFF}},
+		{0, 0, color.RGBA{0, 0, 0, 0xFF}},
+	} {
+		if got := color.RGBAModel.Convert(img.At(tt.x, tt.y)); got != tt.want {
+			t.Errorf("Expected %v at (%d, %d), got %v", tt.want, tt.x, tt.y, got)
+		}
+	}
+}
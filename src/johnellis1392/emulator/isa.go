@@ -0,0 +1,245 @@
+package main
+
+import "fmt"
+
+// Instruction Encoding
+//
+// Programs are Sequences of Big-Endian 16-Bit Words. Each Instruction is
+// One Word, Followed by an Immediate Word When its Operand is Immediate:
+//
+//	15      8 7    4 3    0
+//	[ opcode ][  r   ][  o   ]  [imm]
+//
+// r is the Register the Instruction Writes or Reads (0-3: eax-edx), or the
+// Condition for Jcc. o is the Source Operand: a Register (0-3), or
+// operandImm for the Immediate Word that Follows. Fields an Instruction
+// Doesn't Use (Both for HLT and RET, o for POP, r for JMP, PUSH, CALL and
+// INT) Must be 0, so Decode Never Swallows a Word Meant as an Instruction.
+//
+// Jump and Call Targets are Instruction Numbers, the Same as CPU.Ptr, not
+// Word Offsets.
+
+// Opcode Top Byte of an Instruction Word
+type Opcode uint8
+
+const (
+	OpHlt   Opcode = iota // HLT                Stop
+	OpMov                 // MOV   r, o         r = o
+	OpAdd                 // ADD   r, o         r += o
+	OpSub                 // SUB   r, o         r -= o
+	OpCmp                 // CMP   r, o         Flags from r - o
+	OpJmp                 // JMP   o            Ptr = o
+	OpJcc                 // Jcc   o            Ptr = o if Condition r Holds
+	OpPush                // PUSH  o
+	OpPop                 // POP   r
+	OpCall                // CALL  o            Push Ptr, Ptr = o
+	OpRet                 // RET                Pop Ptr
+	OpLoad                // LOAD  r, [o]       r = Mem[o]
+	OpStore               // STORE [o], r       Mem[o] = r
+	OpInt                 // INT   imm          Raise Interrupt imm
+)
+
+// Reg Register Number, as Encoded
+type Reg uint8
+
+const (
+	Eax Reg = iota
+	Ebx
+	Ecx
+	Edx
+)
+
+var regNames = [...]string{"eax", "ebx", "ecx", "edx"}
+
+func (r Reg) String() string {
+	if int(r) < len(regNames) {
+		return regNames[r]
+	}
+	return fmt.Sprintf("r%d", uint8(r))
+}
+
+// Cond Jump Condition, Tested Against the Flags Left by CMP, ADD or SUB
+type Cond uint8
+
+const (
+	CondE  Cond = iota // Equal / Zero
+	CondNE             // Not Equal
+	CondL              // Less (Signed)
+	CondGE             // Greater or Equal
+	CondG              // Greater
+	CondLE             // Less or Equal
+)
+
+var condNames = [...]string{"e", "ne", "l", "ge", "g", "le"}
+
+func (c Cond) String() string {
+	if int(c) < len(condNames) {
+		return condNames[c]
+	}
+	return fmt.Sprintf("cc%d", uint8(c))
+}
+
+// operandImm Operand Field Value Meaning an Immediate Word Follows
+const operandImm = 0xF
+
+// Operand Register or Immediate Source
+type Operand struct {
+	Reg   Reg
+	Imm   int16
+	IsImm bool
+}
+
+// Imm Immediate Operand
+func Imm(v int16) Operand { return Operand{Imm: v, IsImm: true} }
+
+// R Register Operand
+func R(r Reg) Operand { return Operand{Reg: r} }
+
+func (o Operand) String() string {
+	if o.IsImm {
+		return fmt.Sprint(o.Imm)
+	}
+	return o.Reg.String()
+}
+
+// field Encoded o Field
+func (o Operand) field() uint16 {
+	if o.IsImm {
+		return operandImm
+	}
+	return uint16(o.Reg)
+}
+
+// words Encode an Instruction with Register / Condition r and Operand o
+func words(op Opcode, r uint8, o Operand) []int16 {
+	w := []int16{int16(uint16(op)<<8 | uint16(r&0xF)<<4 | o.field())}
+	if o.IsImm {
+		w = append(w, o.Imm)
+	}
+	return w
+}
+
+// encoder Instruction with a Binary Encoding
+type encoder interface {
+	encode() []int16
+}
+
+// Encode Assemble Instructions into Program Words
+func Encode(insts []Inst) ([]int16, error) {
+	var out []int16
+	for i, inst := range insts {
+		e, ok := inst.(encoder)
+		if !ok {
+			return nil, fmt.Errorf("instruction %d: %v has no encoding", i, inst)
+		}
+		out = append(out, e.encode()...)
+	}
+	return out, nil
+}
+
+// fields Whether an Opcode's Encoding Uses its r and o Fields; Unused
+// Fields Must be 0
+func (op Opcode) fields() (r, o bool) {
+	switch op {
+	case OpHlt, OpRet:
+		return false, false
+	case OpPop:
+		return true, false
+	case OpJmp, OpPush, OpCall, OpInt:
+		return false, true
+	}
+	return true, true
+}
+
+// DecodeError Malformed Instruction at a Word Offset
+type DecodeError struct {
+	Offset int
+	Msg    string
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("word %d: %s", e.Offset, e.Msg)
+}
+
+// Decode Split Program Words into Instructions
+func Decode(code []int16) ([]Inst, error) {
+	var insts []Inst
+	for pc := 0; pc < len(code); {
+		w := uint16(code[pc])
+		op, r, f := Opcode(w>>8), Reg(w>>4&0xF), w&0xF
+		fail := func(format string, args ...interface{}) ([]Inst, error) {
+			return nil, &DecodeError{pc, fmt.Sprintf(format, args...)}
+		}
+
+		usesR, usesO := op.fields()
+		if !usesR && r != 0 {
+			return fail("register field %d unused by opcode $%02X", r, uint8(op))
+		}
+		if !usesO && f != 0 {
+			return fail("operand field %d unused by opcode $%02X", f, uint8(op))
+		}
+
+		var o Operand
+		switch {
+		case f == operandImm:
+			if pc+1 >= len(code) {
+				return fail("missing immediate word")
+			}
+			o = Imm(code[pc+1])
+		case f <= uint16(Edx):
+			o = R(Reg(f))
+		default:
+			return fail("bad operand field %d", f)
+		}
+		if op != OpJcc && r > Edx {
+			return fail("bad register %d", r)
+		}
+
+		var inst Inst
+		switch op {
+		case OpHlt:
+			inst = HltInst{}
+		case OpMov:
+			inst = MovInst{r, o}
+		case OpAdd:
+			inst = AddInst{r, o}
+		case OpSub:
+			inst = SubInst{r, o}
+		case OpCmp:
+			inst = CmpInst{r, o}
+		case OpJmp:
+			inst = JmpInst{o}
+		case OpJcc:
+			if int(r) >= len(condNames) {
+				return fail("bad condition %d", r)
+			}
+			inst = JccInst{Cond(r), o}
+		case OpPush:
+			inst = PushInst{o}
+		case OpPop:
+			inst = PopInst{r}
+		case OpCall:
+			inst = CallInst{o}
+		case OpRet:
+			inst = RetInst{}
+		case OpLoad:
+			inst = LoadInst{r, o}
+		case OpStore:
+			inst = StoreInst{o, r}
+		case OpInt:
+			if !o.IsImm || o.Imm < 0 || o.Imm > 0xFF {
+				return fail("INT needs an immediate code 0-255")
+			}
+			inst = IntInst{InterruptCode(o.Imm)}
+		default:
+			return fail("unknown opcode $%02X", uint8(op))
+		}
+		insts = append(insts, inst)
+
+		pc++
+		if o.IsImm {
+			pc++
+		}
+	}
+	return insts, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Encode_ShouldRoundTripThroughDecode(t *testing.T) {
+	insts := []Inst{
+		MovInst{Eax, Imm(-5)},
+		AddInst{Ebx, R(Ecx)},
+		SubInst{Edx, Imm(300)},
+		CmpInst{Eax, R(Edx)},
+		JmpInst{Imm(0)},
+		JccInst{CondLE, R(Ebx)},
+		PushInst{Imm(7)},
+		PopInst{Ecx},
+		CallInst{Imm(2)},
+		RetInst{},
+		LoadInst{Eax, Imm(0x10)},
+		StoreInst{R(Ebx), Edx},
+		IntInst{PrintCode},
+		HltInst{},
+	}
+	code, err := Encode(insts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, insts) {
+		t.Errorf("Expected %v, got %v", insts, got)
+	}
+}
+
+func Test_Decode_ShouldRejectMalformedWords(t *testing.T) {
+	for _, code := range [][]int16{
+		{0x010F},         // MOV eax, Missing Immediate
+		{0x0104},         // Operand Field 4
+		{0x0150},         // Register 5
+		{0x0660},         // Condition 6
+		{0x0D01},         // INT with a Register
+		{0x0D0F, 0x0100}, // INT 256
+		{0x7F00},         // Unknown Opcode
+		{0x000F, 0x0100}, // HLT with an Immediate
+		{0x0A01},         // RET with a Register Operand
+		{0x080F, 0x0001}, // POP eax with an Immediate
+		{0x0010},         // HLT with a Register
+		{0x0510, 0x0000}, // JMP with a Register
+		{0x071F, 0x0001}, // PUSH with a Register
+		{0x0920},         // CALL with a Register
+		{0x0D1F, 0x0001}, // INT with a Register
+	} {
+		if _, err := Decode(code); err == nil {
+			t.Errorf("Expected an error decoding %04X", code)
+		}
+	}
+}
+
+func Test_System_Run_ShouldExecuteProgram(t *testing.T) {
+	// Sum 1..5 into Mem[0] via a Subroutine that Clobbers ecx, Which the
+	// Loop Keeps on the Stack Across the Call
+	p := Program{Insts: []Inst{
+		MovInst{Ecx, Imm(5)},   // 0
+		PushInst{R(Ecx)},       // 1  loop:
+		CallInst{Imm(8)},       // 2
+		PopInst{Ecx},           // 3
+		SubInst{Ecx, Imm(1)},   // 4
+		CmpInst{Ecx, Imm(0)},   // 5
+		JccInst{CondG, Imm(1)}, // 6
+		HltInst{},              // 7
+		LoadInst{Eax, Imm(0)},  // 8  add:
+		AddInst{Eax, R(Ecx)},   // 9
+		StoreInst{Imm(0), Eax}, // 10
+		MovInst{Ecx, Imm(-1)},  // 11
+		RetInst{},              // 12
+		MovInst{Edx, Imm(-1)},  // 13 Never Reached
+	}}
+	s := NewSystem(p)
+	if err := s.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if s.Mem[0] != 15 || s.CPU.Ecx != 0 || s.CPU.Ptr != 8 || !s.CPU.Halted || s.CPU.Edx != 0 {
+		t.Errorf("Expected Mem[0]=15 and ecx=0 Halted at 8, got %d at %d (%+v)", s.Mem[0], s.CPU.Ptr, s.CPU)
+	}
+	if s.CPU.Sp != len(s.Mem) {
+		t.Errorf("Expected an empty stack, Sp=%d", s.CPU.Sp)
+	}
+}
+
+func Test_System_Run_ShouldFinishAtEndOfProgram(t *testing.T) {
+	s := NewSystem(Program{Insts: []Inst{JmpInst{Imm(2)}, HltInst{}}})
+	if err := s.Run(); err != nil || s.CPU.Halted || !s.Done() {
+		t.Errorf("Expected to run off the end, got %v %+v", err, s.CPU)
+	}
+}
+
+func Test_System_Run_ShouldFailCleanly(t *testing.T) {
+	for _, insts := range [][]Inst{
+		{JmpInst{Imm(3)}},
+		{RetInst{}},
+		{LoadInst{Eax, Imm(MemSize)}},
+		{StoreInst{Imm(-1), Eax}},
+	} {
+		s := NewSystem(Program{Insts: insts})
+		if err := s.Run(); err == nil {
+			t.Errorf("Expected %v to fail", insts)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	digits    = "0123456789"
+	hexDigits = "0123456789abcdefABCDEF"
+
+	alphabetLower = "abcdefghijklmnopqrstuvwxyz"
+	alphabetUpper = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	alphabet      = alphabetLower + alphabetUpper
+
+	underscore    = "_"
+	alphaNumerics = alphabet + digits + underscore
+)
+
+const eof rune = -1
+
+type itemType int
+
+const (
+	itemError itemType = iota
+	itemEOF
+	itemNewline
+	itemIdentifier
+	itemDirective
+	itemNumber
+	itemComma
+	itemColon
+	itemLeftBracket
+	itemRightBracket
+)
+
+func (i itemType) String() string {
+	switch i {
+	case itemError:
+		return "itemError"
+	case itemEOF:
+		return "itemEOF"
+	case itemNewline:
+		return "itemNewline"
+	case itemIdentifier:
+		return "itemIdentifier"
+	case itemDirective:
+		return "itemDirective"
+	case itemNumber:
+		return "itemNumber"
+	case itemComma:
+		return "itemComma"
+	case itemColon:
+		return "itemColon"
+	case itemLeftBracket:
+		return "itemLeftBracket"
+	case itemRightBracket:
+		return "itemRightBracket"
+	default:
+		return "(unknown)"
+	}
+}
+
+// item Token with the 1-Based Line and Column it Starts at
+type item struct {
+	typ  itemType
+	val  string
+	line int
+	col  int
+}
+
+// String Overloads Printf target method for debug-printing items.
+func (i item) String() string {
+	switch i.typ {
+	case itemEOF:
+		return "EOF"
+	case itemNewline:
+		return "newline"
+	case itemError:
+		return i.val
+	}
+	return fmt.Sprintf("%q", i.val)
+}
+
+type lexer struct {
+	name      string
+	input     string
+	start     int
+	pos       int
+	width     int
+	line      int
+	lineStart int // Offset of the Current Line, for Columns
+	items     chan item
+}
+
+type stateFn func(*lexer) stateFn
+
+// lexLine Between Tokens
+func lexLine(l *lexer) stateFn {
+	for {
+		switch r := l.next(); {
+		case r == eof:
+			l.emit(itemEOF)
+			return nil
+		case r == '\n':
+			l.emit(itemNewline)
+			l.line++
+			l.lineStart = l.pos
+		case r == ' ' || r == '\t' || r == '\r':
+			l.ignore()
+		case r == ';' || r == '#':
+			return lexComment
+		case r == ',':
+			l.emit(itemComma)
+		case r == ':':
+			l.emit(itemColon)
+		case r == '[':
+			l.emit(itemLeftBracket)
+		case r == ']':
+			l.emit(itemRightBracket)
+		case r == '.':
+			return lexDirective
+		case r == '-' || '0' <= r && r <= '9':
+			l.backup()
+			return lexNumber
+		case isAlphaNumeric(r):
+			l.backup()
+			return lexIdentifier
+		default:
+			return l.errorf("unexpected character %q", r)
+		}
+	}
+}
+
+// lexComment Skip to the End of the Line
+func lexComment(l *lexer) stateFn {
+	for r := l.peek(); r != '\n' && r != eof; r = l.peek() {
+		l.next()
+	}
+	l.ignore()
+	return lexLine
+}
+
+func lexDirective(l *lexer) stateFn {
+	if !l.accept(alphabet) {
+		return l.errorf("bad directive %q", l.input[l.start:l.pos])
+	}
+	l.acceptRun(alphaNumerics)
+	l.emit(itemDirective)
+	return lexLine
+}
+
+func lexIdentifier(l *lexer) stateFn {
+	if !l.accept(alphabet + underscore) {
+		return l.errorf("invalid identifier start character: %q", l.peek())
+	}
+	l.acceptRun(alphaNumerics)
+	l.emit(itemIdentifier)
+	return lexLine
+}
+
+func lexNumber(l *lexer) stateFn {
+	l.accept("-")
+	valid := digits
+	if l.accept("0") && l.accept("xX") {
+		valid = hexDigits
+	}
+	l.acceptRun(valid)
+	if isAlphaNumeric(l.peek()) || l.input[l.pos-1] == '-' {
+		l.next()
+		return l.errorf("bad number syntax: %q", l.input[l.start:l.pos])
+	}
+	l.emit(itemNumber)
+	return lexLine
+}
+
+func (l *lexer) errorf(format string, args ...interface{}) stateFn {
+	l.items <- item{itemError, fmt.Sprintf(format, args...), l.line, l.start - l.lineStart + 1}
+	return nil
+}
+
+func (l *lexer) emit(t itemType) {
+	l.items <- item{t, l.input[l.start:l.pos], l.line, l.start - l.lineStart + 1}
+	l.start = l.pos
+}
+
+func (l *lexer) accept(valid string) bool {
+	if strings.IndexRune(valid, l.next()) >= 0 {
+		return true
+	}
+	l.backup()
+	return false
+}
+
+func (l *lexer) acceptRun(valid string) {
+	for strings.IndexRune(valid, l.next()) >= 0 {
+	}
+	l.backup()
+}
+
+func (l *lexer) peek() rune {
+	r := l.next()
+	l.backup()
+	return r
+}
+
+func (l *lexer) ignore() {
+	l.start = l.pos
+}
+
+func (l *lexer) backup() {
+	l.pos -= l.width
+}
+
+func (l *lexer) next() rune {
+	if l.pos >= len(l.input) {
+		l.width = 0
+		return eof
+	}
+
+	var r rune
+	r, l.width = utf8.DecodeRuneInString(l.input[l.pos:])
+	l.pos += l.width
+	return r
+}
+
+func (l *lexer) run() {
+	for state := stateFn(lexLine); state != nil; {
+		state = state(l)
+	}
+	close(l.items)
+}
+
+func lex(name, input string) (*lexer, chan item) {
+	l := &lexer{
+		name:  name,
+		input: input,
+		line:  1,
+		items: make(chan item, bufferSize),
+	}
+	go l.run()
+	return l, l.items
+}
+
+func isAlphaNumeric(r rune) bool {
+	return strings.ContainsRune(alphaNumerics, r)
+}
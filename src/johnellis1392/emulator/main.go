@@ -1,14 +1,24 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"strings"
 )
 
+// Framebuffer Size for DrawPixelCode
+const (
+	screenWidth  = 128
+	screenHeight = 128
+)
+
+// chunk Split Bytes into Big-Endian Words, Dropping an Odd Trailing Byte
 func chunk(input []byte) []int16 {
-	var res []int16
-	for i := 0; i < len(input); i += 2 {
-		res[i/2] = int16(input[i]<<8) | int16(input[i+1])
+	res := make([]int16, len(input)/2)
+	for i := range res {
+		res[i] = int16(uint16(input[2*i])<<8 | uint16(input[2*i+1]))
 	}
 	return res
 }
@@ -23,17 +33,13 @@ func ReadProgram(filename string) (Program, error) {
 
 	// TODO: Handle any headers or blocks that may be found in these files.
 
-	var insts []Inst
+	if len(data)%2 != 0 {
+		return Program{}, fmt.Errorf("%s: odd length %d; programs are 16-bit words", filename, len(data))
+	}
 	vs := chunk(data)
-	for _, b := range vs {
-		// TODO: Handle remainder of instructions
-		switch b {
-		case 0x01:
-			break
-		default:
-			// Unknown Instruction
-			break
-		}
+	insts, err := Decode(vs)
+	if err != nil {
+		return Program{}, fmt.Errorf("%s: %v", filename, err)
 	}
 
 	// TODO: Read Data
@@ -46,17 +52,38 @@ func ReadProgram(filename string) (Program, error) {
 }
 
 func main() {
-	const filename = "./test.txt"
+	pngFile := flag.String("png", "", "save the framebuffer to this PNG file when the program ends")
+	flag.Parse()
+
+	filename := "./test.txt"
+	if flag.NArg() > 0 {
+		filename = flag.Arg(0)
+	}
 	fmt.Printf("Reading file: %s\n", filename)
-	p, err := ReadProgram(filename)
+	read := ReadProgram
+	if strings.HasSuffix(filename, ".s") {
+		read = AssembleFile
+	}
+	p, err := read(filename)
 	if err != nil {
 		panic(err)
 	}
 
 	s := NewSystem(p)
-	for inst := p.Insts[p.Ptr]; inst != nil; {
-		fmt.Printf("Executing Instruction: %v\n", inst.String())
-		inst.Exec(s)
+	screen := NewDrawPixelInt(screenWidth, screenHeight)
+	s.Register(PrintInt{os.Stdout})
+	s.Register(screen)
+	for !s.Done() {
+		fmt.Printf("Executing Instruction: %v\n", s.Insts[s.CPU.Ptr].String())
+		if err := s.Step(); err != nil {
+			panic(err)
+		}
+	}
+
+	if *pngFile != "" {
+		if err := screen.SavePNG(*pngFile); err != nil {
+			panic(err)
+		}
 	}
 
 	fmt.Println("Done")
@@ -1,29 +1,441 @@
 package main
 
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// Assembly Syntax
+//
+//	; Comments Run to the End of the Line (# Works Too)
+//	.data                 ; Words Copied to Memory at Address 0
+//	count:  .word 5, -1, 0x10
+//	.bss                  ; Zeroed Words Following .data
+//	buf:    .space 16
+//	.text                 ; Instructions (the Default Section)
+//	loop:   load eax, [count]
+//	        add eax, ebx
+//	        jne loop
+//	        int 1
+//	        hlt
+//
+// Operands are Registers, Numbers or Labels. A Text Label is an Instruction
+// Number and a Data or Bss Label is a Memory Address, so `mov eax, buf`
+// Loads the Address and `load eax, [buf]` the Word There. Jcc is Written
+// j<cond>: je, jne, jl, jge, jg and jle.
+
 const (
 	bufferSize = 10
 )
 
+// section Part of the Program a Line Assembles into
+type section int
+
+const (
+	sectionText section = iota
+	sectionData
+	sectionBss
+)
+
+// symbol Label Defined at an Offset in its Section
+type symbol struct {
+	section section
+	off     int
+}
+
+// AsmError Assembly Error at a Source Position
+type AsmError struct {
+	Name      string
+	Line, Col int
+	Msg       string
+}
+
+func (e *AsmError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.Name, e.Line, e.Col, e.Msg)
+}
+
 type parser struct {
+	name   string
 	input  string
 	output chan Inst
-	pos    int
+	err    error // Set Before output is Closed
+
+	items   []item // The Current Line
+	pos     int
+	pass    int
+	section section
+	symbols map[string]symbol
+	text    int // Instructions so Far
+	data    []int16
+	bss     int
+	dataLen int // Words of .data, Known After Pass 1; .bss Follows
 }
 
+// run Assemble in Two Passes over the Lexed Lines: the First Defines
+// Labels, the Second Streams Instructions
 func (p *parser) run() {
+	defer close(p.output)
+
+	var lines [][]item
+	_, items := lex(p.name, p.input)
+	var line []item
+	for it := range items {
+		if it.typ == itemError {
+			p.err = &AsmError{p.name, it.line, it.col, it.val}
+			for range items {
+			}
+			return
+		}
+		line = append(line, it)
+		if it.typ == itemNewline || it.typ == itemEOF {
+			lines = append(lines, line)
+			line = nil
+		}
+	}
+
+	for p.pass = 1; p.pass <= 2; p.pass++ {
+		p.dataLen = len(p.data)
+		p.section, p.text, p.data, p.bss = sectionText, 0, nil, 0
+		for _, l := range lines {
+			p.items, p.pos = l, 0
+			if err := p.line(); err != nil {
+				p.err = err
+				return
+			}
+		}
+	}
+}
+
+// line Assemble p.items
+func (p *parser) line() error {
+	for p.peek().typ == itemIdentifier && p.peekAt(1).typ == itemColon {
+		if err := p.label(p.next()); err != nil {
+			return err
+		}
+		p.next()
+	}
+
+	switch it := p.next(); it.typ {
+	case itemNewline, itemEOF:
+		return nil
+	case itemDirective:
+		if err := p.directive(it); err != nil {
+			return err
+		}
+	case itemIdentifier:
+		if p.section != sectionText {
+			return p.errorf(it, "instruction %s outside .text", it.val)
+		}
+		inst, err := p.instruction(it)
+		if err != nil {
+			return err
+		}
+		p.text++
+		if p.pass == 2 {
+			p.output <- inst
+		}
+	default:
+		return p.errorf(it, "unexpected %v", it)
+	}
+	return p.end()
+}
+
+func (p *parser) label(it item) error {
+	if p.pass == 2 {
+		return nil
+	}
+	if _, ok := registers[strings.ToLower(it.val)]; ok {
+		return p.errorf(it, "register %s used as a label", it.val)
+	}
+	if _, ok := p.symbols[it.val]; ok {
+		return p.errorf(it, "label %s redefined", it.val)
+	}
+	off := p.text
+	switch p.section {
+	case sectionData:
+		off = len(p.data)
+	case sectionBss:
+		off = p.bss
+	}
+	p.symbols[it.val] = symbol{p.section, off}
+	return nil
+}
+
+func (p *parser) directive(it item) error {
+	switch it.val {
+	case ".text":
+		p.section = sectionText
+	case ".data":
+		p.section = sectionData
+	case ".bss":
+		p.section = sectionBss
+	case ".word":
+		if p.section != sectionData {
+			return p.errorf(it, ".word outside .data")
+		}
+		for {
+			v, err := p.immediate()
+			if err != nil {
+				return err
+			}
+			p.data = append(p.data, v)
+			if p.peek().typ != itemComma {
+				return nil
+			}
+			p.next()
+		}
+	case ".space":
+		if p.section == sectionText {
+			return p.errorf(it, ".space outside .data or .bss")
+		}
+		n := p.next()
+		v, err := p.number(n)
+		if err != nil {
+			return err
+		}
+		if v < 0 {
+			return p.errorf(n, "negative .space")
+		}
+		if p.section == sectionData {
+			p.data = append(p.data, make([]int16, v)...)
+		} else {
+			p.bss += int(v)
+		}
+	default:
+		return p.errorf(it, "unknown directive %s", it.val)
+	}
+	return nil
+}
+
+var registers = map[string]Reg{"eax": Eax, "ebx": Ebx, "ecx": Ecx, "edx": Edx}
+
+// instruction Parse the Operands of Mnemonic it
+func (p *parser) instruction(it item) (Inst, error) {
+	var (
+		r   Reg
+		o   Operand
+		err error
+	)
+	switch strings.ToLower(it.val) {
+	case "hlt":
+		return HltInst{}, nil
+	case "ret":
+		return RetInst{}, nil
+	case "mov", "add", "sub", "cmp":
+		if r, err = p.register(); err == nil {
+			err = p.comma()
+		}
+		if err == nil {
+			o, err = p.operand()
+		}
+		switch strings.ToLower(it.val) {
+		case "mov":
+			return MovInst{r, o}, err
+		case "add":
+			return AddInst{r, o}, err
+		case "sub":
+			return SubInst{r, o}, err
+		}
+		return CmpInst{r, o}, err
+	case "jmp":
+		o, err = p.operand()
+		return JmpInst{o}, err
+	case "call":
+		o, err = p.operand()
+		return CallInst{o}, err
+	case "push":
+		o, err = p.operand()
+		return PushInst{o}, err
+	case "pop":
+		r, err = p.register()
+		return PopInst{r}, err
+	case "load":
+		if r, err = p.register(); err == nil {
+			err = p.comma()
+		}
+		if err == nil {
+			o, err = p.address()
+		}
+		return LoadInst{r, o}, err
+	case "store":
+		if o, err = p.address(); err == nil {
+			err = p.comma()
+		}
+		if err == nil {
+			r, err = p.register()
+		}
+		return StoreInst{o, r}, err
+	case "int":
+		n := p.next()
+		v, err := p.number(n)
+		if err == nil && (v < 0 || v > 0xFF) {
+			err = p.errorf(n, "interrupt code %d out of range", v)
+		}
+		return IntInst{InterruptCode(v)}, err
+	}
+	for i, name := range condNames {
+		if strings.EqualFold(it.val, "j"+name) {
+			o, err = p.operand()
+			return JccInst{Cond(i), o}, err
+		}
+	}
+	return nil, p.errorf(it, "unknown instruction %s", it.val)
+}
+
+func (p *parser) register() (Reg, error) {
+	it := p.next()
+	r, ok := registers[strings.ToLower(it.val)]
+	if it.typ != itemIdentifier || !ok {
+		return 0, p.errorf(it, "expected a register, found %v", it)
+	}
+	return r, nil
+}
+
+// operand Register, Number or Label
+func (p *parser) operand() (Operand, error) {
+	if it := p.peek(); it.typ == itemIdentifier {
+		if r, ok := registers[strings.ToLower(it.val)]; ok {
+			p.next()
+			return R(r), nil
+		}
+	}
+	v, err := p.immediate()
+	return Imm(v), err
+}
+
+// address Bracketed Operand of LOAD and STORE
+func (p *parser) address() (Operand, error) {
+	if it := p.next(); it.typ != itemLeftBracket {
+		return Operand{}, p.errorf(it, "expected [, found %v", it)
+	}
+	o, err := p.operand()
+	if err != nil {
+		return o, err
+	}
+	if it := p.next(); it.typ != itemRightBracket {
+		return o, p.errorf(it, "expected ], found %v", it)
+	}
+	return o, nil
+}
 
+// immediate Number or Label; Labels are 0 in the First Pass
+func (p *parser) immediate() (int16, error) {
+	it := p.next()
+	switch it.typ {
+	case itemNumber:
+		return p.number(it)
+	case itemIdentifier:
+		if p.pass == 1 {
+			return 0, nil
+		}
+		sym, ok := p.symbols[it.val]
+		if !ok {
+			return 0, p.errorf(it, "undefined label %s", it.val)
+		}
+		if sym.section == sectionBss {
+			return int16(p.dataLen + sym.off), nil
+		}
+		return int16(sym.off), nil
+	}
+	return 0, p.errorf(it, "expected a number or label, found %v", it)
+}
+
+// number Word Literal; Hex Literals up to 0xFFFF Wrap to Negative
+func (p *parser) number(it item) (int16, error) {
+	if it.typ != itemNumber {
+		return 0, p.errorf(it, "expected a number, found %v", it)
+	}
+	n, err := strconv.ParseInt(it.val, 0, 32)
+	if err != nil || n < -0x8000 || n > 0xFFFF {
+		return 0, p.errorf(it, "bad number %s", it.val)
+	}
+	return int16(n), nil
 }
 
-func newParser(input string) *parser {
+func (p *parser) comma() error {
+	if it := p.next(); it.typ != itemComma {
+		return p.errorf(it, "expected \",\", found %v", it)
+	}
+	return nil
+}
+
+// end Expect the End of the Line
+func (p *parser) end() error {
+	if it := p.next(); it.typ != itemNewline && it.typ != itemEOF {
+		return p.errorf(it, "unexpected %v at end of line", it)
+	}
+	return nil
+}
+
+// next / peek / peekAt Step Through the Line; Past its End They Return its
+// Final Newline or EOF
+func (p *parser) next() item {
+	it := p.peek()
+	if p.pos < len(p.items)-1 {
+		p.pos++
+	}
+	return it
+}
+
+func (p *parser) peek() item { return p.peekAt(0) }
+
+func (p *parser) peekAt(n int) item {
+	if p.pos+n >= len(p.items) {
+		return p.items[len(p.items)-1]
+	}
+	return p.items[p.pos+n]
+}
+
+func (p *parser) errorf(it item, format string, args ...interface{}) error {
+	return &AsmError{p.name, it.line, it.col, fmt.Sprintf(format, args...)}
+}
+
+func newParser(name, input string) *parser {
 	return &parser{
-		input:  input,
-		output: make(chan Inst, bufferSize),
-		pos:    0,
+		name:    name,
+		input:   input,
+		output:  make(chan Inst, bufferSize),
+		symbols: map[string]symbol{},
 	}
 }
 
-func Parse(input string) chan Inst {
-	p := newParser(input)
+// Parse Stream the Instructions of Assembly Source; After the Channel
+// Closes, the Parser's err Says Whether it Stopped Early
+func Parse(name, input string) (*parser, chan Inst) {
+	p := newParser(name, input)
 	go p.run()
-	return p.output
+	return p, p.output
+}
+
+// Assemble Build a Program from Assembly Source
+func Assemble(name, input string) (Program, error) {
+	p, insts := Parse(name, input)
+	var prog Program
+	for inst := range insts {
+		prog.Insts = append(prog.Insts, inst)
+	}
+	if p.err != nil {
+		return Program{}, p.err
+	}
+	if len(p.data)+p.bss > MemSize {
+		return Program{}, fmt.Errorf("%s: %d words of data and bss exceed memory", name, len(p.data)+p.bss)
+	}
+	prog.Data, prog.Bss = p.data, make([]int16, p.bss)
+	raw, err := Encode(prog.Insts)
+	if err != nil {
+		return Program{}, err
+	}
+	prog.Raw = raw
+	return prog, nil
+}
+
+// AssembleFile Assemble a Source File
+func AssembleFile(filename string) (Program, error) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return Program{}, err
+	}
+	return Assemble(filename, string(src))
 }
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Assemble_ShouldBuildProgram(t *testing.T) {
+	p, err := AssembleFile("testdata/sum.s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Inst{
+		MovInst{Ebx, Imm(0)},
+		LoadInst{Ecx, Imm(5)},
+		PushInst{R(Ebx)},
+		CallInst{Imm(9)},
+		PopInst{Ebx},
+		AddInst{Ebx, Imm(1)},
+		SubInst{Ecx, Imm(1)},
+		JccInst{CondG, Imm(2)},
+		HltInst{},
+		LoadInst{Edx, R(Ebx)},
+		LoadInst{Eax, Imm(6)},
+		AddInst{Eax, R(Edx)},
+		StoreInst{Imm(6), Eax},
+		RetInst{},
+	}
+	if !reflect.DeepEqual(p.Insts, want) {
+		t.Errorf("Expected %v, got %v", want, p.Insts)
+	}
+	if !reflect.DeepEqual(p.Data, []int16{1, 2, 3, 4, 16, 5}) || len(p.Bss) != 1 {
+		t.Errorf("Expected data and one bss word, got %v %v", p.Data, p.Bss)
+	}
+	if insts, err := Decode(p.Raw); err != nil || !reflect.DeepEqual(insts, want) {
+		t.Errorf("Expected Raw to decode to the program, got %v %v", insts, err)
+	}
+
+	s := NewSystem(p)
+	if err := s.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if s.Mem[6] != 26 {
+		t.Errorf("Expected total 26, got %d", s.Mem[6])
+	}
+}
+
+func Test_Parse_ShouldStreamInstructions(t *testing.T) {
+	p, insts := Parse("stream", "JE end\nint 0x01 ; draw\nend: mov edx, -0x8000")
+	var got []Inst
+	for inst := range insts {
+		got = append(got, inst)
+	}
+	want := []Inst{JccInst{CondE, Imm(2)}, IntInst{DrawPixelCode}, MovInst{Edx, Imm(-0x8000)}}
+	if p.err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v (%v)", want, got, p.err)
+	}
+}
+
+func Test_Assemble_ShouldReportPosition(t *testing.T) {
+	for _, tt := range []struct {
+		src       string
+		line, col int
+	}{
+		{"mov eax, 1\nadd eax 2", 2, 9},
+		{"hlt\n  jmp nowhere", 2, 7},
+		{"x: hlt\nx: hlt", 2, 1},
+		{"eax: hlt", 1, 1},
+		{"mov eax, 1 $", 1, 12},
+		{"mov eax, 0x10000", 1, 10},
+		{"frob eax", 1, 1},
+		{".data\n  hlt", 2, 3},
+		{".text\n.word 1", 2, 1},
+		{"load eax, count", 1, 11},
+		{"int 256", 1, 5},
+		{"hlt eax", 1, 5},
+		{"mov eax, 12ab", 1, 10},
+	} {
+		_, err := Assemble("t.s", tt.src)
+		e, ok := err.(*AsmError)
+		if !ok || e.Line != tt.line || e.Col != tt.col {
+			t.Errorf("%q: expected an error at %d:%d, got %v", tt.src, tt.line, tt.col, err)
+		}
+	}
+}
+
+func Test_Assemble_ShouldPlaceBssAfterAllData(t *testing.T) {
+	p, err := Assemble("t.s", `
+.text
+	mov eax, buf
+	hlt
+.data
+ptr:	.word buf
+d:	.word 7, 8
+.bss
+buf:	.space 1
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (MovInst{Eax, Imm(3)}); p.Insts[0] != want {
+		t.Errorf("Expected %v, got %v", want, p.Insts[0])
+	}
+	if !reflect.DeepEqual(p.Data, []int16{3, 7, 8}) {
+		t.Errorf("Expected ptr to hold 3, got data %v", p.Data)
+	}
+}
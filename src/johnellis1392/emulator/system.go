@@ -1,22 +1,61 @@
 package main
 
+import "fmt"
+
+// MemSize Words of Memory; the Stack Grows Down from the Top
+const MemSize = 0x1000
+
 type System struct {
 	CPU
 	Mem
 	Program
+	Interrupts map[InterruptCode]Interrupt // Handlers by Code; See Register
 }
 
+// NewSystem Load a Program, with its Data at Address 0, the Zeroed Bss
+// After it and the Stack Empty
 func NewSystem(p Program) System {
+	mem := make(Mem, MemSize)
+	copy(mem, p.Data)
 	return System{
 		Program: p,
-		CPU:     CPU{},
-		Mem:     Mem{},
+		CPU:     CPU{Sp: len(mem)},
+		Mem:     mem,
 	}
 }
 
 type CPU struct {
 	Eax, Ebx, Ecx, Edx int16
-	Ptr                int
+	Ptr                int  // Number of the Next Instruction
+	Sp                 int  // Stack Pointer; Mem[Sp] is the Top
+	Zf, Sf             bool // Zero and Less-Than Flags
+	Halted             bool
+}
+
+// reg Register r's Storage
+func (c *CPU) reg(r Reg) *int16 {
+	switch r {
+	case Ebx:
+		return &c.Ebx
+	case Ecx:
+		return &c.Ecx
+	case Edx:
+		return &c.Edx
+	}
+	return &c.Eax
+}
+
+// value Read an Operand
+func (c *CPU) value(o Operand) int16 {
+	if o.IsImm {
+		return o.Imm
+	}
+	return *c.reg(o.Reg)
+}
+
+// setFlags Set Zf and Sf from an Arithmetic Result
+func (c *CPU) setFlags(v int16) {
+	c.Zf, c.Sf = v == 0, v < 0
 }
 
 type Mem []int16
@@ -27,3 +66,66 @@ type Program struct {
 	Bss   []int16
 	Raw   []int16
 }
+
+// Done Whether the Program has Halted or Run Off its End
+func (s *System) Done() bool {
+	return s.CPU.Halted || s.CPU.Ptr == len(s.Insts)
+}
+
+// Step Execute the Next Instruction
+func (s *System) Step() error {
+	if s.Done() {
+		return nil
+	}
+	inst := s.Insts[s.CPU.Ptr]
+	s.CPU.Ptr++
+	if err := inst.Exec(s); err != nil {
+		return fmt.Errorf("instruction %d (%v): %v", s.CPU.Ptr-1, inst, err)
+	}
+	return nil
+}
+
+// Run Execute Until the Program Halts, Runs Off its End or Fails
+func (s *System) Run() error {
+	for !s.Done() {
+		if err := s.Step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jump Continue at Instruction n; Jumping Just Past the End Finishes
+func (s *System) jump(n int16) error {
+	if n < 0 || int(n) > len(s.Insts) {
+		return fmt.Errorf("jump to %d outside the program", n)
+	}
+	s.CPU.Ptr = int(n)
+	return nil
+}
+
+// mem Storage of Memory Word addr
+func (s *System) mem(addr int16) (*int16, error) {
+	if addr < 0 || int(addr) >= len(s.Mem) {
+		return nil, fmt.Errorf("address %d out of range", addr)
+	}
+	return &s.Mem[addr], nil
+}
+
+func (s *System) push(v int16) error {
+	if s.CPU.Sp == 0 {
+		return fmt.Errorf("stack overflow")
+	}
+	s.CPU.Sp--
+	s.Mem[s.CPU.Sp] = v
+	return nil
+}
+
+func (s *System) pop() (int16, error) {
+	if s.CPU.Sp >= len(s.Mem) {
+		return 0, fmt.Errorf("stack underflow")
+	}
+	v := s.Mem[s.CPU.Sp]
+	s.CPU.Sp++
+	return v, nil
+}
@@ -0,0 +1,24 @@
+; Sum the table into total, one call per entry
+.data
+table:  .word 1, 2, 3, 4, 0x10
+count:  .word 5
+
+.bss
+total:  .space 1
+
+.text
+        mov ebx, table          ; Address of the Next Entry
+        load ecx, [count]
+loop:   push ebx
+        call add
+        pop ebx
+        add ebx, 1
+        sub ecx, 1
+        jg loop
+        hlt
+
+add:    load edx, [ebx]         # Entry
+        load eax, [total]
+        add eax, edx
+        store [total], eax
+        ret
@@ -0,0 +1,107 @@
+package main
+
+// mode Addressing Mode of an Instruction
+//
+// See the List of Addressing Modes in main.go.
+type mode byte
+
+const (
+	implied     mode = iota // No Operand
+	accumulator             // Operates on A
+	immediate               // #$nn
+	zeroPage                // $nn
+	zeroPageX               // $nn,X
+	zeroPageY               // $nn,Y
+	absolute                // $nnnn
+	absoluteX               // $nnnn,X
+	absoluteY               // $nnnn,Y
+	indirect                // ($nnnn), JMP Only
+	indirectX               // ($nn,X)
+	indirectY               // ($nn),Y
+	relative                // Signed Branch Offset
+)
+
+// operand Result of Decoding an Addressing Mode
+type operand struct {
+	addr    uint16 // Effective Address; the Branch Target for Relative
+	crossed bool   // Indexing or Branching Crossed into Another Page
+}
+
+// pageCrossReads Instructions that Only Read their Operand. These Take an
+// Extra Cycle When Indexing Crosses a Page; Stores and Read-Modify-Write
+// Instructions Always Spend it, so it's in their Base Count.
+var pageCrossReads = map[string]bool{
+	"ADC": true,
+	"AND": true,
+	"CMP": true,
+	"EOR": true,
+	"LDA": true,
+	"LDX": true,
+	"LDY": true,
+	"ORA": true,
+	"SBC": true,
+}
+
+// decode Resolve the Effective Address for an Addressing Mode, Consuming
+// the Operand Bytes at the Program Counter
+//
+// Immediate Operands are Addressed Where they Sit in the Instruction
+// Stream. Implied and Accumulator Modes have No Address.
+func (s *system) decode(m mode) operand {
+	switch m {
+	case immediate:
+		addr := s.cpu.pc
+		s.cpu.pc++
+		return operand{addr: addr}
+
+	case zeroPage:
+		return operand{addr: uint16(s.next())}
+
+	case zeroPageX: // Wraps Within the Zero Page
+		return operand{addr: uint16(s.next() + s.cpu.xr)}
+
+	case zeroPageY:
+		return operand{addr: uint16(s.next() + s.cpu.yr)}
+
+	case absolute:
+		return operand{addr: s.next16()}
+
+	case absoluteX:
+		return indexed(s.next16(), s.cpu.xr)
+
+	case absoluteY:
+		return indexed(s.next16(), s.cpu.yr)
+
+	case indirect:
+		// The 6502 Never Carries into the High Byte of the Pointer, so
+		// JMP ($xxFF) Fetches its High Byte from $xx00.
+		ptr := s.next16()
+		hi := ptr&0xFF00 | uint16(byte(ptr)+1)
+		return operand{addr: uint16(s.busRead(ptr)) | uint16(s.busRead(hi))<<8}
+
+	case indirectX: // Pointer Wraps Within the Zero Page
+		return operand{addr: s.zeroPage16(s.next() + s.cpu.xr)}
+
+	case indirectY:
+		return indexed(s.zeroPage16(s.next()), s.cpu.yr)
+
+	case relative:
+		offset := int8(s.next())
+		target := s.cpu.pc + uint16(offset)
+		return operand{addr: target, crossed: target&0xFF00 != s.cpu.pc&0xFF00}
+	}
+	return operand{}
+}
+
+// indexed Add an Index Register to a Base Address
+func indexed(base uint16, index byte) operand {
+	addr := base + uint16(index)
+	return operand{addr: addr, crossed: addr&0xFF00 != base&0xFF00}
+}
+
+// zeroPage16 Read a Little Endian Pointer from the Zero Page, Wrapping from
+// $FF to $00
+func (s *system) zeroPage16(p byte) uint16 {
+	lo := s.busRead(uint16(p))
+	return uint16(lo) | uint16(s.busRead(uint16(p+1)))<<8
+}
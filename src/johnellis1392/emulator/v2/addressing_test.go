@@ -0,0 +1,94 @@
+package main
+
+import "testing"
+
+func Test_System_Decode_ShouldResolveEveryMode(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		mode    mode
+		operand []byte // Bytes Following the Opcode
+		x, y    byte
+		mem     map[uint16]byte
+		addr    uint16
+		crossed bool
+	}{
+		{name: "immediate", mode: immediate, operand: []byte{0x42}, addr: pcStart},
+		{name: "zero page", mode: zeroPage, operand: []byte{0x42}, addr: 0x0042},
+		{name: "zero page,X", mode: zeroPageX, operand: []byte{0x42}, x: 1, addr: 0x0043},
+		{name: "zero page,X wraps", mode: zeroPageX, operand: []byte{0xF0}, x: 0x20, addr: 0x0010},
+		{name: "zero page,Y wraps", mode: zeroPageY, operand: []byte{0xFF}, y: 0x01, addr: 0x0000},
+		{name: "absolute", mode: absolute, operand: []byte{0x34, 0x12}, addr: 0x1234},
+		{name: "absolute,X", mode: absoluteX, operand: []byte{0x34, 0x12}, x: 1, addr: 0x1235},
+		{name: "absolute,X crossing", mode: absoluteX, operand: []byte{0xFF, 0x12}, x: 1, addr: 0x1300, crossed: true},
+		{name: "absolute,Y crossing", mode: absoluteY, operand: []byte{0x80, 0x12}, y: 0x80, addr: 0x1300, crossed: true},
+		{name: "absolute,Y wraps", mode: absoluteY, operand: []byte{0xFF, 0xFF}, y: 2, addr: 0x0001, crossed: true},
+		{name: "indirect", mode: indirect, operand: []byte{0x00, 0x02}, mem: map[uint16]byte{0x0200: 0x34, 0x0201: 0x12}, addr: 0x1234},
+		{name: "indirect page bug", mode: indirect, operand: []byte{0xFF, 0x02},
+			mem: map[uint16]byte{0x02FF: 0x34, 0x0300: 0x56, 0x0200: 0x12}, addr: 0x1234},
+		{name: "(indirect,X)", mode: indirectX, operand: []byte{0x20}, x: 4, mem: map[uint16]byte{0x24: 0x34, 0x25: 0x12}, addr: 0x1234},
+		{name: "(indirect,X) wraps", mode: indirectX, operand: []byte{0xFE}, x: 1, mem: map[uint16]byte{0xFF: 0x34, 0x00: 0x12}, addr: 0x1234},
+		{name: "(indirect),Y", mode: indirectY, operand: []byte{0x20}, y: 1, mem: map[uint16]byte{0x20: 0x34, 0x21: 0x12}, addr: 0x1235},
+		{name: "(indirect),Y crossing", mode: indirectY, operand: []byte{0x20}, y: 0x10, mem: map[uint16]byte{0x20: 0xF8, 0x21: 0x12}, addr: 0x1308, crossed: true},
+		{name: "(indirect),Y pointer wraps", mode: indirectY, operand: []byte{0xFF}, mem: map[uint16]byte{0xFF: 0x34, 0x00: 0x12}, addr: 0x1234},
+		{name: "relative forward", mode: relative, operand: []byte{0x10}, addr: pcStart + 0x11},
+		{name: "relative backward crossing", mode: relative, operand: []byte{0x80}, addr: pcStart + 1 - 0x80, crossed: true},
+	} {
+		s := newTestSystem(c.operand)
+		s.cpu.xr, s.cpu.yr = c.x, c.y
+		for addr, v := range c.mem {
+			s.write(addr, v)
+		}
+
+		o := s.decode(c.mode)
+		if o.addr != c.addr || o.crossed != c.crossed {
+			t.Errorf("%s: Expected $%04X (crossed %v), got: $%04X (crossed %v)", c.name, c.addr, c.crossed, o.addr, o.crossed)
+		}
+		if want := pcStart + uint16(len(c.operand)); s.cpu.pc != want {
+			t.Errorf("%s: Expected PC $%04X after decoding, got: $%04X", c.name, want, s.cpu.pc)
+		}
+	}
+}
+
+func Test_System_Exec_ShouldChargePageCrossCycles(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		prog   []byte
+		x, y   byte
+		p      byte
+		mem    map[uint16]byte
+		cycles uint64
+	}{
+		{name: "LDA abs,X same page", prog: []byte{0xBD, 0x00, 0x02}, x: 0xFF, cycles: 4},
+		{name: "LDA abs,X crossing", prog: []byte{0xBD, 0x01, 0x02}, x: 0xFF, cycles: 5},
+		{name: "LDX abs,Y crossing", prog: []byte{0xBE, 0x01, 0x02}, y: 0xFF, cycles: 5},
+		{name: "LDA (zp),Y crossing", prog: []byte{0xB1, 0x10}, y: 0x01, mem: map[uint16]byte{0x10: 0xFF, 0x11: 0x02}, cycles: 6},
+		{name: "STA abs,X crossing", prog: []byte{0x9D, 0x01, 0x02}, x: 0xFF, cycles: 5},
+		{name: "STA (zp),Y crossing", prog: []byte{0x91, 0x10}, y: 0x01, mem: map[uint16]byte{0x10: 0xFF, 0x11: 0x02}, cycles: 6},
+		{name: "INC abs,X crossing", prog: []byte{0xFE, 0x01, 0x02}, x: 0xFF, cycles: 7},
+		{name: "BNE taken crossing", prog: []byte{0xD0, 0x80}, cycles: 4},
+		{name: "BNE not taken", prog: []byte{0xD0, 0x80}, p: flagZ, cycles: 2},
+	} {
+		s := newTestSystem(c.prog)
+		s.cpu.xr, s.cpu.yr = c.x, c.y
+		s.cpu.sr.set(c.p)
+		for addr, v := range c.mem {
+			s.write(addr, v)
+		}
+		s.exec()
+		if s.cpu.clk != c.cycles {
+			t.Errorf("%s: Expected %d cycles, got: %d", c.name, c.cycles, s.cpu.clk)
+		}
+	}
+}
+
+func Test_System_Exec_ShouldJumpThroughBuggyIndirectVector(t *testing.T) {
+	// JMP ($02FF)
+	s := newTestSystem([]byte{0x6C, 0xFF, 0x02})
+	s.write(0x02FF, 0x00)
+	s.write(0x0300, 0x90) // Skipped by the Bug
+	s.write(0x0200, 0xA0)
+	s.exec()
+	if s.cpu.pc != 0xA000 {
+		t.Errorf("Expected PC $A000, got: $%04X", s.cpu.pc)
+	}
+}
@@ -0,0 +1,744 @@
+package main
+
+import "math"
+
+// NES Audio Processing Unit (2A03)
+//
+// CPU Registers:
+// $4000-$4003  Pulse 1    Duty / Envelope, Sweep, Timer Low, Length / Timer High
+// $4004-$4007  Pulse 2    The Same
+// $4008-$400B  Triangle   Linear Counter, Unused, Timer Low, Length / Timer High
+// $400C-$400F  Noise      Envelope, Unused, Mode / Period, Length
+// $4010-$4013  DMC        IRQ / Loop / Rate, Direct Load, Sample Address, Sample Length
+// $4015        Status     Write: Channel Enables; Read: Length Counters and IRQ Flags
+// $4017        Frame Counter Mode and IRQ Inhibit
+//
+// Everything is Clocked from the CPU: the Triangle, Noise and DMC Timers
+// Every Cycle, the Pulse Timers Every Other Cycle, and the Frame Counter's
+// Quarter and Half Frame Steps at Fixed Cycle Counts. Output is Mixed with
+// the Nonlinear Lookup Tables, Averaged Down to the Sample Rate, and Passed
+// Through a 90Hz High-Pass, as on the Console, to Center it on Zero.
+//
+// Not Emulated: the Frame Counter Reset Delay After $4017 Writes, and the
+// CPU Cycles Stolen by DMC Sample Fetches.
+
+const (
+	cpuClockRate      = 1789773 // NTSC
+	defaultSampleRate = 44100
+	highPassHz        = 90
+)
+
+// IRQ Sources the APU Drives
+const (
+	irqFrameCounter byte = 0x02
+	irqDMC          byte = 0x04
+)
+
+// Frame Counter Steps, in CPU Cycles
+const (
+	frameStep1    = 7457
+	frameStep2    = 14913
+	frameStep3    = 22371
+	frameStep4    = 29829
+	frameStep5    = 37281
+	frameLength4  = 29830
+	frameLength5  = 37282
+	frameIRQFirst = 29828 // The IRQ Flag is Set on the Last Three Cycles
+)
+
+var lengthTable = [32]byte{
+	10, 254, 20, 2, 40, 4, 80, 6, 160, 8, 60, 10, 14, 12, 26, 14,
+	12, 16, 24, 18, 48, 20, 96, 22, 192, 24, 72, 26, 16, 28, 32, 30,
+}
+
+var dutyTable = [4][8]byte{
+	{0, 1, 0, 0, 0, 0, 0, 0},
+	{0, 1, 1, 0, 0, 0, 0, 0},
+	{0, 1, 1, 1, 1, 0, 0, 0},
+	{1, 0, 0, 1, 1, 1, 1, 1},
+}
+
+var triangleTable = [32]byte{
+	15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1, 0,
+	0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+}
+
+// noiseTable / dmcTable Timer Periods in CPU Cycles
+var noiseTable = [16]uint16{4, 8, 16, 32, 64, 96, 128, 160, 202, 254, 380, 508, 762, 1016, 2034, 4068}
+var dmcTable = [16]uint16{428, 380, 340, 320, 286, 254, 226, 214, 190, 160, 142, 128, 106, 84, 72, 54}
+
+// pulseMix / tndMix Nonlinear Mixer Lookup Tables
+var pulseMix [31]float64
+var tndMix [203]float64
+
+func init() {
+	for n := 1; n < len(pulseMix); n++ {
+		pulseMix[n] = 95.52 / (8128/float64(n) + 100)
+	}
+	for n := 1; n < len(tndMix); n++ {
+		tndMix[n] = 163.67 / (24329/float64(n) + 100)
+	}
+}
+
+// envelope Volume Shared by the Pulse and Noise Channels
+type envelope struct {
+	start    bool
+	loop     bool // Also Halts the Length Counter
+	constant bool
+	period   byte // Also the Constant Volume
+	divider  byte
+	decay    byte
+}
+
+func (e *envelope) write(v byte) {
+	e.loop, e.constant, e.period = v&0x20 != 0, v&0x10 != 0, v&0x0F
+}
+
+// quarter Clocked Every Quarter Frame
+func (e *envelope) quarter() {
+	switch {
+	case e.start:
+		e.start, e.decay, e.divider = false, 15, e.period
+	case e.divider > 0:
+		e.divider--
+	default:
+		e.divider = e.period
+		if e.decay > 0 {
+			e.decay--
+		} else if e.loop {
+			e.decay = 15
+		}
+	}
+}
+
+func (e *envelope) volume() byte {
+	if e.constant {
+		return e.period
+	}
+	return e.decay
+}
+
+type pulse struct {
+	envelope
+	second bool // Pulse 2 Negates its Sweep in Two's Complement
+
+	enabled bool
+	duty    byte
+	step    byte
+	period  uint16
+	timer   uint16
+	length  byte
+
+	sweepOn      bool
+	sweepPeriod  byte
+	sweepNegate  bool
+	sweepShift   byte
+	sweepDivider byte
+	sweepReload  bool
+}
+
+func (p *pulse) write(reg uint16, v byte) {
+	switch reg {
+	case 0:
+		p.duty = v >> 6
+		p.envelope.write(v)
+	case 1:
+		p.sweepOn, p.sweepPeriod = v&0x80 != 0, v>>4&7
+		p.sweepNegate, p.sweepShift = v&0x08 != 0, v&7
+		p.sweepReload = true
+	case 2:
+		p.period = p.period&0x0700 | uint16(v)
+	case 3:
+		p.period = p.period&0x00FF | uint16(v&7)<<8
+		if p.enabled {
+			p.length = lengthTable[v>>3]
+		}
+		p.step, p.start = 0, true
+	}
+}
+
+// clock Every Other CPU Cycle
+func (p *pulse) clock() {
+	if p.timer > 0 {
+		p.timer--
+		return
+	}
+	p.timer = p.period
+	p.step = (p.step + 1) & 7
+}
+
+// target Period the Sweep Unit is Heading for
+func (p *pulse) target() uint16 {
+	change := p.period >> p.sweepShift
+	if !p.sweepNegate {
+		return p.period + change
+	}
+	if !p.second {
+		change++
+	}
+	if change > p.period {
+		return 0
+	}
+	return p.period - change
+}
+
+func (p *pulse) muted() bool {
+	return p.period < 8 || p.target() > 0x7FF
+}
+
+// half Clocked Every Half Frame
+func (p *pulse) half() {
+	if p.sweepDivider == 0 && p.sweepOn && p.sweepShift > 0 && !p.muted() {
+		p.period = p.target()
+	}
+	if p.sweepDivider == 0 || p.sweepReload {
+		p.sweepDivider, p.sweepReload = p.sweepPeriod, false
+	} else {
+		p.sweepDivider--
+	}
+	if !p.loop && p.length > 0 {
+		p.length--
+	}
+}
+
+func (p *pulse) output() byte {
+	if p.length == 0 || p.muted() || dutyTable[p.duty][p.step] == 0 {
+		return 0
+	}
+	return p.volume()
+}
+
+type triangle struct {
+	enabled bool
+	control bool // Also Halts the Length Counter
+	reload  byte // Linear Counter Reload Value
+	linear  byte
+	relFlag bool
+	step    byte
+	period  uint16
+	timer   uint16
+	length  byte
+}
+
+func (t *triangle) write(reg uint16, v byte) {
+	switch reg {
+	case 0:
+		t.control, t.reload = v&0x80 != 0, v&0x7F
+	case 2:
+		t.period = t.period&0x0700 | uint16(v)
+	case 3:
+		t.period = t.period&0x00FF | uint16(v&7)<<8
+		if t.enabled {
+			t.length = lengthTable[v>>3]
+		}
+		t.relFlag = true
+	}
+}
+
+// clock Every CPU Cycle
+func (t *triangle) clock() {
+	if t.timer > 0 {
+		t.timer--
+		return
+	}
+	t.timer = t.period
+	if t.length > 0 && t.linear > 0 {
+		t.step = (t.step + 1) & 31
+	}
+}
+
+func (t *triangle) quarter() {
+	if t.relFlag {
+		t.linear = t.reload
+	} else if t.linear > 0 {
+		t.linear--
+	}
+	if !t.control {
+		t.relFlag = false
+	}
+}
+
+func (t *triangle) half() {
+	if !t.control && t.length > 0 {
+		t.length--
+	}
+}
+
+// output The Sequencer Holds its Level While Silenced, Like the Hardware
+func (t *triangle) output() byte {
+	return triangleTable[t.step]
+}
+
+type noise struct {
+	envelope
+	enabled bool
+	mode    bool   // Short, Metallic Sequence
+	shift   uint16 // 15-Bit LFSR
+	period  uint16
+	timer   uint16
+	length  byte
+}
+
+func (n *noise) write(reg uint16, v byte) {
+	switch reg {
+	case 0:
+		n.envelope.write(v)
+	case 2:
+		n.mode, n.period = v&0x80 != 0, noiseTable[v&0x0F]
+	case 3:
+		if n.enabled {
+			n.length = lengthTable[v>>3]
+		}
+		n.start = true
+	}
+}
+
+// clock Every CPU Cycle
+func (n *noise) clock() {
+	if n.timer > 0 {
+		n.timer--
+		return
+	}
+	n.timer = n.period - 1
+	tap := uint16(1)
+	if n.mode {
+		tap = 6
+	}
+	feedback := (n.shift ^ n.shift>>tap) & 1
+	n.shift = n.shift>>1 | feedback<<14
+}
+
+func (n *noise) half() {
+	if !n.loop && n.length > 0 {
+		n.length--
+	}
+}
+
+func (n *noise) output() byte {
+	if n.length == 0 || n.shift&1 != 0 {
+		return 0
+	}
+	return n.volume()
+}
+
+// dmc Delta Modulation Channel, Playing 1-Bit Deltas Fetched from Memory
+type dmc struct {
+	irqOn   bool
+	loop    bool
+	period  uint16
+	timer   uint16
+	level   byte
+	start   uint16 // Sample Address
+	size    uint16 // Sample Length
+	addr    uint16
+	left    uint16 // Bytes Still to Fetch
+	buffer  byte
+	full    bool // buffer Holds a Byte
+	shift   byte
+	bits    byte
+	silence bool
+	irq     bool
+}
+
+func (d *dmc) write(reg uint16, v byte) {
+	switch reg {
+	case 0:
+		d.irqOn, d.loop, d.period = v&0x80 != 0, v&0x40 != 0, dmcTable[v&0x0F]
+		if !d.irqOn {
+			d.irq = false
+		}
+	case 1:
+		d.level = v & 0x7F
+	case 2:
+		d.start = 0xC000 | uint16(v)<<6
+	case 3:
+		d.size = uint16(v)<<4 | 1
+	}
+}
+
+func (d *dmc) restart() {
+	d.addr, d.left = d.start, d.size
+}
+
+// clock Every CPU Cycle; read Fetches Sample Bytes
+func (d *dmc) clock(read func(uint16) byte) {
+	if !d.full && d.left > 0 {
+		d.buffer, d.full = read(d.addr), true
+		if d.addr++; d.addr == 0 {
+			d.addr = 0x8000
+		}
+		if d.left--; d.left == 0 {
+			if d.loop {
+				d.restart()
+			} else if d.irqOn {
+				d.irq = true
+			}
+		}
+	}
+
+	if d.timer > 0 {
+		d.timer--
+		return
+	}
+	d.timer = d.period - 1
+	if !d.silence {
+		if d.shift&1 != 0 && d.level <= 125 {
+			d.level += 2
+		} else if d.shift&1 == 0 && d.level >= 2 {
+			d.level -= 2
+		}
+	}
+	d.shift >>= 1
+	if d.bits > 0 {
+		d.bits--
+	}
+	if d.bits == 0 {
+		d.bits = 8
+		d.silence = !d.full
+		if d.full {
+			d.shift, d.full = d.buffer, false
+		}
+	}
+}
+
+type apu struct {
+	read func(addr uint16) byte      // CPU Bus, for DMC Fetches
+	irq  func(mask byte, level bool) // Drives the CPU's IRQ Line
+
+	pulse1, pulse2 pulse
+	triangle       triangle
+	noise          noise
+	dmc            dmc
+
+	frameCycle uint32
+	fiveStep   bool
+	inhibit    bool
+	frameIRQ   bool
+	odd        bool // Pulse Timers Clock on Odd Cycles
+
+	// sampleRate Samples per Second Sent to onSample; 0 Skips Mixing
+	sampleRate int
+	onSample   func(v int16)
+	phase      int     // Sample Clock Accumulator, in Units of 1/cpuClockRate
+	sum        float64 // Mixer Output Since the Last Sample
+	count      int
+	hpIn       float64 // High-Pass Filter's Last Input and Output
+	hpOut      float64
+	hpPrimed   bool
+}
+
+func newAPU(read func(uint16) byte) *apu {
+	a := &apu{read: read, sampleRate: defaultSampleRate}
+	a.pulse2.second = true
+	a.noise.shift = 1
+	a.noise.period = noiseTable[0]
+	a.dmc.period = dmcTable[0]
+	a.dmc.bits = 8
+	a.dmc.silence = true
+	return a
+}
+
+// readRegister / writeRegister CPU Side, addr Relative to $4000
+func (a *apu) readRegister(addr uint16) byte {
+	if addr != 0x15 {
+		return 0
+	}
+	var v byte
+	for i, n := range []byte{a.pulse1.length, a.pulse2.length, a.triangle.length, a.noise.length} {
+		if n > 0 {
+			v |= 1 << uint(i)
+		}
+	}
+	if a.dmc.left > 0 {
+		v |= 0x10
+	}
+	if a.frameIRQ {
+		v |= 0x40
+	}
+	if a.dmc.irq {
+		v |= 0x80
+	}
+	a.frameIRQ = false
+	a.updateIRQ()
+	return v
+}
+
+func (a *apu) writeRegister(addr uint16, v byte) {
+	switch {
+	case addr < 0x04:
+		a.pulse1.write(addr, v)
+	case addr < 0x08:
+		a.pulse2.write(addr-0x04, v)
+	case addr < 0x0C:
+		a.triangle.write(addr-0x08, v)
+	case addr < 0x10:
+		a.noise.write(addr-0x0C, v)
+	case addr < 0x14:
+		a.dmc.write(addr-0x10, v)
+	case addr == 0x15:
+		a.pulse1.enabled = v&0x01 != 0
+		a.pulse2.enabled = v&0x02 != 0
+		a.triangle.enabled = v&0x04 != 0
+		a.noise.enabled = v&0x08 != 0
+		for _, c := range []struct {
+			on     bool
+			length *byte
+		}{
+			{a.pulse1.enabled, &a.pulse1.length},
+			{a.pulse2.enabled, &a.pulse2.length},
+			{a.triangle.enabled, &a.triangle.length},
+			{a.noise.enabled, &a.noise.length},
+		} {
+			if !c.on {
+				*c.length = 0
+			}
+		}
+		if v&0x10 == 0 {
+			a.dmc.left = 0
+		} else if a.dmc.left == 0 {
+			a.dmc.restart()
+		}
+		a.dmc.irq = false
+	case addr == 0x17:
+		a.fiveStep, a.inhibit = v&0x80 != 0, v&0x40 != 0
+		if a.inhibit {
+			a.frameIRQ = false
+		}
+		a.frameCycle = 0
+		if a.fiveStep {
+			a.quarter()
+			a.half()
+		}
+	}
+	a.updateIRQ()
+}
+
+// clock One CPU Cycle
+func (a *apu) clock() {
+	a.frameStep()
+	if a.odd = !a.odd; a.odd {
+		a.pulse1.clock()
+		a.pulse2.clock()
+	}
+	a.triangle.clock()
+	a.noise.clock()
+	a.dmc.clock(a.read)
+	a.updateIRQ()
+
+	if a.sampleRate > 0 && a.onSample != nil {
+		a.sum += a.mix()
+		a.count++
+		if a.phase += a.sampleRate; a.phase >= cpuClockRate {
+			a.phase -= cpuClockRate
+			a.onSample(a.sample(a.sum / float64(a.count)))
+			a.sum, a.count = 0, 0
+		}
+	}
+}
+
+// sample Filter a Mixer Output and Scale it to 16 Bits
+func (a *apu) sample(in float64) int16 {
+	if !a.hpPrimed {
+		a.hpIn, a.hpPrimed = in, true // No Pop from the Initial Offset
+	}
+	rc := 1 / (2 * math.Pi * highPassHz)
+	k := rc / (rc + 1/float64(a.sampleRate))
+	a.hpOut = k * (a.hpOut + in - a.hpIn)
+	a.hpIn = in
+	v := a.hpOut * 32767
+	if v > math.MaxInt16 {
+		v = math.MaxInt16
+	} else if v < math.MinInt16 {
+		v = math.MinInt16
+	}
+	return int16(v)
+}
+
+// frameStep Advance the Frame Counter One CPU Cycle
+func (a *apu) frameStep() {
+	a.frameCycle++
+	switch a.frameCycle {
+	case frameStep1, frameStep3:
+		a.quarter()
+	case frameStep2:
+		a.quarter()
+		a.half()
+	case frameStep4:
+		if !a.fiveStep {
+			a.quarter()
+			a.half()
+		}
+	case frameStep5:
+		a.quarter()
+		a.half()
+	}
+	if !a.fiveStep && !a.inhibit && a.frameCycle >= frameIRQFirst && a.frameCycle <= frameLength4 {
+		a.frameIRQ = true
+	}
+	if a.fiveStep && a.frameCycle == frameLength5 || !a.fiveStep && a.frameCycle == frameLength4 {
+		a.frameCycle = 0
+	}
+}
+
+func (a *apu) quarter() {
+	a.pulse1.envelope.quarter()
+	a.pulse2.envelope.quarter()
+	a.triangle.quarter()
+	a.noise.envelope.quarter()
+}
+
+func (a *apu) half() {
+	a.pulse1.half()
+	a.pulse2.half()
+	a.triangle.half()
+	a.noise.half()
+}
+
+func (a *apu) updateIRQ() {
+	if a.irq != nil {
+		a.irq(irqFrameCounter, a.frameIRQ)
+		a.irq(irqDMC, a.dmc.irq)
+	}
+}
+
+// mix Combined Output in [0, 1]
+func (a *apu) mix() float64 {
+	p := a.pulse1.output() + a.pulse2.output()
+	tnd := 3*int(a.triangle.output()) + 2*int(a.noise.output()) + int(a.dmc.level)
+	return pulseMix[p] + tndMix[tnd]
+}
+
+func (e *envelope) save(w *stateWriter) {
+	for _, v := range []bool{e.start, e.loop, e.constant} {
+		w.bool(v)
+	}
+	for _, v := range []byte{e.period, e.divider, e.decay} {
+		w.u8(v)
+	}
+}
+
+func (e *envelope) load(r *stateReader) {
+	for _, v := range []*bool{&e.start, &e.loop, &e.constant} {
+		*v = r.bool()
+	}
+	for _, v := range []*byte{&e.period, &e.divider, &e.decay} {
+		*v = r.u8()
+	}
+}
+
+func (p *pulse) save(w *stateWriter) {
+	p.envelope.save(w)
+	for _, v := range []bool{p.enabled, p.sweepOn, p.sweepNegate, p.sweepReload} {
+		w.bool(v)
+	}
+	for _, v := range []byte{p.duty, p.step, p.length, p.sweepPeriod, p.sweepShift, p.sweepDivider} {
+		w.u8(v)
+	}
+	w.u16(p.period)
+	w.u16(p.timer)
+}
+
+func (p *pulse) load(r *stateReader) {
+	p.envelope.load(r)
+	for _, v := range []*bool{&p.enabled, &p.sweepOn, &p.sweepNegate, &p.sweepReload} {
+		*v = r.bool()
+	}
+	for _, v := range []*byte{&p.duty, &p.step, &p.length, &p.sweepPeriod, &p.sweepShift, &p.sweepDivider} {
+		*v = r.u8()
+	}
+	p.period = r.u16()
+	p.timer = r.u16()
+}
+
+func (t *triangle) save(w *stateWriter) {
+	for _, v := range []bool{t.enabled, t.control, t.relFlag} {
+		w.bool(v)
+	}
+	for _, v := range []byte{t.reload, t.linear, t.step, t.length} {
+		w.u8(v)
+	}
+	w.u16(t.period)
+	w.u16(t.timer)
+}
+
+func (t *triangle) load(r *stateReader) {
+	for _, v := range []*bool{&t.enabled, &t.control, &t.relFlag} {
+		*v = r.bool()
+	}
+	for _, v := range []*byte{&t.reload, &t.linear, &t.step, &t.length} {
+		*v = r.u8()
+	}
+	t.period = r.u16()
+	t.timer = r.u16()
+}
+
+func (n *noise) save(w *stateWriter) {
+	n.envelope.save(w)
+	w.bool(n.enabled)
+	w.bool(n.mode)
+	w.u8(n.length)
+	for _, v := range []uint16{n.shift, n.period, n.timer} {
+		w.u16(v)
+	}
+}
+
+func (n *noise) load(r *stateReader) {
+	n.envelope.load(r)
+	n.enabled = r.bool()
+	n.mode = r.bool()
+	n.length = r.u8()
+	for _, v := range []*uint16{&n.shift, &n.period, &n.timer} {
+		*v = r.u16()
+	}
+}
+
+func (d *dmc) save(w *stateWriter) {
+	for _, v := range []bool{d.irqOn, d.loop, d.full, d.silence, d.irq} {
+		w.bool(v)
+	}
+	for _, v := range []byte{d.level, d.buffer, d.shift, d.bits} {
+		w.u8(v)
+	}
+	for _, v := range []uint16{d.period, d.timer, d.start, d.size, d.addr, d.left} {
+		w.u16(v)
+	}
+}
+
+func (d *dmc) load(r *stateReader) {
+	for _, v := range []*bool{&d.irqOn, &d.loop, &d.full, &d.silence, &d.irq} {
+		*v = r.bool()
+	}
+	for _, v := range []*byte{&d.level, &d.buffer, &d.shift, &d.bits} {
+		*v = r.u8()
+	}
+	for _, v := range []*uint16{&d.period, &d.timer, &d.start, &d.size, &d.addr, &d.left} {
+		*v = r.u16()
+	}
+}
+
+// save / load The Sample Clock and Filter aren't Saved; Loading Starts
+// Them Afresh
+func (a *apu) save(w *stateWriter) {
+	a.pulse1.save(w)
+	a.pulse2.save(w)
+	a.triangle.save(w)
+	a.noise.save(w)
+	a.dmc.save(w)
+	w.u32(a.frameCycle)
+	for _, v := range []bool{a.fiveStep, a.inhibit, a.frameIRQ, a.odd} {
+		w.bool(v)
+	}
+}
+
+func (a *apu) load(r *stateReader) {
+	a.pulse1.load(r)
+	a.pulse2.load(r)
+	a.triangle.load(r)
+	a.noise.load(r)
+	a.dmc.load(r)
+	a.frameCycle = r.u32()
+	for _, v := range []*bool{&a.fiveStep, &a.inhibit, &a.frameIRQ, &a.odd} {
+		*v = r.bool()
+	}
+	a.sum, a.count, a.hpPrimed = 0, 0, false
+}
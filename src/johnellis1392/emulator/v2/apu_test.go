@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// clockAPU Run a Standalone APU for n CPU Cycles
+func clockAPU(a *apu, n int) {
+	for i := 0; i < n; i++ {
+		a.clock()
+	}
+}
+
+func Test_APU_FrameCounter_ShouldRaiseIRQ(t *testing.T) {
+	var line byte
+	a := newAPU(nil)
+	a.irq = func(mask byte, level bool) {
+		if level {
+			line |= mask
+		} else {
+			line &^= mask
+		}
+	}
+	clockAPU(a, frameIRQFirst-1)
+	if line != 0 {
+		t.Fatalf("Expected no IRQ before cycle %d", frameIRQFirst)
+	}
+	a.clock()
+	if line != irqFrameCounter {
+		t.Fatalf("Expected a frame IRQ at cycle %d", frameIRQFirst)
+	}
+	if v := a.readRegister(0x15); v&0x40 == 0 || line != 0 {
+		t.Errorf("Expected $4015 to report and clear the IRQ, got $%02X line %d", v, line)
+	}
+
+	for _, mode := range []byte{0x40, 0x80} { // Inhibited, Five-Step
+		a.writeRegister(0x17, mode)
+		clockAPU(a, 2*frameLength5)
+		if line != 0 {
+			t.Errorf("Expected no IRQ with $4017=$%02X", mode)
+		}
+	}
+}
+
+func Test_APU_LengthCounter_ShouldCountHalfFrames(t *testing.T) {
+	a := newAPU(nil)
+	a.writeRegister(0x03, 0x08) // Ignored While Disabled
+	if a.readRegister(0x15) != 0 {
+		t.Fatal("Expected a disabled channel to ignore length loads")
+	}
+	a.writeRegister(0x15, 0x01)
+	a.writeRegister(0x03, 0x18) // Length Index 3: 2
+	if a.readRegister(0x15) != 0x01 {
+		t.Fatal("Expected pulse 1's length counter to be running")
+	}
+	clockAPU(a, frameStep2)
+	if a.pulse1.length != 1 {
+		t.Errorf("Expected one half-frame clock, length %d", a.pulse1.length)
+	}
+	clockAPU(a, frameStep4-frameStep2)
+	if a.readRegister(0x15)&0x01 != 0 {
+		t.Error("Expected the length counter to run out")
+	}
+
+	a.writeRegister(0x03, 0x08)
+	a.writeRegister(0x15, 0x00)
+	if a.pulse1.length != 0 {
+		t.Error("Expected disabling to clear the length counter")
+	}
+}
+
+func Test_APU_Pulse_ShouldPlayAtTimerPeriod(t *testing.T) {
+	a := newAPU(nil)
+	a.writeRegister(0x15, 0x01)
+	a.writeRegister(0x00, 0xBF) // 50% Duty, Halted Length, Constant Volume 15
+	a.writeRegister(0x02, 99)
+	a.writeRegister(0x03, 0x08)
+
+	// Count Rising Edges; the Period is 16 * (99 + 1) CPU Cycles
+	const cycles = 16 * 100 * 10
+	edges, last := 0, a.pulse1.output()
+	for i := 0; i < cycles; i++ {
+		a.clock()
+		if out := a.pulse1.output(); out != last {
+			if out == 15 {
+				edges++
+			}
+			last = out
+		}
+	}
+	if edges != 10 {
+		t.Errorf("Expected 10 periods, got %d", edges)
+	}
+
+	a.writeRegister(0x02, 7) // Periods Below 8 are Silent
+	a.writeRegister(0x03, 0x08)
+	if a.pulse1.muted() != true {
+		t.Error("Expected a period of 7 to mute the channel")
+	}
+}
+
+func Test_APU_Pulse_ShouldSweepDifferentlyPerChannel(t *testing.T) {
+	a := newAPU(nil)
+	for _, reg := range []uint16{0x01, 0x05} {
+		a.writeRegister(reg, 0x89) // Enabled, Negate, Shift 1
+		a.writeRegister(reg+1, 100)
+	}
+	if a.pulse1.target() != 49 || a.pulse2.target() != 50 {
+		t.Errorf("Expected targets 49 and 50, got %d and %d", a.pulse1.target(), a.pulse2.target())
+	}
+}
+
+func Test_APU_DMC_ShouldPlaySampleAndRaiseIRQ(t *testing.T) {
+	var reads []uint16
+	a := newAPU(func(addr uint16) byte {
+		reads = append(reads, addr)
+		return 0xFF // Every Bit Steps the Level Up
+	})
+	var irq bool
+	a.irq = func(mask byte, level bool) {
+		if mask == irqDMC {
+			irq = level
+		}
+	}
+	a.writeRegister(0x10, 0x8F) // IRQ, Fastest Rate
+	a.writeRegister(0x11, 0x10)
+	a.writeRegister(0x12, 0x01) // $C040
+	a.writeRegister(0x13, 0x00) // 1 Byte
+	a.writeRegister(0x15, 0x10)
+
+	clockAPU(a, 20*int(dmcTable[0x0F]))
+	if len(reads) != 1 || reads[0] != 0xC040 {
+		t.Errorf("Expected one fetch from $C040, got %v", reads)
+	}
+	if a.dmc.level != 0x10+16 {
+		t.Errorf("Expected 8 steps up from $10, got $%02X", a.dmc.level)
+	}
+	if !irq || a.readRegister(0x15)&0x90 != 0x80 {
+		t.Error("Expected a DMC IRQ with no bytes left")
+	}
+	a.writeRegister(0x15, 0x00)
+	if irq {
+		t.Error("Expected writing $4015 to clear the DMC IRQ")
+	}
+}
+
+func Test_APU_ShouldRecordWAV(t *testing.T) {
+	f, err := ioutil.TempFile("", "apu*.wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	a := newAPU(nil)
+	w, err := recordAudio(a, f, 8000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.writeRegister(0x15, 0x01)
+	a.writeRegister(0x00, 0xBF)
+	a.writeRegister(0x02, 0xFD) // About 440Hz
+	a.writeRegister(0x03, 0x08)
+	const cycles = cpuClockRate / 10
+	clockAPU(a, cycles)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) < wavHeaderSize || string(data[0:4]) != "RIFF" || string(data[8:16]) != "WAVEfmt " {
+		t.Fatalf("Expected a WAV header, got % X", data[:16])
+	}
+	n := binary.LittleEndian.Uint32(data[40:])
+	rate := binary.LittleEndian.Uint32(data[24:])
+	if want := uint32(2 * (cycles * 8000 / cpuClockRate)); rate != 8000 || n != want || len(data) != wavHeaderSize+int(n) {
+		t.Fatalf("Expected %d bytes of samples at 8000Hz, got %d bytes at %dHz in %d", want, n, rate, len(data))
+	}
+	var lo, hi int16
+	for i := wavHeaderSize; i < len(data); i += 2 {
+		v := int16(binary.LittleEndian.Uint16(data[i:]))
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+	if lo > -2000 || hi < 2000 {
+		t.Errorf("Expected a square wave around 0, got %d to %d", lo, hi)
+	}
+}
+
+func Test_APU_ShouldDriveCPUIRQ(t *testing.T) {
+	p := mustAssemble(t, `
+	.org $C000
+reset:	LDA #$00
+	STA $4017
+	CLI
+wait:	JMP wait
+irq:	LDA $4015
+	STA $00
+	RTI
+nmi:	RTI
+	.org $FFFA
+	.word nmi, reset, irq
+`)
+	image, err := p.ines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newNES(loadMapper(t, image))
+	for s.cpu.clk < frameLength4+100 {
+		s.exec()
+	}
+	if v := s.read(0x0000); v&0x40 == 0 || s.cpu.irq != 0 {
+		t.Errorf("Expected the handler to read and clear the frame IRQ, got $%02X line $%02X", v, s.cpu.irq)
+	}
+}
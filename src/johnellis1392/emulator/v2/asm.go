@@ -0,0 +1,483 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 6502 Assembler
+//
+// Source is Assembled in Two Passes. The First Picks Each Instruction's
+// Addressing Mode, and so its Size, and Assigns Addresses to Labels; the
+// Second Evaluates Every Operand with All Labels Known and Emits Code.
+//
+// Syntax, One Statement per Line:
+//	label:             Define label as the Current Address
+//	name = expr        Define a Constant
+//	.org expr          Set the Current Address; Gaps are Filled with $FF
+//	.byte expr, "str"  Emit Bytes
+//	.word expr, ...    Emit Little-Endian Words
+//	LDA ($10),Y        Instruction; Mnemonics and Directives Ignore Case
+//	; comment
+//
+// Expressions: $hex, %binary, decimal, 'c', Symbols and * (the Current
+// Address), with Unary - ~ < (Low Byte) > (High Byte) and, Loosest
+// Binding Last: * / %, + -, << >>, &, ^, |.
+//
+// A Known Operand Below $100 Selects Zero-Page Addressing Where the
+// Instruction Has it, Unless Written as a Hex Literal with Three or More
+// Digits: LDA $0010 is Absolute, Which is What the Disassembler Prints.
+
+// program Assembled Code and the Address it Loads at
+type program struct {
+	origin uint16
+	code   []byte
+}
+
+// asmError Assembly Error at a Source Line
+type asmError struct {
+	line int
+	msg  string
+}
+
+func (e *asmError) Error() string { return fmt.Sprintf("line %d: %s", e.line, e.msg) }
+
+// opcodes Reverse of instructionSet: Mnemonic -> Mode -> Opcode, Documented
+// Opcodes Only
+var opcodes = func() map[string]map[mode]byte {
+	m := make(map[string]map[mode]byte)
+	for op := 0; op < 0x100; op++ {
+		if !documented(byte(op)) {
+			continue
+		}
+		in := instructionSet[byte(op)]
+		if m[in.name] == nil {
+			m[in.name] = make(map[mode]byte)
+		}
+		m[in.name][in.mode] = byte(op)
+	}
+	return m
+}()
+
+// assembler State for One Pass over the Source
+type assembler struct {
+	pass    int
+	symbols map[string]int
+	defined map[string]int // Line Each Symbol was Defined on
+	modes   map[int]mode   // Addressing Mode Chosen per Line in Pass 1
+	origin  int
+	pc      int
+	here    int  // Address at the Start of the Line, for *
+	placed  bool // Whether Anything has Been Emitted, Fixing the Origin
+	code    []byte
+}
+
+// assemble Assemble 6502 Source into a Program
+func assemble(src string) (*program, error) {
+	a := &assembler{
+		symbols: make(map[string]int),
+		defined: make(map[string]int),
+		modes:   make(map[int]mode),
+	}
+	lines := strings.Split(src, "\n")
+	for a.pass = 1; a.pass <= 2; a.pass++ {
+		a.origin, a.pc, a.placed, a.code = loadAddr, loadAddr, false, nil
+		for i, line := range lines {
+			if err := a.line(i+1, line); err != nil {
+				return nil, &asmError{i + 1, err.Error()}
+			}
+		}
+	}
+	return &program{uint16(a.origin), a.code}, nil
+}
+
+// line Assemble One Source Line
+func (a *assembler) line(n int, line string) error {
+	line = strings.TrimSpace(stripComment(line))
+	a.here = a.pc
+
+	// label:
+	if i := strings.IndexByte(line, ':'); i > 0 && isIdent(strings.TrimSpace(line[:i])) {
+		if err := a.define(n, strings.TrimSpace(line[:i]), a.pc); err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line[i+1:])
+	}
+	if line == "" {
+		return nil
+	}
+
+	// name = expr
+	if i := strings.IndexByte(line, '='); i > 0 && isIdent(strings.TrimSpace(line[:i])) {
+		v, err := a.eval(line[i+1:])
+		if err != nil {
+			return err
+		}
+		if !v.known {
+			return nil // Resolved in Pass 2
+		}
+		return a.define(n, strings.TrimSpace(line[:i]), v.n)
+	}
+
+	op, arg := line, ""
+	if i := strings.IndexAny(line, " \t"); i > 0 {
+		op, arg = line[:i], strings.TrimSpace(line[i+1:])
+	}
+	op = strings.ToUpper(op)
+	if op[0] == '.' {
+		return a.directive(op, arg)
+	}
+	return a.instruction(n, op, arg)
+}
+
+// define Bind a Symbol, Rejecting Redefinitions
+func (a *assembler) define(n int, name string, v int) error {
+	switch strings.ToUpper(name) {
+	case "A", "X", "Y":
+		return fmt.Errorf("%s is a register name", name)
+	}
+	if at, ok := a.defined[name]; ok && at != n {
+		return fmt.Errorf("%s already defined on line %d", name, at)
+	}
+	a.defined[name] = n
+	a.symbols[name] = v
+	return nil
+}
+
+func (a *assembler) directive(op, arg string) error {
+	switch op {
+	case ".ORG":
+		v, err := a.evalKnown(arg)
+		if err != nil {
+			return err
+		}
+		switch {
+		case v < 0 || v > 0xFFFF:
+			return fmt.Errorf(".org $%X out of range", v)
+		case !a.placed:
+			a.origin, a.pc = v, v
+		case v < a.pc:
+			return fmt.Errorf(".org $%04X is behind the current address $%04X", v, a.pc)
+		default:
+			for a.pc < v {
+				a.emit(0xFF)
+			}
+		}
+		return nil
+
+	case ".BYTE", ".DB":
+		for _, e := range splitTop(arg) {
+			if s := strings.TrimSpace(e); len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+				if err := a.emit([]byte(s[1 : len(s)-1])...); err != nil {
+					return err
+				}
+				continue
+			}
+			b, err := a.byteArg(e, -0x80)
+			if err != nil {
+				return err
+			}
+			if err := a.emit(b); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case ".WORD", ".DW":
+		for _, e := range splitTop(arg) {
+			w, err := a.wordArg(e)
+			if err != nil {
+				return err
+			}
+			if err := a.emit(byte(w), byte(w>>8)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown directive %s", op)
+}
+
+func (a *assembler) instruction(n int, name, arg string) error {
+	modes, ok := opcodes[name]
+	if !ok {
+		return fmt.Errorf("unknown instruction %s", name)
+	}
+
+	var m mode
+	if a.pass == 1 {
+		var err error
+		if m, err = a.chooseMode(name, modes, arg); err != nil {
+			return err
+		}
+		a.modes[n] = m
+	} else {
+		m = a.modes[n]
+	}
+
+	expr := operandExpr(arg)
+	switch m {
+	case implied, accumulator:
+		return a.emit(modes[m])
+
+	case immediate:
+		b, err := a.byteArg(expr, -0x80)
+		if err != nil {
+			return err
+		}
+		return a.emit(modes[m], b)
+
+	case zeroPage, zeroPageX, zeroPageY, indirectX, indirectY:
+		b, err := a.byteArg(expr, 0)
+		if err != nil {
+			return err
+		}
+		return a.emit(modes[m], b)
+
+	case relative:
+		target, err := a.evalKnown(expr)
+		if err != nil {
+			return err
+		}
+		off := target - (a.pc + 2)
+		if a.pass == 2 && (off < -0x80 || off > 0x7F) {
+			return fmt.Errorf("branch to $%04X out of range", target)
+		}
+		return a.emit(modes[m], byte(off))
+	}
+
+	w, err := a.wordArg(expr)
+	if err != nil {
+		return err
+	}
+	return a.emit(modes[m], byte(w), byte(w>>8))
+}
+
+// chooseMode Work Out an Instruction's Addressing Mode from its Operand
+func (a *assembler) chooseMode(name string, modes map[mode]byte, arg string) (mode, error) {
+	has := func(m mode) bool { _, ok := modes[m]; return ok }
+	pick := func(m mode) (mode, error) {
+		if !has(m) {
+			return 0, fmt.Errorf("%s has no such addressing mode", name)
+		}
+		return m, nil
+	}
+
+	switch {
+	case arg == "" && has(accumulator):
+		return accumulator, nil
+	case arg == "":
+		return pick(implied)
+	case strings.EqualFold(arg, "A"):
+		return pick(accumulator)
+	case arg[0] == '#':
+		return pick(immediate)
+	case has(relative):
+		return relative, nil
+	}
+
+	base, index := arg, ""
+	if parts := splitTop(arg); len(parts) == 2 {
+		base, index = strings.TrimSpace(parts[0]), strings.ToUpper(strings.TrimSpace(parts[1]))
+	}
+	if inner, ok := unwrap(base); ok {
+		if parts := splitTop(inner); len(parts) == 2 && index == "" &&
+			strings.EqualFold(strings.TrimSpace(parts[1]), "X") {
+			return pick(indirectX)
+		}
+		switch index {
+		case "":
+			return pick(indirect)
+		case "Y":
+			return pick(indirectY)
+		}
+		return 0, fmt.Errorf("bad operand %q", arg)
+	}
+
+	zp, abs := zeroPage, absolute
+	switch index {
+	case "":
+	case "X":
+		zp, abs = zeroPageX, absoluteX
+	case "Y":
+		zp, abs = zeroPageY, absoluteY
+	default:
+		return 0, fmt.Errorf("bad index register %q", index)
+	}
+	v, err := a.eval(base)
+	if err != nil {
+		return 0, err
+	}
+	if has(zp) && (!has(abs) || v.known && v.n >= 0 && v.n < 0x100 && !v.wide) {
+		return zp, nil
+	}
+	return pick(abs)
+}
+
+// operandExpr Strip an Operand Down to its Expression: No #, Parentheses
+// or Index Register
+func operandExpr(arg string) string {
+	arg = strings.TrimPrefix(arg, "#")
+	if parts := splitTop(arg); len(parts) == 2 {
+		arg = parts[0]
+	}
+	if inner, ok := unwrap(strings.TrimSpace(arg)); ok {
+		arg = splitTop(inner)[0]
+	}
+	return arg
+}
+
+// emit Append Bytes at the Current Address (in Pass 2) and Advance it
+func (a *assembler) emit(b ...byte) error {
+	if a.pc+len(b) > 0x10000 {
+		return fmt.Errorf("program runs past $FFFF")
+	}
+	if a.pass == 2 {
+		a.code = append(a.code, b...)
+	}
+	a.pc += len(b)
+	a.placed = true
+	return nil
+}
+
+// byteArg Evaluate an 8-Bit Operand No Smaller than lo
+func (a *assembler) byteArg(expr string, lo int) (byte, error) {
+	v, err := a.evalKnown(expr)
+	if err != nil {
+		return 0, err
+	}
+	if a.pass == 2 && (v < lo || v > 0xFF) {
+		return 0, fmt.Errorf("value $%X does not fit in a byte", v)
+	}
+	return byte(v), nil
+}
+
+// wordArg Evaluate a 16-Bit Operand
+func (a *assembler) wordArg(expr string) (uint16, error) {
+	v, err := a.evalKnown(expr)
+	if err != nil {
+		return 0, err
+	}
+	if a.pass == 2 && (v < -0x8000 || v > 0xFFFF) {
+		return 0, fmt.Errorf("value $%X does not fit in a word", v)
+	}
+	return uint16(v), nil
+}
+
+// evalKnown Evaluate expr, Which Must be Known by Pass 2
+func (a *assembler) evalKnown(expr string) (int, error) {
+	v, err := a.eval(expr)
+	if err != nil {
+		return 0, err
+	}
+	if !v.known && a.pass == 2 {
+		return 0, fmt.Errorf("undefined symbol in %q", strings.TrimSpace(expr))
+	}
+	return v.n, nil
+}
+
+// stripComment Drop Everything from a ; Outside Quotes
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ';':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// splitTop Split s on Commas Outside Quotes and Parentheses
+func splitTop(s string) []string {
+	var parts []string
+	var quote byte
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unwrap Strip Parentheses Enclosing the Whole of s
+func unwrap(s string) (string, bool) {
+	if len(s) < 2 || s[0] != '(' || s[len(s)-1] != ')' {
+		return s, false
+	}
+	depth := 0
+	for i := 0; i < len(s)-1; i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		if depth == 0 {
+			return s, false // (a)+(b)
+		}
+	}
+	return s[1 : len(s)-1], true
+}
+
+func isIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isIdentByte(s[i]) || i == 0 && s[i] >= '0' && s[i] <= '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '.' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// ines Wrap the Program in an NROM Cartridge Image: 16KiB of PRG if it Fits
+// in $8000-$BFFF or $C000-$FFFF, Otherwise 32KiB, Plus One Empty CHR Bank
+func (p *program) ines() ([]byte, error) {
+	start, end := int(p.origin), int(p.origin)+len(p.code)
+	base, banks := 0x8000, 2
+	switch {
+	case start < 0x8000:
+		return nil, fmt.Errorf("program at $%04X is below PRG ROM at $8000", start)
+	case start >= 0xC000:
+		base, banks = 0xC000, 1
+	case end <= 0xC000:
+		banks = 1
+	}
+
+	prg := make([]byte, banks*prgBankSize)
+	for i := range prg {
+		prg[i] = 0xFF
+	}
+	copy(prg[start-base:], p.code)
+
+	image := append([]byte{}, inesMagic...)
+	image = append(image, byte(banks), 1)
+	image = append(image, make([]byte, inesHeaderSize-len(image))...)
+	image = append(image, prg...)
+	return append(image, make([]byte, chrBankSize)...), nil
+}
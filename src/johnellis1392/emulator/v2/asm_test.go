@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func mustAssemble(t *testing.T, src string) *program {
+	t.Helper()
+	p, err := assemble(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func Test_Assemble_ShouldMatchDebugProgram(t *testing.T) {
+	p := mustAssemble(t, `
+counter = $0200
+
+	.org $8000
+start:	LDX #$03
+loop:	JSR inc        ; Forward Reference
+	DEX
+	BNE loop
+	BRK
+	.org $8010
+inc:	INC counter
+	RTS
+`)
+	want := []byte{0xA2, 0x03, 0x20, 0x10, 0x80, 0xCA, 0xD0, 0xFA, 0x00}
+	want = append(want, bytes.Repeat([]byte{0xFF}, 7)...)
+	want = append(want, 0xEE, 0x00, 0x02, 0x60)
+	if p.origin != 0x8000 || !bytes.Equal(p.code, want) {
+		t.Errorf("Expected $8000: % X, got: $%04X: % X", want, p.origin, p.code)
+	}
+}
+
+func Test_Assemble_ShouldEncodeEveryAddressingMode(t *testing.T) {
+	cases := []struct {
+		src  string
+		want []byte
+	}{
+		{"NOP", []byte{0xEA}},
+		{"asl", []byte{0x0A}},
+		{"ROL A", []byte{0x2A}},
+		{"LDA #$42", []byte{0xA9, 0x42}},
+		{"LDA #-1", []byte{0xA9, 0xFF}},
+		{"LDA $10", []byte{0xA5, 0x10}},
+		{"LDA $0010", []byte{0xAD, 0x10, 0x00}},
+		{"LDA $10,X", []byte{0xB5, 0x10}},
+		{"LDX $10, y", []byte{0xB6, 0x10}},
+		{"LDA $1234", []byte{0xAD, 0x34, 0x12}},
+		{"LDA $1234,X", []byte{0xBD, 0x34, 0x12}},
+		{"LDA $10,Y", []byte{0xB9, 0x10, 0x00}}, // No Zero Page,Y for LDA
+		{"JMP ($1234)", []byte{0x6C, 0x34, 0x12}},
+		{"LDA ($10,X)", []byte{0xA1, 0x10}},
+		{"LDA ($10),Y", []byte{0xB1, 0x10}},
+		{"BEQ *", []byte{0xF0, 0xFE}},
+		{"JMP $10", []byte{0x4C, 0x10, 0x00}},
+	}
+	for _, c := range cases {
+		p, err := assemble(c.src)
+		if err != nil {
+			t.Errorf("%s: %v", c.src, err)
+			continue
+		}
+		if !bytes.Equal(p.code, c.want) {
+			t.Errorf("%s: expected % X, got: % X", c.src, c.want, p.code)
+		}
+	}
+}
+
+func Test_Assemble_ShouldEvaluateExpressions(t *testing.T) {
+	p := mustAssemble(t, `
+base = $1200
+	.org $C000
+	.byte <base+$34, >(base+$34), 1+2*3, (1+2)*3, %1010 | 1, 'A', 7 % 4, 1 << 4 >> 2, ~0 & $0F
+	.word base, end - *, table
+	.byte "Hi", 0
+table:
+end:
+`)
+	want := []byte{
+		0x34, 0x12, 7, 9, 0x0B, 'A', 3, 4, 0x0F,
+		0x00, 0x12, 0x09, 0x00, 0x12, 0xC0,
+		'H', 'i', 0,
+	}
+	if p.origin != 0xC000 || !bytes.Equal(p.code, want) {
+		t.Errorf("Expected $C000: % X, got: $%04X: % X", want, p.origin, p.code)
+	}
+}
+
+func Test_Assemble_ShouldReportErrorsWithLines(t *testing.T) {
+	cases := []struct {
+		src, want string
+	}{
+		{"FOO", "line 1: unknown instruction FOO"},
+		{"NOP\nLDA missing", "line 2: undefined symbol"},
+		{"xx: NOP\nxx: NOP", "line 2: xx already defined on line 1"},
+		{"x: NOP", "x is a register name"},
+		{"LDA #$100", "does not fit in a byte"},
+		{"STX $1234,Y", "does not fit in a byte"},
+		{"JMP ($10),Y", "no such addressing mode"},
+		{"BNE far\n.org $8100\nfar:", "out of range"},
+		{".org $9000\nNOP\n.org $8000", "behind the current address"},
+		{".fill 3", "unknown directive .FILL"},
+		{".byte 1/0", "division by zero"},
+	}
+	for _, c := range cases {
+		_, err := assemble(c.src)
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%q: expected error containing %q, got: %v", c.src, c.want, err)
+		}
+	}
+}
+
+func Test_Assemble_ShouldRoundTripWithDisassembler(t *testing.T) {
+	// Every Byte Value in Every Position, Including Undocumented Opcodes
+	code := make([]byte, 0, 0x300)
+	for op := 0; op < 0x100; op++ {
+		code = append(code, byte(op), byte(op*7), byte(op*13))
+	}
+	src := disassembleProgram(code, 0x8000)
+	p, err := assemble(src)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, src)
+	}
+	if p.origin != 0x8000 || !bytes.Equal(p.code, code) {
+		t.Errorf("Expected round trip, got: $%04X\n%s", p.origin, src)
+	}
+	if again := disassembleProgram(p.code, p.origin); again != src {
+		t.Errorf("Expected identical disassembly, got:\n%s", again)
+	}
+}
+
+func Test_Disassemble_ShouldEmitBytesForTruncatedInstruction(t *testing.T) {
+	got := disassembleProgram([]byte{0xEA, 0x02, 0xAD, 0x34}, 0x8000)
+	want := "\t.org $8000\n\tNOP\n\t.byte $02\n\t.byte $AD\n\t.byte $34\n"
+	if got != want {
+		t.Errorf("Expected:\n%s, got:\n%s", want, got)
+	}
+}
+
+func Test_Program_INES_ShouldRunUnderNROM(t *testing.T) {
+	p := mustAssemble(t, `
+	.org $C000
+reset:	LDA #$2A
+	STA $00
+	JMP *
+	.org $FFFA
+	.word 0, reset, 0
+`)
+	image, err := p.ines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cart, err := loadINES(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cart.prg) != prgBankSize || cart.mapper != 0 {
+		t.Fatalf("Expected one 16KiB bank on mapper 0, got: %d bytes on mapper %d", len(cart.prg), cart.mapper)
+	}
+	m, err := cart.newMapper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newNES(m)
+	for i := 0; i < 3; i++ {
+		s.exec()
+	}
+	if s.read(0x0000) != 0x2A || s.cpu.pc != 0xC004 {
+		t.Errorf("Expected $2A stored and a loop at $C004, got: $%02X PC=$%04X", s.read(0x0000), s.cpu.pc)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import "fmt"
+
+// Bus Memory-Mapped Address Space Seen by the CPU
+//
+// Anything Attached to an addressSpace is a Bus too, and Receives Addresses
+// Relative to the Start of the Range it's Mapped at.
+type Bus interface {
+	Read(addr uint16) byte
+	Write(addr uint16, v byte)
+}
+
+// ram Read / Write Memory
+type ram []byte
+
+func (r ram) Read(addr uint16) byte     { return r[addr] }
+func (r ram) Write(addr uint16, v byte) { r[addr] = v }
+
+// rom Read-Only Memory; Writes are Ignored
+type rom []byte
+
+func (r rom) Read(addr uint16) byte     { return r[addr] }
+func (r rom) Write(addr uint16, v byte) {}
+
+// mirror Repeat the First size Bytes of a Bus Across a Larger Range
+type mirror struct {
+	Bus
+	size uint16
+}
+
+func (m mirror) Read(addr uint16) byte     { return m.Bus.Read(addr % m.size) }
+func (m mirror) Write(addr uint16, v byte) { m.Bus.Write(addr%m.size, v) }
+
+// device Adapts a Pair of Handlers to the Bus, for Registers and Other
+// Peripherals. Either may be nil.
+type device struct {
+	read  func(addr uint16) byte
+	write func(addr uint16, v byte)
+}
+
+func (d device) Read(addr uint16) byte {
+	if d.read == nil {
+		return 0
+	}
+	return d.read(addr)
+}
+
+func (d device) Write(addr uint16, v byte) {
+	if d.write != nil {
+		d.write(addr, v)
+	}
+}
+
+// region Bus Mapped at [lo, hi]
+type region struct {
+	lo, hi uint16
+	bus    Bus
+}
+
+// addressSpace Flat 64KiB Address Space, Routing Each Address to the Bus
+// Mapped Over it. Unmapped Addresses Read 0 and Ignore Writes.
+type addressSpace struct {
+	regions []region
+	index   [0x10000]uint8 // Region Number of Each Address
+}
+
+func newAddressSpace() *addressSpace {
+	return &addressSpace{regions: []region{{0x0000, 0xFFFF, device{}}}}
+}
+
+// mapRange Attach b at [lo, hi], Over Anything Mapped There Before
+func (a *addressSpace) mapRange(lo, hi uint16, b Bus) {
+	if lo > hi {
+		panic(fmt.Sprintf("bus: empty range $%04X-$%04X", lo, hi))
+	}
+	if len(a.regions) > 0xFF {
+		panic("bus: too many regions")
+	}
+	n := uint8(len(a.regions))
+	a.regions = append(a.regions, region{lo, hi, b})
+	for addr := int(lo); addr <= int(hi); addr++ {
+		a.index[addr] = n
+	}
+}
+
+func (a *addressSpace) Read(addr uint16) byte {
+	r := &a.regions[a.index[addr]]
+	return r.bus.Read(addr - r.lo)
+}
+
+func (a *addressSpace) Write(addr uint16, v byte) {
+	r := &a.regions[a.index[addr]]
+	r.bus.Write(addr-r.lo, v)
+}
@@ -0,0 +1,68 @@
+package main
+
+import "testing"
+
+func Test_AddressSpace_ShouldRouteByRange(t *testing.T) {
+	a := newAddressSpace()
+	low := make(ram, 0x0800)
+	a.mapRange(0x0000, 0x1FFF, mirror{low, 0x0800})
+	a.mapRange(0x8000, 0xFFFF, rom{0x11, 0x22})
+
+	var regs [8]byte
+	a.mapRange(0x2000, 0x3FFF, mirror{device{
+		read:  func(addr uint16) byte { return regs[addr] },
+		write: func(addr uint16, v byte) { regs[addr] = v },
+	}, 8})
+
+	a.Write(0x0001, 0x42)
+	for _, addr := range []uint16{0x0001, 0x0801, 0x1001, 0x1801} {
+		if v := a.Read(addr); v != 0x42 {
+			t.Errorf("Expected mirrored RAM at $%04X to read $42, got: $%02X", addr, v)
+		}
+	}
+
+	a.Write(0x3FFE, 0x99) // Register 6
+	if regs[6] != 0x99 || a.Read(0x2006) != 0x99 {
+		t.Errorf("Expected write to $3FFE to reach device register 6, got: %v", regs)
+	}
+
+	a.Write(0x8000, 0xFF)
+	if a.Read(0x8000) != 0x11 || a.Read(0x8001) != 0x22 {
+		t.Errorf("Expected ROM to ignore writes, got: $%02X $%02X", a.Read(0x8000), a.Read(0x8001))
+	}
+
+	a.Write(0x5000, 0x42)
+	if v := a.Read(0x5000); v != 0 {
+		t.Errorf("Expected unmapped address to read 0, got: $%02X", v)
+	}
+}
+
+func Test_AddressSpace_ShouldOverrideEarlierMappings(t *testing.T) {
+	a := newAddressSpace()
+	a.mapRange(0x0000, 0xFFFF, make(ram, 0x10000))
+	a.mapRange(0x4000, 0x4000, device{read: func(uint16) byte { return 0x7F }})
+
+	a.Write(0x3FFF, 1)
+	a.Write(0x4001, 2)
+	if a.Read(0x3FFF) != 1 || a.Read(0x4000) != 0x7F || a.Read(0x4001) != 2 {
+		t.Errorf("Expected device at $4000 only, got: $%02X $%02X $%02X", a.Read(0x3FFF), a.Read(0x4000), a.Read(0x4001))
+	}
+}
+
+func Test_System_ShouldRunProgramFromROM(t *testing.T) {
+	// LDA #$42; STA $0200; JMP $8000
+	s := newSystem([]byte{0xA9, 0x42, 0x8D, 0x00, 0x02, 0x4C, 0x00, 0x80})
+	for i := 0; i < 3; i++ {
+		s.exec()
+	}
+	if s.read(0x0200) != 0x42 || s.cpu.pc != 0x8000 {
+		t.Errorf("Expected $0200=$42 and PC=$8000, got: $%02X PC=$%04X", s.read(0x0200), s.cpu.pc)
+	}
+	// A 16 KiB Image Repeats Across the 32 KiB ROM Window
+	image := make([]byte, 0x4000)
+	image[0x3FFC] = 0x12
+	s = newSystem(image)
+	if s.read(0xBFFC) != 0x12 || s.read(0xFFFC) != 0x12 {
+		t.Errorf("Expected ROM to be mirrored")
+	}
+}
@@ -0,0 +1,471 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const debuggerHelp = `Commands:
+  s, step [n]          Execute n Instructions (Default 1)
+  n, next              Step Over a JSR
+  c, continue          Run Until a Breakpoint or Watchpoint
+  b, break <addr>      Break When PC Reaches addr
+  bo <op>              Break Before Any Instruction with Opcode op ($20 or JSR)
+  w, watch <addr>      Break After a Write to addr
+  d, delete <addr|op>  Remove a Breakpoint or Watchpoint
+  l, list              List Breakpoints and Watchpoints
+  r, regs              Show Registers and Flags
+  m, mem <addr> [n]    Hex Dump n Bytes (Default 64); I/O Registers Show as FF
+  dis [addr] [n]       Disassemble n Instructions (Default: Around PC)
+  rw, rewind [n]       Step Back n Frames (Default 1)
+  pad <1|2> <buttons>  Hold Buttons (RLDUTSBA, . for None) from the Next Frame Start
+  save <file>          Write a Save State
+  load <file>          Restore a Save State
+  q, quit              Leave the Debugger
+Addresses and Values are Hex, with an Optional $ or 0x Prefix.
+`
+
+// historySize Executed Instructions Kept for the Disassembly Window
+const historySize = 3
+
+// rewindFrames Default Number of Frames the Debugger Can Step Back
+const rewindFrames = 60
+
+// debugger Interactive Monitor Around a System
+type debugger struct {
+	s   *system
+	out io.Writer
+
+	breaks   map[uint16]bool
+	opBreaks map[byte]bool
+	watches  map[uint16]bool
+	hits     []watchHit // Watched Writes Since the Last Check
+
+	history []uint16 // Addresses of Recently Executed Instructions
+	rewind  *rewind
+
+	pads   [2]byte        // Buttons Set with the pad Command
+	frame  uint64         // Frame the Pads Were Last Updated in
+	synced bool           // frame is Valid
+	movie  *movieRecorder // Records the Pads, if Set
+}
+
+// watchHit Write to a Watched Address
+type watchHit struct {
+	addr     uint16
+	old, new byte
+}
+
+// watchBus Records Writes to Watched Addresses on the Way Through
+type watchBus struct {
+	Bus
+	d *debugger
+}
+
+func (w watchBus) Write(addr uint16, v byte) {
+	if w.d.watches[addr] {
+		old := byte(0xFF) // I/O Registers aren't Read; See inspect
+		if !isIO(addr) {
+			old = w.Bus.Read(addr)
+		}
+		w.d.hits = append(w.d.hits, watchHit{addr, old, v})
+	}
+	w.Bus.Write(addr, v)
+}
+
+func (w watchBus) save(sw *stateWriter) { saveAny(sw, w.Bus) }
+func (w watchBus) load(r *stateReader)  { loadAny(r, w.Bus) }
+
+// newDebugger Attach a Debugger to s, Writing its Output to out
+func newDebugger(s *system, out io.Writer) *debugger {
+	d := &debugger{
+		s:        s,
+		out:      out,
+		breaks:   make(map[uint16]bool),
+		opBreaks: make(map[byte]bool),
+		watches:  make(map[uint16]bool),
+		rewind:   newRewind(rewindFrames, frameCycles),
+	}
+	s.bus = watchBus{s.bus, d}
+	return d
+}
+
+// run Read and Execute Commands from in Until quit or EOF
+func (d *debugger) run(in io.Reader) error {
+	sc := bufio.NewScanner(in)
+	d.printf("%s\n", d.regs())
+	for d.printf("> "); sc.Scan(); d.printf("> ") {
+		quit, err := d.command(sc.Text())
+		if err != nil {
+			d.printf("error: %v\n", err)
+		}
+		if quit {
+			return nil
+		}
+	}
+	d.printf("\n")
+	return sc.Err()
+}
+
+func (d *debugger) printf(format string, args ...interface{}) {
+	fmt.Fprintf(d.out, format, args...)
+}
+
+// command Execute One Command Line
+func (d *debugger) command(line string) (quit bool, err error) {
+	args := strings.Fields(line)
+	if len(args) == 0 {
+		return false, nil
+	}
+	cmd, args := args[0], args[1:]
+
+	switch cmd {
+	case "s", "step":
+		n := 1
+		if len(args) > 0 {
+			if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+				return false, fmt.Errorf("bad count %q", args[0])
+			}
+		}
+		for i := 0; i < n; i++ {
+			if d.step() {
+				break
+			}
+		}
+		d.printf("%s\n", d.regs())
+
+	case "n", "next":
+		d.stepOver()
+		d.printf("%s\n", d.regs())
+
+	case "c", "continue":
+		d.cont()
+		d.printf("%s\n", d.regs())
+
+	case "b", "break", "w", "watch":
+		addr, err := d.arg(args, 0)
+		if err != nil {
+			return false, err
+		}
+		if cmd[0] == 'b' {
+			d.breaks[addr] = true
+		} else {
+			d.watches[addr] = true
+		}
+
+	case "bo":
+		if len(args) == 0 {
+			return false, fmt.Errorf("missing opcode")
+		}
+		ops, err := parseOpcodes(args[0])
+		if err != nil {
+			return false, err
+		}
+		for _, op := range ops {
+			d.opBreaks[op] = true
+		}
+
+	case "d", "delete":
+		if len(args) == 0 {
+			return false, fmt.Errorf("missing address or opcode")
+		}
+		if ops, err := parseOpcodes(args[0]); err == nil && d.opBreaks[ops[0]] {
+			for _, op := range ops {
+				delete(d.opBreaks, op)
+			}
+			return false, nil
+		}
+		addr, err := d.arg(args, 0)
+		if err != nil {
+			return false, err
+		}
+		if !d.breaks[addr] && !d.watches[addr] {
+			return false, fmt.Errorf("nothing set at $%04X", addr)
+		}
+		delete(d.breaks, addr)
+		delete(d.watches, addr)
+
+	case "l", "list":
+		d.list()
+
+	case "r", "regs":
+		d.printf("%s\n", d.regs())
+
+	case "m", "mem":
+		addr, err := d.arg(args, 0)
+		if err != nil {
+			return false, err
+		}
+		n, err := d.count(args, 1, 64)
+		if err != nil {
+			return false, err
+		}
+		d.hexDump(addr, n)
+
+	case "dis":
+		if len(args) == 0 {
+			d.window()
+			return false, nil
+		}
+		addr, err := d.arg(args, 0)
+		if err != nil {
+			return false, err
+		}
+		n, err := d.count(args, 1, 10)
+		if err != nil {
+			return false, err
+		}
+		for i := 0; i < n; i++ {
+			addr += uint16(d.disassembleAt(addr, " "))
+		}
+
+	case "rw", "rewind":
+		n, err := d.count(args, 0, 1)
+		if err != nil {
+			return false, err
+		}
+		if n, err = d.rewind.back(d.s, n); err != nil {
+			return false, err
+		}
+		d.history = d.history[:0]
+		d.printf("rewound %d frame(s)\n%s\n", n, d.regs())
+
+	case "pad":
+		if len(args) != 2 || args[0] != "1" && args[0] != "2" {
+			return false, fmt.Errorf("expected: pad <1|2> <buttons>")
+		}
+		if d.s.pads[0] == nil {
+			return false, fmt.Errorf("no controllers")
+		}
+		b, err := parseButtons(args[1])
+		if err != nil {
+			return false, err
+		}
+		d.pads[args[0][0]-'1'] = b
+
+	case "save", "load":
+		if len(args) == 0 {
+			return false, fmt.Errorf("missing file name")
+		}
+		if cmd == "save" {
+			return false, d.s.saveStateFile(args[0])
+		}
+		if err := d.s.loadStateFile(args[0]); err != nil {
+			return false, err
+		}
+		d.history = d.history[:0]
+		d.printf("%s\n", d.regs())
+
+	case "h", "help", "?":
+		d.printf("%s", debuggerHelp)
+
+	case "q", "quit":
+		return true, nil
+
+	default:
+		return false, fmt.Errorf("unknown command %q; try help", cmd)
+	}
+	return false, nil
+}
+
+// step Execute One Instruction, Reporting Whether a Watchpoint Fired
+func (d *debugger) step() bool {
+	d.rewind.record(d.s)
+	d.history = append(d.history, d.s.cpu.pc)
+	if len(d.history) > historySize {
+		d.history = d.history[1:]
+	}
+	d.hits = d.hits[:0]
+	d.syncPads()
+	d.s.exec()
+	for _, h := range d.hits {
+		d.printf("watch $%04X: $%02X -> $%02X\n", h.addr, h.old, h.new)
+	}
+	return len(d.hits) > 0
+}
+
+// syncPads At the Start of Each Frame, Hand the Pads the Buttons from the
+// pad Command, and Record them. Movies Change Input Only Between Frames, so
+// Holding Changes Until Then Lets Playback Match.
+func (d *debugger) syncPads() {
+	if d.s.ppu == nil || d.s.pads[0] == nil || d.synced && d.s.ppu.frame == d.frame {
+		return
+	}
+	d.frame, d.synced = d.s.ppu.frame, true
+	d.s.setPads(d.pads)
+	if d.movie != nil {
+		d.movie.record(d.s)
+	}
+}
+
+// cont Run Until a Breakpoint or Watchpoint
+func (d *debugger) cont() {
+	for !d.step() && !d.s.stopped() && !d.atBreak() {
+	}
+}
+
+// stepOver Step, Running a Called Subroutine to its Return
+func (d *debugger) stepOver() {
+	if instructionSet[d.s.inspect(d.s.cpu.pc)].name != "JSR" {
+		d.step()
+		return
+	}
+	ret, sp := d.s.cpu.pc+3, d.s.cpu.sp
+	for !d.step() && !d.s.stopped() && !d.atBreak() {
+		if d.s.cpu.pc == ret && d.s.cpu.sp == sp {
+			return
+		}
+	}
+}
+
+// atBreak Report, and Announce, a Breakpoint at the Program Counter
+func (d *debugger) atBreak() bool {
+	pc := d.s.cpu.pc
+	if d.breaks[pc] {
+		d.printf("break at $%04X\n", pc)
+		return true
+	}
+	if op := d.s.inspect(pc); d.opBreaks[op] {
+		d.printf("break on %s at $%04X\n", instructionSet[op].name, pc)
+		return true
+	}
+	return false
+}
+
+// regs Register and Flag Dump; Set Flags are Upper Case
+func (d *debugger) regs() string {
+	c := &d.s.cpu
+	return fmt.Sprintf("PC=$%04X A=$%02X X=$%02X Y=$%02X SP=$%02X P=$%02X [%s] CYC=%d",
+		c.pc, c.ac, c.xr, c.yr, c.sp, c.sr.pack(), flagString(c.sr.pack()), c.clk)
+}
+
+// flagString Render a Status Byte as NV-BDIZC, Upper Case When Set
+func flagString(p byte) string {
+	const names = "nv-bdizc"
+	var b strings.Builder
+	for i := 0; i < 8; i++ {
+		c := names[i]
+		if p&(0x80>>i) != 0 && c != '-' {
+			c -= 'a' - 'A'
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func (d *debugger) list() {
+	var lines []string
+	for addr := range d.breaks {
+		lines = append(lines, fmt.Sprintf("break $%04X", addr))
+	}
+	for op := range d.opBreaks {
+		lines = append(lines, fmt.Sprintf("bo    $%02X (%s)", op, instructionSet[op].name))
+	}
+	for addr := range d.watches {
+		lines = append(lines, fmt.Sprintf("watch $%04X", addr))
+	}
+	sort.Strings(lines)
+	for _, l := range lines {
+		d.printf("%s\n", l)
+	}
+}
+
+func (d *debugger) hexDump(addr uint16, n int) {
+	for i := 0; i < n; i += 16 {
+		row := make([]byte, min(16, n-i))
+		for j := range row {
+			row[j] = d.s.inspect(addr + uint16(i+j))
+		}
+		text := []byte(strings.Map(func(r rune) rune {
+			if r < 0x20 || r > 0x7E {
+				return '.'
+			}
+			return r
+		}, string(row)))
+		d.printf("%04X: % -47X  %s\n", addr+uint16(i), row, text)
+	}
+}
+
+// window Disassemble the Recently Executed Instructions, the One at PC and
+// a Few After it
+func (d *debugger) window() {
+	for _, addr := range d.history {
+		d.disassembleAt(addr, " ")
+	}
+	addr := d.s.cpu.pc
+	addr += uint16(d.disassembleAt(addr, ">"))
+	for i := 0; i < 5; i++ {
+		addr += uint16(d.disassembleAt(addr, " "))
+	}
+}
+
+// disassembleAt Print the Instruction at addr with its Bytes
+func (d *debugger) disassembleAt(addr uint16, mark string) int {
+	text, size := disassemble(d.s.inspect, addr)
+	raw := make([]string, size)
+	for i := range raw {
+		raw[i] = fmt.Sprintf("%02X", d.s.inspect(addr+uint16(i)))
+	}
+	if d.breaks[addr] {
+		mark += "*"
+	} else {
+		mark += " "
+	}
+	d.printf("%s$%04X  %-8s  %s\n", mark, addr, strings.Join(raw, " "), text)
+	return size
+}
+
+// arg Parse args[i] as a Hex Address
+func (d *debugger) arg(args []string, i int) (uint16, error) {
+	if i >= len(args) {
+		return 0, fmt.Errorf("missing address")
+	}
+	v, err := parseHex(args[i], 16)
+	return uint16(v), err
+}
+
+// count Parse args[i] as a Decimal Count, or Return def
+func (d *debugger) count(args []string, i, def int) (int, error) {
+	if i >= len(args) {
+		return def, nil
+	}
+	n, err := strconv.Atoi(args[i])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("bad count %q", args[i])
+	}
+	return n, nil
+}
+
+// parseHex Parse a Hex Number with an Optional $ or 0x Prefix
+func parseHex(s string, bits int) (uint64, error) {
+	t := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(s), "$"), "0x")
+	v, err := strconv.ParseUint(t, 16, bits)
+	if err != nil {
+		return 0, fmt.Errorf("bad hex value %q", s)
+	}
+	return v, nil
+}
+
+// parseOpcodes Parse an Opcode Given in Hex, or a Mnemonic Standing for
+// Every Opcode with that Name
+func parseOpcodes(s string) ([]byte, error) {
+	var ops []byte
+	name := strings.ToUpper(s)
+	for op := 0; op < 0x100; op++ {
+		if instructionSet[byte(op)].name == name {
+			ops = append(ops, byte(op))
+		}
+	}
+	if len(ops) > 0 {
+		return ops, nil
+	}
+	v, err := parseHex(s, 8)
+	if err != nil {
+		return nil, err
+	}
+	return []byte{byte(v)}, nil
+}
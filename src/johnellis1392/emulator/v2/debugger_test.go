@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// debugProgram
+//
+//	8000  LDX #$03
+//	8002  JSR $8010
+//	8005  DEX
+//	8006  BNE $8002
+//	8008  BRK
+//	8010  INC $0200
+//	8013  RTS
+func debugProgram() *system {
+	prog := make([]byte, 0x20)
+	copy(prog, []byte{0xA2, 0x03, 0x20, 0x10, 0x80, 0xCA, 0xD0, 0xFA, 0x00})
+	copy(prog[0x10:], []byte{0xEE, 0x00, 0x02, 0x60})
+	return newTestSystem(prog)
+}
+
+func runDebugger(t *testing.T, s *system, script ...string) string {
+	var out bytes.Buffer
+	if err := newDebugger(s, &out).run(strings.NewReader(strings.Join(script, "\n"))); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func Test_Debugger_ShouldStopAtBreakpoint(t *testing.T) {
+	s := debugProgram()
+	out := runDebugger(t, s, "b 8005", "c", "c")
+	if s.cpu.pc != 0x8005 || s.cpu.xr != 2 {
+		t.Errorf("Expected second stop at $8005 with X=2, got: PC=$%04X X=%d", s.cpu.pc, s.cpu.xr)
+	}
+	if strings.Count(out, "break at $8005") != 2 {
+		t.Errorf("Expected two breaks, got:\n%s", out)
+	}
+}
+
+func Test_Debugger_ShouldStopOnOpcode(t *testing.T) {
+	s := debugProgram()
+	out := runDebugger(t, s, "bo rts", "c")
+	if s.cpu.pc != 0x8013 || !strings.Contains(out, "break on RTS at $8013") {
+		t.Errorf("Expected break on RTS, got:\n%s", out)
+	}
+}
+
+func Test_Debugger_ShouldStopAfterWatchedWrite(t *testing.T) {
+	s := debugProgram()
+	out := runDebugger(t, s, "w $0200", "c", "c")
+	if s.read(0x0200) != 2 || s.cpu.pc != 0x8013 {
+		t.Errorf("Expected stop after second write, got: $0200=%d PC=$%04X", s.read(0x0200), s.cpu.pc)
+	}
+	if !strings.Contains(out, "watch $0200: $01 -> $02") {
+		t.Errorf("Expected watch report, got:\n%s", out)
+	}
+}
+
+func Test_Debugger_ShouldStepOverSubroutine(t *testing.T) {
+	s := debugProgram()
+	runDebugger(t, s, "s", "n")
+	if s.cpu.pc != 0x8005 || s.read(0x0200) != 1 {
+		t.Errorf("Expected to step over JSR to $8005, got: PC=$%04X", s.cpu.pc)
+	}
+	runDebugger(t, s, "n", "n")
+	if s.cpu.pc != 0x8002 {
+		t.Errorf("Expected next to step plain instructions, got: PC=$%04X", s.cpu.pc)
+	}
+}
+
+func Test_Debugger_ShouldDumpRegistersMemoryAndDisassembly(t *testing.T) {
+	s := debugProgram()
+	s.write(0x0300, 'H')
+	s.write(0x0301, 'i')
+	out := runDebugger(t, s, "s 2", "r", "m 0300 16", "dis", "dis 8010 2")
+	for _, want := range []string{
+		"PC=$8010 A=$00 X=$03 Y=$00 SP=$FB P=$24 [nv-bdIzc] CYC=8",
+		"0300: 48 69 00 00 00 00 00 00 00 00 00 00 00 00 00 00  Hi..............",
+		"  $8000  A2 03     LDX #$03",
+		"  $8002  20 10 80  JSR $8010",
+		"> $8010  EE 00 02  INC $0200",
+		"  $8013  60        RTS",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func Test_Debugger_ShouldReportBadCommands(t *testing.T) {
+	out := runDebugger(t, debugProgram(), "frob", "b", "m zz", "d 1234", "q", "s")
+	if strings.Count(out, "error:") != 4 {
+		t.Errorf("Expected four errors, got:\n%s", out)
+	}
+	if strings.Contains(out, "CYC=2") {
+		t.Errorf("Expected quit to stop reading commands")
+	}
+}
+
+func Test_FlagString_ShouldUpperCaseSetFlags(t *testing.T) {
+	if s := flagString(flagS | flagU | flagC); s != "Nv-bdizC" {
+		t.Errorf("Expected Nv-bdizC, got: %s", s)
+	}
+}
+
+func Test_Debugger_ShouldNotReadIORegisters(t *testing.T) {
+	s := newTestSystem([]byte{0xA9, 0x05, 0x8D, 0x16, 0x40}) // LDA #$05, STA $4016
+	reads := 0
+	bus := newAddressSpace()
+	bus.mapRange(0x0000, 0xFFFF, s.bus)
+	bus.mapRange(ioStart, ioEnd, device{read: func(uint16) byte { reads++; return 0x80 }})
+	s.bus = bus
+
+	out := runDebugger(t, s, "m 2000 8", "m 4010 16", "dis 2000 2", "w 4016", "c", "m 4016 2")
+	if reads != 0 {
+		t.Errorf("Expected no I/O reads, got %d:\n%s", reads, out)
+	}
+	if !strings.Contains(out, "2000: FF FF FF FF FF FF FF FF") || !strings.Contains(out, "watch $4016: $FF -> $05") {
+		t.Errorf("Expected I/O registers to show as FF, got:\n%s", out)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// operandSize Number of Operand Bytes Following the Opcode
+func (m mode) operandSize() int {
+	switch m {
+	case implied, accumulator:
+		return 0
+	case absolute, absoluteX, absoluteY, indirect:
+		return 2
+	}
+	return 1
+}
+
+// disassemble Render the Instruction at addr, Returning it and its Length
+// in Bytes. Branch Targets are Resolved to Absolute Addresses, and
+// Undocumented Opcodes Become .byte Directives.
+//
+// The Output is Valid Input for assemble: Absolute Operands Always Have
+// Four Digits, so they Reassemble to the Same Mode Even Below $0100.
+func disassemble(read func(addr uint16) byte, addr uint16) (string, int) {
+	op := read(addr)
+	if !documented(op) {
+		return fmt.Sprintf(".byte $%02X", op), 1
+	}
+	in := instructionSet[op]
+	lo, hi := read(addr+1), read(addr+2)
+	word := uint16(lo) | uint16(hi)<<8
+
+	var arg string
+	switch in.mode {
+	case accumulator:
+		arg = "A"
+	case immediate:
+		arg = fmt.Sprintf("#$%02X", lo)
+	case zeroPage:
+		arg = fmt.Sprintf("$%02X", lo)
+	case zeroPageX:
+		arg = fmt.Sprintf("$%02X,X", lo)
+	case zeroPageY:
+		arg = fmt.Sprintf("$%02X,Y", lo)
+	case absolute:
+		arg = fmt.Sprintf("$%04X", word)
+	case absoluteX:
+		arg = fmt.Sprintf("$%04X,X", word)
+	case absoluteY:
+		arg = fmt.Sprintf("$%04X,Y", word)
+	case indirect:
+		arg = fmt.Sprintf("($%04X)", word)
+	case indirectX:
+		arg = fmt.Sprintf("($%02X,X)", lo)
+	case indirectY:
+		arg = fmt.Sprintf("($%02X),Y", lo)
+	case relative:
+		arg = fmt.Sprintf("$%04X", addr+2+uint16(int8(lo)))
+	}
+
+	size := 1 + in.mode.operandSize()
+	if arg == "" {
+		return in.name, size
+	}
+	return in.name + " " + arg, size
+}
+
+// disassembleProgram Render code Loaded at origin as Assembler Source. An
+// Instruction Cut Off by the End of code is Written as .byte Directives.
+func disassembleProgram(code []byte, origin uint16) string {
+	read := func(addr uint16) byte {
+		if i := int(addr - origin); i < len(code) {
+			return code[i]
+		}
+		return 0
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\t.org $%04X\n", origin)
+	for i := 0; i < len(code); {
+		text, size := disassemble(read, origin+uint16(i))
+		if i+size > len(code) {
+			text, size = fmt.Sprintf(".byte $%02X", code[i]), 1
+		}
+		fmt.Fprintf(&b, "\t%s\n", text)
+		i += size
+	}
+	return b.String()
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// value Result of an Assembler Expression
+type value struct {
+	n     int
+	known bool // False While it Refers to a Symbol Not Yet Defined
+	wide  bool // Written with a 16-Bit Hex Literal, so Never Zero Page
+}
+
+// binaryOps Binary Operators by Precedence, Loosest First
+var binaryOps = [][]string{
+	{"|"},
+	{"^"},
+	{"&"},
+	{"<<", ">>"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+// exprParser Recursive Descent Evaluator over One Expression
+type exprParser struct {
+	a   *assembler
+	s   string
+	pos int
+}
+
+// eval Evaluate an Expression at the Current Address
+func (a *assembler) eval(expr string) (value, error) {
+	p := &exprParser{a: a, s: expr}
+	v, err := p.binary(0)
+	if err != nil {
+		return value{}, err
+	}
+	if p.skipSpace(); p.pos < len(p.s) {
+		return value{}, fmt.Errorf("unexpected %q in expression", p.s[p.pos:])
+	}
+	return v, nil
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *exprParser) binary(level int) (value, error) {
+	if level == len(binaryOps) {
+		return p.unary()
+	}
+	l, err := p.binary(level + 1)
+	if err != nil {
+		return l, err
+	}
+	for {
+		p.skipSpace()
+		op := ""
+		for _, o := range binaryOps[level] {
+			if strings.HasPrefix(p.s[p.pos:], o) {
+				op = o
+			}
+		}
+		if op == "" {
+			return l, nil
+		}
+		p.pos += len(op)
+		r, err := p.binary(level + 1)
+		if err != nil {
+			return r, err
+		}
+		if l, err = apply(op, l, r); err != nil {
+			return l, err
+		}
+	}
+}
+
+// apply Combine Two Values; Unknown Operands Give an Unknown Result
+func apply(op string, l, r value) (value, error) {
+	v := value{known: l.known && r.known, wide: l.wide || r.wide}
+	if !v.known {
+		return v, nil
+	}
+	switch op {
+	case "|":
+		v.n = l.n | r.n
+	case "^":
+		v.n = l.n ^ r.n
+	case "&":
+		v.n = l.n & r.n
+	case "<<":
+		v.n = l.n << uint(r.n)
+	case ">>":
+		v.n = l.n >> uint(r.n)
+	case "+":
+		v.n = l.n + r.n
+	case "-":
+		v.n = l.n - r.n
+	case "*":
+		v.n = l.n * r.n
+	case "/", "%":
+		if r.n == 0 {
+			return v, fmt.Errorf("division by zero")
+		}
+		if op == "/" {
+			v.n = l.n / r.n
+		} else {
+			v.n = l.n % r.n
+		}
+	}
+	return v, nil
+}
+
+func (p *exprParser) unary() (value, error) {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return value{}, fmt.Errorf("missing operand")
+	}
+	switch c := p.s[p.pos]; c {
+	case '-', '~', '<', '>':
+		p.pos++
+		v, err := p.unary()
+		switch c {
+		case '-':
+			v.n = -v.n
+		case '~':
+			v.n = ^v.n
+		case '<':
+			v.n, v.wide = v.n&0xFF, false
+		case '>':
+			v.n, v.wide = v.n>>8&0xFF, false
+		}
+		return v, err
+	}
+	return p.primary()
+}
+
+func (p *exprParser) primary() (value, error) {
+	start := p.pos
+	switch c := p.s[p.pos]; {
+	case c == '(':
+		p.pos++
+		v, err := p.binary(0)
+		if err != nil {
+			return v, err
+		}
+		if p.skipSpace(); p.pos >= len(p.s) || p.s[p.pos] != ')' {
+			return v, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return v, nil
+
+	case c == '*':
+		p.pos++
+		return value{n: p.a.here, known: true}, nil
+
+	case c == '\'':
+		if p.pos+2 >= len(p.s) || p.s[p.pos+2] != '\'' {
+			return value{}, fmt.Errorf("bad character literal")
+		}
+		p.pos += 3
+		return value{n: int(p.s[start+1]), known: true}, nil
+
+	case c == '$' || c == '%':
+		p.pos++
+		base := 16
+		if c == '%' {
+			base = 2
+		}
+		digits := p.word()
+		n, err := strconv.ParseInt(digits, base, 32)
+		if err != nil {
+			return value{}, fmt.Errorf("bad number %q", p.s[start:p.pos])
+		}
+		return value{n: int(n), known: true, wide: c == '$' && len(digits) > 2}, nil
+
+	case c >= '0' && c <= '9':
+		n, err := strconv.ParseInt(p.word(), 10, 32)
+		if err != nil {
+			return value{}, fmt.Errorf("bad number %q", p.s[start:p.pos])
+		}
+		return value{n: int(n), known: true}, nil
+
+	case isIdentByte(c):
+		name := p.word()
+		n, ok := p.a.symbols[name]
+		return value{n: n, known: ok}, nil
+	}
+	return value{}, fmt.Errorf("unexpected %q in expression", p.s[p.pos:])
+}
+
+// word Consume a Run of Identifier Characters
+func (p *exprParser) word() string {
+	start := p.pos
+	for p.pos < len(p.s) && isIdentByte(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// iNES / NES 2.0 Cartridge Images
+//
+//	[header 16][trainer 512, if flagged][PRG ROM][CHR ROM][misc ROM...]
+//
+// Header:
+// 0-3  "NES\x1A"
+// 4    PRG ROM Size, 16KiB Units (NES 2.0: LSB)
+// 5    CHR ROM Size, 8KiB Units (NES 2.0: LSB); 0 Means the Board has CHR RAM
+// 6    Flags 6: Mirroring, Battery, Trainer, Four-Screen, Mapper Bits 0-3
+// 7    Flags 7: NES 2.0 Identifier (Bits 2-3 == 2), Mapper Bits 4-7
+// 8    iNES: PRG RAM Size, 8KiB Units; NES 2.0: Mapper Bits 8-11, Submapper
+// 9    NES 2.0: PRG / CHR ROM Size MSB Nybbles
+// 10   NES 2.0: PRG RAM / NVRAM Shift Counts
+// 11   NES 2.0: CHR RAM / NVRAM Shift Counts
+// 12-15 Unused Padding (Older Tools Wrote Junk Here, e.g. "DiskDude!")
+
+const (
+	inesHeaderSize  = 16
+	inesTrainerSize = 512
+	prgBankSize     = 0x4000
+	chrBankSize     = 0x2000
+)
+
+var inesMagic = []byte("NES\x1A")
+
+var (
+	errNotINES   = errors.New("ines: missing NES<EOF> header")
+	errTruncated = errors.New("ines: image shorter than its header declares")
+)
+
+// mirroring Nametable Arrangement
+type mirroring byte
+
+const (
+	horizontal  mirroring = iota // $2000 = $2400, $2800 = $2C00 (Vertical Scrolling)
+	vertical                     // $2000 = $2800, $2400 = $2C00 (Horizontal Scrolling)
+	singleLower                  // All Four Use the First Nametable
+	singleUpper                  // All Four Use the Second Nametable
+	fourScreen                   // Cartridge Supplies Extra VRAM
+)
+
+// cartridge Parsed Cartridge Image
+type cartridge struct {
+	mapper    uint16 // Mapper Number; Up to 12 Bits in NES 2.0
+	submapper byte
+	nes2      bool
+
+	prg     []byte
+	chr     []byte // Empty When the Board has CHR RAM
+	trainer []byte // Loaded at $7000 if Present
+
+	mirroring mirroring
+	battery   bool
+	prgRAM    int // Bytes of PRG RAM, Including Battery-Backed
+	chrRAM    int // Bytes of CHR RAM, Including Battery-Backed
+}
+
+// loadINES Parse an iNES or NES 2.0 Image
+func loadINES(data []byte) (*cartridge, error) {
+	if len(data) < inesHeaderSize || !bytes.Equal(data[:4], inesMagic) {
+		return nil, errNotINES
+	}
+	h := data[:inesHeaderSize]
+	c := &cartridge{
+		battery: h[6]&0x02 != 0,
+		nes2:    h[7]&0x0C == 0x08,
+	}
+	switch {
+	case h[6]&0x08 != 0:
+		c.mirroring = fourScreen
+	case h[6]&0x01 != 0:
+		c.mirroring = vertical
+	default:
+		c.mirroring = horizontal
+	}
+
+	prgSize, chrSize := int(h[4])*prgBankSize, int(h[5])*chrBankSize
+	c.mapper = uint16(h[6] >> 4)
+	switch {
+	case c.nes2:
+		c.mapper |= uint16(h[7]&0xF0) | uint16(h[8]&0x0F)<<8
+		c.submapper = h[8] >> 4
+		prgSize = nes2Size(h[4], h[9]&0x0F, prgBankSize)
+		chrSize = nes2Size(h[5], h[9]>>4, chrBankSize)
+		c.prgRAM = shiftSize(h[10]&0x0F) + shiftSize(h[10]>>4)
+		c.chrRAM = shiftSize(h[11]&0x0F) + shiftSize(h[11]>>4)
+	case bytes.Equal(h[12:16], []byte{0, 0, 0, 0}):
+		c.mapper |= uint16(h[7] & 0xF0)
+		c.prgRAM = int(h[8]) * 0x2000
+		fallthrough
+	default:
+		// Archaic Images with Junk in Bytes 7-15 Only Use the Low Nybble
+		if c.prgRAM == 0 {
+			c.prgRAM = 0x2000
+		}
+		if chrSize == 0 {
+			c.chrRAM = chrBankSize
+		}
+	}
+
+	data = data[inesHeaderSize:]
+	if h[6]&0x04 != 0 {
+		if len(data) < inesTrainerSize {
+			return nil, errTruncated
+		}
+		c.trainer, data = data[:inesTrainerSize], data[inesTrainerSize:]
+	}
+	if prgSize < 0 || chrSize < 0 || len(data) < prgSize+chrSize {
+		return nil, errTruncated
+	}
+	c.prg, c.chr = data[:prgSize], data[prgSize:prgSize+chrSize]
+	if len(c.prg) == 0 {
+		return nil, fmt.Errorf("ines: no PRG ROM")
+	}
+	return c, nil
+}
+
+// nes2Size Decode an NES 2.0 ROM Size from its LSB and MSB Nybble. An MSB
+// of $F Switches to Exponent-Multiplier Notation: 2^E * (2M + 1).
+func nes2Size(lsb, msb byte, unit int) int {
+	if msb == 0x0F {
+		e, m := lsb>>2, int(lsb&0x03)
+		if e > 30 {
+			return -1
+		}
+		return (1 << e) * (2*m + 1)
+	}
+	return (int(msb)<<8 | int(lsb)) * unit
+}
+
+// shiftSize Decode an NES 2.0 RAM Shift Count; 0 Means None
+func shiftSize(n byte) int {
+	if n == 0 {
+		return 0
+	}
+	return 64 << n
+}
+
+// newMapper Build the Mapper for c's Board
+func (c *cartridge) newMapper() (Mapper, error) {
+	switch c.mapper {
+	case 0:
+		return newNROM(c), nil
+	case 1:
+		return newMMC1(c), nil
+	case 2:
+		return newUxROM(c), nil
+	case 3:
+		return newCNROM(c), nil
+	}
+	return nil, fmt.Errorf("ines: unsupported mapper %d", c.mapper)
+}
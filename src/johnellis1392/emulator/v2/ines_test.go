@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// inesImage Build a Synthetic iNES Image. Each 16KiB PRG Bank is Filled
+// with its Bank Number, and Each 8KiB CHR Bank with $80 Plus its Number.
+func inesImage(mapper byte, prgBanks, chrBanks int, flags6 byte) []byte {
+	h := []byte{'N', 'E', 'S', 0x1A, byte(prgBanks), byte(chrBanks), mapper<<4 | flags6&0x0F, mapper & 0xF0, 0, 0, 0, 0, 0, 0, 0, 0}
+	var b bytes.Buffer
+	b.Write(h)
+	if flags6&0x04 != 0 {
+		b.Write(bytes.Repeat([]byte{0x7E}, inesTrainerSize))
+	}
+	for i := 0; i < prgBanks; i++ {
+		b.Write(bytes.Repeat([]byte{byte(i)}, prgBankSize))
+	}
+	for i := 0; i < chrBanks; i++ {
+		b.Write(bytes.Repeat([]byte{0x80 | byte(i)}, chrBankSize))
+	}
+	return b.Bytes()
+}
+
+func Test_LoadINES_ShouldParseHeader(t *testing.T) {
+	c, err := loadINES(inesImage(0x12, 4, 2, 0x01|0x02|0x04))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.mapper != 0x12 || c.nes2 || c.mirroring != vertical || !c.battery {
+		t.Errorf("Expected mapper 18, iNES, vertical, battery, got: %+v", c)
+	}
+	if len(c.prg) != 4*prgBankSize || len(c.chr) != 2*chrBankSize || len(c.trainer) != inesTrainerSize {
+		t.Errorf("Expected 64KiB PRG, 16KiB CHR and a trainer, got: %d %d %d", len(c.prg), len(c.chr), len(c.trainer))
+	}
+	if c.prg[0] != 0 || c.prg[len(c.prg)-1] != 3 || c.chr[0] != 0x80 || c.trainer[0] != 0x7E {
+		t.Errorf("Expected trainer, PRG and CHR to be split at the right offsets")
+	}
+	if c.prgRAM != 0x2000 || c.chrRAM != 0 {
+		t.Errorf("Expected 8KiB PRG RAM and no CHR RAM, got: %d %d", c.prgRAM, c.chrRAM)
+	}
+}
+
+func Test_LoadINES_ShouldDefaultToCHRRAM(t *testing.T) {
+	c, err := loadINES(inesImage(2, 8, 0, 0x08))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.mirroring != fourScreen || len(c.chr) != 0 || c.chrRAM != chrBankSize {
+		t.Errorf("Expected four-screen with 8KiB CHR RAM, got: %+v", c)
+	}
+}
+
+func Test_LoadINES_ShouldParseNES2Header(t *testing.T) {
+	data := inesImage(0x34, 2, 1, 0)
+	data[7] |= 0x08 // NES 2.0
+	data[8] = 0x51  // Submapper 5, Mapper Bits 8-11 = 1
+	data[9] = 0x00  // Size MSBs
+	data[10] = 0x07 // 8KiB PRG RAM
+	data[11] = 0x70 // 8KiB CHR NVRAM
+	c, err := loadINES(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.nes2 || c.mapper != 0x134 || c.submapper != 5 {
+		t.Errorf("Expected NES 2.0 mapper 308.5, got: %v %d.%d", c.nes2, c.mapper, c.submapper)
+	}
+	if c.prgRAM != 0x2000 || c.chrRAM != 0x2000 {
+		t.Errorf("Expected 8KiB PRG RAM and CHR NVRAM, got: %d %d", c.prgRAM, c.chrRAM)
+	}
+
+	// Exponent-Multiplier Notation: 2^14 * 3 = 48KiB of PRG
+	data = inesImage(0, 3, 0, 0)
+	data[7] |= 0x08
+	data[4], data[9] = 14<<2|1, 0x0F
+	if c, err = loadINES(data); err != nil || len(c.prg) != 3*prgBankSize {
+		t.Errorf("Expected 48KiB PRG from exponent notation, got: %v", err)
+	}
+}
+
+func Test_LoadINES_ShouldIgnoreJunkInArchaicHeaders(t *testing.T) {
+	data := inesImage(0x01, 1, 1, 0)
+	data[7] = 0x40 // Would Make it Mapper 65
+	copy(data[7:], "DiskDude!")
+	c, err := loadINES(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.mapper != 1 {
+		t.Errorf("Expected mapper 1, got: %d", c.mapper)
+	}
+}
+
+func Test_LoadINES_ShouldRejectBadImages(t *testing.T) {
+	good := inesImage(0, 1, 1, 0x04)
+	for name, data := range map[string][]byte{
+		"empty":      nil,
+		"bad magic":  append([]byte("NES\x00"), good[4:]...),
+		"truncated":  good[:len(good)-1],
+		"no trainer": good[:inesHeaderSize+10],
+		"no PRG":     inesImage(0, 0, 1, 0),
+	} {
+		if _, err := loadINES(data); err == nil {
+			t.Errorf("%s: Expected an error", name)
+		}
+	}
+	if _, err := loadINES(good[:3]); err != errNotINES {
+		t.Errorf("Expected errNotINES, got: %v", err)
+	}
+}
+
+func Test_Cartridge_NewMapper_ShouldRejectUnknownMappers(t *testing.T) {
+	c, _ := loadINES(inesImage(0x45, 1, 1, 0))
+	if _, err := c.newMapper(); err == nil {
+		t.Errorf("Expected mapper 69 to be unsupported")
+	}
+}
@@ -1,5 +1,7 @@
 package main
 
+import "fmt"
+
 // Addressing Modes:
 // 1.   Immediate              =>   Operand IS Numeric Value
 // 2.   Absolute               =>   Operand is Address of Value in Memory
@@ -24,276 +26,396 @@ package main
 
 type Inst func(s *system)
 
-var instructionSet = map[byte]Inst{
-	0x00: BRK,
-	0x01: ORA,
-	0x02: NOP, // Future Expansion
-	0x03: NOP, // Future Expansion
-	0x04: NOP, // Future Expansion
-	0x05: ORA,
-	0x06: ASL,
-	0x07: NOP, // Future Expansion
-	0x08: PHP,
-	0x09: ORA,
-	0x0A: ASL,
-	0x0B: NOP, // Future Expansion
-	0x0C: NOP, // Future Expansion
-	0x0D: ORA,
-	0x0E: ASL,
-	0x0F: NOP, // Future Expansion
-	0x10: BPL,
-	0x11: ORA,
-	0x12: NOP, // Future Expansion
-	0x13: NOP, // Future Expansion
-	0x14: NOP, // Future Expansion
-	0x15: ORA,
-	0x16: ASL,
-	0x17: NOP, // Future Expansion
-	0x18: CLC,
-	0x19: ORA,
-	0x1A: NOP, // Future Expansion
-	0x1B: NOP, // Future Expansion
-	0x1C: NOP, // Future Expansion
-	0x1D: ORA,
-	0x1E: ASL,
-	0x1F: NOP, // Future Expansion
-	0x20: JSR,
-	0x21: AND,
-	0x22: NOP, // Future Expansion
-	0x23: NOP, // Future Expansion
-	0x24: BIT,
-	0x25: AND,
-	0x26: ROL,
-	0x27: NOP, // Future Expansion
-	0x28: PLP,
-	0x29: AND,
-	0x2A: ROL,
-	0x2B: NOP, // Future Expansion
-	0x2C: BIT,
-	0x2D: AND,
-	0x2E: ROL,
-	0x2F: NOP, // Future Expansion
-	0x30: BMI,
-	0x31: AND,
-	0x32: NOP, // Future Expansion
-	0x33: NOP, // Future Expansion
-	0x34: NOP, // Future Expansion
-	0x35: AND,
-	0x36: ROL,
-	0x37: NOP, // Future Expansion
-	0x38: SEC,
-	0x39: AND,
-	0x3A: NOP, // Future Expansion
-	0x3B: NOP, // Future Expansion
-	0x3C: NOP, // Future Expansion
-	0x3D: AND,
-	0x3E: ROL,
-	0x3F: NOP, // Future Expansion
-	0x40: RTI,
-	0x41: EOR,
-	0x42: NOP, // Future Expansion
-	0x43: NOP, // Future Expansion
-	0x44: NOP, // Future Expansion
-	0x45: EOR,
-	0x46: LSR,
-	0x47: NOP, // Future Expansion
-	0x48: PHA,
-	0x49: EOR,
-	0x4A: LSR,
-	0x4B: NOP, // Future Expansion
-	0x4C: JMP,
-	0x4D: EOR,
-	0x4E: LSR,
-	0x4F: NOP, // Future Expansion
-	0x50: BVC,
-	0x51: EOR,
-	0x52: NOP, // Future Expansion
-	0x53: NOP, // Future Expansion
-	0x54: NOP, // Future Expansion
-	0x55: EOR,
-	0x56: LSR,
-	0x57: NOP, // Future Expansion
-	0x58: CLI,
-	0x59: EOR,
-	0x5A: NOP, // Future Expansion
-	0x5B: NOP, // Future Expansion
-	0x5C: NOP, // Future Expansion
-	0x5D: EOR,
-	0x5E: LSR,
-	0x5F: NOP, // Future Expansion
-	0x60: RTS,
-	0x61: ADC,
-	0x62: NOP, // Future Expansion
-	0x63: NOP, // Future Expansion
-	0x64: NOP, // Future Expansion
-	0x65: ADC,
-	0x66: ROR,
-	0x67: NOP, // Future Expansion
-	0x68: PLA,
-	0x69: ADC,
-	0x6A: ROR,
-	0x6B: NOP, // Future Expansion
-	0x6C: JMP,
-	0x6D: ADC,
-	0x6E: ROR,
-	0x6F: NOP, // Future Expansion
-	0x70: BVS,
-	0x71: ADC,
-	0x72: NOP, // Future Expansion
-	0x73: NOP, // Future Expansion
-	0x74: NOP, // Future Expansion
-	0x75: ADC,
-	0x76: ROR,
-	0x77: NOP, // Future Expansion
-	0x78: SEI,
-	0x79: ADC,
-	0x7A: NOP, // Future Expansion
-	0x7B: NOP, // Future Expansion
-	0x7C: NOP, // Future Expansion
-	0x7D: ADC,
-	0x7E: ROR,
-	0x7F: NOP, // Future Expansion
-	0x80: NOP, // Future Expansion
-	0x81: STA,
-	0x82: NOP, // Future Expansion
-	0x83: NOP, // Future Expansion
-	0x84: STY,
-	0x85: STA,
-	0x86: STX,
-	0x87: NOP, // Future Expansion
-	0x88: DEY,
-	0x89: NOP, // Future Expansion
-	0x8A: TXA,
-	0x8B: NOP, // Future Expansion
-	0x8C: STY,
-	0x8D: STA,
-	0x8E: STX,
-	0x8F: NOP, // Future Expansion
-	0x90: BCC,
-	0x91: STA,
-	0x92: NOP, // Future Expansion
-	0x93: NOP, // Future Expansion
-	0x94: STY,
-	0x95: STA,
-	0x96: STX,
-	0x97: NOP, // Future Expansion
-	0x98: TYA,
-	0x99: STA,
-	0x9A: TXS,
-	0x9B: NOP, // Future Expansion
-	0x9C: NOP, // Future Expansion
-	0x9D: STA,
-	0x9E: NOP, // Future Expansion
-	0x9F: NOP, // Future Expansion
-	0xA0: LDY,
-	0xA1: LDA,
-	0xA2: LDX,
-	0xA3: NOP, // Future Expansion
-	0xA4: LDY,
-	0xA5: LDA,
-	0xA6: LDX,
-	0xA7: NOP, // Future Expansion
-	0xA8: TAY,
-	0xA9: LDA,
-	0xAA: TAX,
-	0xAB: NOP, // Future Expansion
-	0xAC: LDY,
-	0xAD: LDA,
-	0xAE: LDX,
-	0xAF: NOP, // Future Expansion
-	0xB0: BCS,
-	0xB1: LDA,
-	0xB2: NOP, // Future Expansion
-	0xB3: NOP, // Future Expansion
-	0xB4: LDY,
-	0xB5: LDA,
-	0xB6: LDX,
-	0xB7: NOP, // Future Expansion
-	0xB8: CLV,
-	0xB9: LDA,
-	0xBA: TSX,
-	0xBB: NOP, // Future Expansion
-	0xBC: LDY,
-	0xBD: LDA,
-	0xBE: LDX,
-	0xBF: NOP, // Future Expansion
-	0xC0: Cpy,
-	0xC1: CMP,
-	0xC2: NOP, // Future Expansion
-	0xC3: NOP, // Future Expansion
-	0xC4: CPY,
-	0xC5: CMP,
-	0xC6: DEC,
-	0xC7: NOP, // Future Expansion
-	0xC8: INY,
-	0xC9: CMP,
-	0xCA: DEX,
-	0xCB: NOP, // Future Expansion
-	0xCC: CPY,
-	0xCD: CMP,
-	0xCE: DEC,
-	0xCF: NOP, // Future Expansion
-	0xD0: BNE,
-	0xD1: CMP,
-	0xD2: NOP, // Future Expansion
-	0xD3: NOP, // Future Expansion
-	0xD4: NOP, // Future Expansion
-	0xD5: CMP,
-	0xD6: DEC,
-	0xD7: NOP, // Future Expansion
-	0xD8: CLD,
-	0xD9: CMP,
-	0xDA: NOP, // Future Expansion
-	0xDB: NOP, // Future Expansion
-	0xDC: NOP, // Future Expansion
-	0xDD: CMP,
-	0xDE: DEC,
-	0xDF: NOP, // Future Expansion
-	0xE0: CPX,
-	0xE1: SBC,
-	0xE2: NOP, // Future Expansion
-	0xE3: NOP, // Future Expansion
-	0xE4: CPX,
-	0xE5: SBC,
-	0xE6: INC,
-	0xE7: NOP, // Future Expansion
-	0xE8: INX,
-	0xE9: SBC,
-	0xEA: NOP,
-	0xEB: NOP, // Future Expansion
-	0xEC: CPX,
-	0xED: SBC,
-	0xEE: INC,
-	0xEF: NOP, // Future Expansion
-	0xF0: BEQ,
-	0xF1: SBC,
-	0xF2: NOP, // Future Expansion
-	0xF3: NOP, // Future Expansion
-	0xF4: NOP, // Future Expansion
-	0xF5: SBC,
-	0xF6: INC,
-	0xF7: NOP, // Future Expansion
-	0xF8: SED,
-	0xF9: SBC,
-	0xFA: NOP, // Future Expansion
-	0xFB: NOP, // Future Expansion
-	0xFC: NOP, // Future Expansion
-	0xFD: SBC,
-	0xFE: INC,
-	0xFF: NOP, // Future Expansion
+// instruction Entry in the Opcode Table
+type instruction struct {
+	name   string
+	exec   Inst
+	mode   mode
+	cycles byte // Base Cycle Count, Before Page-Cross and Branch Penalties
+}
+
+var instructionSet = map[byte]instruction{
+	0x00: {"BRK", BRK, implied, 7},
+	0x01: {"ORA", ORA, indirectX, 6},
+	0x02: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x03: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x04: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x05: {"ORA", ORA, zeroPage, 3},
+	0x06: {"ASL", ASL, zeroPage, 5},
+	0x07: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x08: {"PHP", PHP, implied, 3},
+	0x09: {"ORA", ORA, immediate, 2},
+	0x0A: {"ASL", ASL, accumulator, 2},
+	0x0B: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x0C: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x0D: {"ORA", ORA, absolute, 4},
+	0x0E: {"ASL", ASL, absolute, 6},
+	0x0F: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x10: {"BPL", BPL, relative, 2},
+	0x11: {"ORA", ORA, indirectY, 5},
+	0x12: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x13: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x14: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x15: {"ORA", ORA, zeroPageX, 4},
+	0x16: {"ASL", ASL, zeroPageX, 6},
+	0x17: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x18: {"CLC", CLC, implied, 2},
+	0x19: {"ORA", ORA, absoluteY, 4},
+	0x1A: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x1B: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x1C: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x1D: {"ORA", ORA, absoluteX, 4},
+	0x1E: {"ASL", ASL, absoluteX, 7},
+	0x1F: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x20: {"JSR", JSR, absolute, 6},
+	0x21: {"AND", AND, indirectX, 6},
+	0x22: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x23: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x24: {"BIT", BIT, zeroPage, 3},
+	0x25: {"AND", AND, zeroPage, 3},
+	0x26: {"ROL", ROL, zeroPage, 5},
+	0x27: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x28: {"PLP", PLP, implied, 4},
+	0x29: {"AND", AND, immediate, 2},
+	0x2A: {"ROL", ROL, accumulator, 2},
+	0x2B: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x2C: {"BIT", BIT, absolute, 4},
+	0x2D: {"AND", AND, absolute, 4},
+	0x2E: {"ROL", ROL, absolute, 6},
+	0x2F: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x30: {"BMI", BMI, relative, 2},
+	0x31: {"AND", AND, indirectY, 5},
+	0x32: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x33: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x34: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x35: {"AND", AND, zeroPageX, 4},
+	0x36: {"ROL", ROL, zeroPageX, 6},
+	0x37: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x38: {"SEC", SEC, implied, 2},
+	0x39: {"AND", AND, absoluteY, 4},
+	0x3A: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x3B: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x3C: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x3D: {"AND", AND, absoluteX, 4},
+	0x3E: {"ROL", ROL, absoluteX, 7},
+	0x3F: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x40: {"RTI", RTI, implied, 6},
+	0x41: {"EOR", EOR, indirectX, 6},
+	0x42: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x43: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x44: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x45: {"EOR", EOR, zeroPage, 3},
+	0x46: {"LSR", LSR, zeroPage, 5},
+	0x47: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x48: {"PHA", PHA, implied, 3},
+	0x49: {"EOR", EOR, immediate, 2},
+	0x4A: {"LSR", LSR, accumulator, 2},
+	0x4B: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x4C: {"JMP", JMP, absolute, 3},
+	0x4D: {"EOR", EOR, absolute, 4},
+	0x4E: {"LSR", LSR, absolute, 6},
+	0x4F: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x50: {"BVC", BVC, relative, 2},
+	0x51: {"EOR", EOR, indirectY, 5},
+	0x52: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x53: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x54: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x55: {"EOR", EOR, zeroPageX, 4},
+	0x56: {"LSR", LSR, zeroPageX, 6},
+	0x57: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x58: {"CLI", CLI, implied, 2},
+	0x59: {"EOR", EOR, absoluteY, 4},
+	0x5A: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x5B: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x5C: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x5D: {"EOR", EOR, absoluteX, 4},
+	0x5E: {"LSR", LSR, absoluteX, 7},
+	0x5F: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x60: {"RTS", RTS, implied, 6},
+	0x61: {"ADC", ADC, indirectX, 6},
+	0x62: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x63: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x64: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x65: {"ADC", ADC, zeroPage, 3},
+	0x66: {"ROR", ROR, zeroPage, 5},
+	0x67: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x68: {"PLA", PLA, implied, 4},
+	0x69: {"ADC", ADC, immediate, 2},
+	0x6A: {"ROR", ROR, accumulator, 2},
+	0x6B: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x6C: {"JMP", JMP, indirect, 5},
+	0x6D: {"ADC", ADC, absolute, 4},
+	0x6E: {"ROR", ROR, absolute, 6},
+	0x6F: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x70: {"BVS", BVS, relative, 2},
+	0x71: {"ADC", ADC, indirectY, 5},
+	0x72: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x73: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x74: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x75: {"ADC", ADC, zeroPageX, 4},
+	0x76: {"ROR", ROR, zeroPageX, 6},
+	0x77: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x78: {"SEI", SEI, implied, 2},
+	0x79: {"ADC", ADC, absoluteY, 4},
+	0x7A: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x7B: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x7C: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x7D: {"ADC", ADC, absoluteX, 4},
+	0x7E: {"ROR", ROR, absoluteX, 7},
+	0x7F: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x80: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x81: {"STA", STA, indirectX, 6},
+	0x82: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x83: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x84: {"STY", STY, zeroPage, 3},
+	0x85: {"STA", STA, zeroPage, 3},
+	0x86: {"STX", STX, zeroPage, 3},
+	0x87: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x88: {"DEY", DEY, implied, 2},
+	0x89: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x8A: {"TXA", TXA, implied, 2},
+	0x8B: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x8C: {"STY", STY, absolute, 4},
+	0x8D: {"STA", STA, absolute, 4},
+	0x8E: {"STX", STX, absolute, 4},
+	0x8F: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x90: {"BCC", BCC, relative, 2},
+	0x91: {"STA", STA, indirectY, 6},
+	0x92: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x93: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x94: {"STY", STY, zeroPageX, 4},
+	0x95: {"STA", STA, zeroPageX, 4},
+	0x96: {"STX", STX, zeroPageY, 4},
+	0x97: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x98: {"TYA", TYA, implied, 2},
+	0x99: {"STA", STA, absoluteY, 5},
+	0x9A: {"TXS", TXS, implied, 2},
+	0x9B: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x9C: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x9D: {"STA", STA, absoluteX, 5},
+	0x9E: {"NOP", NOP, implied, 2}, // Future Expansion
+	0x9F: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xA0: {"LDY", LDY, immediate, 2},
+	0xA1: {"LDA", LDA, indirectX, 6},
+	0xA2: {"LDX", LDX, immediate, 2},
+	0xA3: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xA4: {"LDY", LDY, zeroPage, 3},
+	0xA5: {"LDA", LDA, zeroPage, 3},
+	0xA6: {"LDX", LDX, zeroPage, 3},
+	0xA7: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xA8: {"TAY", TAY, implied, 2},
+	0xA9: {"LDA", LDA, immediate, 2},
+	0xAA: {"TAX", TAX, implied, 2},
+	0xAB: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xAC: {"LDY", LDY, absolute, 4},
+	0xAD: {"LDA", LDA, absolute, 4},
+	0xAE: {"LDX", LDX, absolute, 4},
+	0xAF: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xB0: {"BCS", BCS, relative, 2},
+	0xB1: {"LDA", LDA, indirectY, 5},
+	0xB2: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xB3: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xB4: {"LDY", LDY, zeroPageX, 4},
+	0xB5: {"LDA", LDA, zeroPageX, 4},
+	0xB6: {"LDX", LDX, zeroPageY, 4},
+	0xB7: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xB8: {"CLV", CLV, implied, 2},
+	0xB9: {"LDA", LDA, absoluteY, 4},
+	0xBA: {"TSX", TSX, implied, 2},
+	0xBB: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xBC: {"LDY", LDY, absoluteX, 4},
+	0xBD: {"LDA", LDA, absoluteX, 4},
+	0xBE: {"LDX", LDX, absoluteY, 4},
+	0xBF: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xC0: {"CPY", CPY, immediate, 2},
+	0xC1: {"CMP", CMP, indirectX, 6},
+	0xC2: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xC3: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xC4: {"CPY", CPY, zeroPage, 3},
+	0xC5: {"CMP", CMP, zeroPage, 3},
+	0xC6: {"DEC", DEC, zeroPage, 5},
+	0xC7: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xC8: {"INY", INY, implied, 2},
+	0xC9: {"CMP", CMP, immediate, 2},
+	0xCA: {"DEX", DEX, implied, 2},
+	0xCB: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xCC: {"CPY", CPY, absolute, 4},
+	0xCD: {"CMP", CMP, absolute, 4},
+	0xCE: {"DEC", DEC, absolute, 6},
+	0xCF: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xD0: {"BNE", BNE, relative, 2},
+	0xD1: {"CMP", CMP, indirectY, 5},
+	0xD2: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xD3: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xD4: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xD5: {"CMP", CMP, zeroPageX, 4},
+	0xD6: {"DEC", DEC, zeroPageX, 6},
+	0xD7: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xD8: {"CLD", CLD, implied, 2},
+	0xD9: {"CMP", CMP, absoluteY, 4},
+	0xDA: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xDB: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xDC: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xDD: {"CMP", CMP, absoluteX, 4},
+	0xDE: {"DEC", DEC, absoluteX, 7},
+	0xDF: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xE0: {"CPX", CPX, immediate, 2},
+	0xE1: {"SBC", SBC, indirectX, 6},
+	0xE2: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xE3: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xE4: {"CPX", CPX, zeroPage, 3},
+	0xE5: {"SBC", SBC, zeroPage, 3},
+	0xE6: {"INC", INC, zeroPage, 5},
+	0xE7: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xE8: {"INX", INX, implied, 2},
+	0xE9: {"SBC", SBC, immediate, 2},
+	0xEA: {"NOP", NOP, implied, 2},
+	0xEB: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xEC: {"CPX", CPX, absolute, 4},
+	0xED: {"SBC", SBC, absolute, 4},
+	0xEE: {"INC", INC, absolute, 6},
+	0xEF: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xF0: {"BEQ", BEQ, relative, 2},
+	0xF1: {"SBC", SBC, indirectY, 5},
+	0xF2: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xF3: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xF4: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xF5: {"SBC", SBC, zeroPageX, 4},
+	0xF6: {"INC", INC, zeroPageX, 6},
+	0xF7: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xF8: {"SED", SED, implied, 2},
+	0xF9: {"SBC", SBC, absoluteY, 4},
+	0xFA: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xFB: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xFC: {"NOP", NOP, implied, 2}, // Future Expansion
+	0xFD: {"SBC", SBC, absoluteX, 4},
+	0xFE: {"INC", INC, absoluteX, 7},
+	0xFF: {"NOP", NOP, implied, 2}, // Future Expansion
+}
+
+// documented Whether op is One of the 151 Documented Opcodes; the Rest are
+// Treated as Single-Byte NOPs
+func documented(op byte) bool {
+	return instructionSet[op].name != "NOP" || op == 0xEA
 }
 
+// exec Execute the Instruction at the Program Counter
+//
+// Every Bus Access Takes a Cycle as it Happens (See busRead), Including the
+// Dummy Read at the Unfixed Address of an Indexed Operand and the Dummy
+// Write of a Read-Modify-Write. Cycles the CPU Spends Internally, or on
+// Reads that Can't Reach an I/O Register (the Stack, the Byte after an
+// Implied Opcode), are Clocked Once the Instruction's Accesses are Done.
 func (s *system) exec() {
-	for inst := s.next(); inst != nil; inst = s.next() {
-		if fn, ok := instructionSet[inst]; ok {
-			// Found Instruction
-			fn(s)
+	if s.trace != nil {
+		fmt.Fprintln(s.trace, s.traceLine())
+	}
+	start := s.cpu.clk
+	s.cpu.pollI = s.cpu.sr.i
+	op := s.next()
+	in := instructionSet[op]
+	s.cpu.inst = op
+	s.cpu.mode = in.mode
+	s.cpu.pollAt = start + uint64(in.cycles) - 1
+	switch in.name {
+	case "CLI", "SEI", "PLP": // I Changes After the Poll
+		s.cpu.pollHoldI = true
+	default:
+		s.cpu.pollHoldI = false
+	}
+
+	// Decoding Ends Before the Last Cycle of Any Read that Can Cross a
+	// Page, so the Poll Can Still Move
+	o := s.decode(in.mode)
+	s.cpu.addr, s.cpu.crossed = o.addr, o.crossed
+	if o.crossed && pageCrossReads[in.name] {
+		s.cpu.pollAt++
+	}
+
+	switch in.mode {
+	case absoluteX, absoluteY, indirectY:
+		// Before the High Byte is Fixed Up, the CPU Reads the Address
+		// Without the Carry. Reads that Don't Cross Skip this Cycle.
+		if o.crossed || !pageCrossReads[in.name] {
+			unfixed := o.addr
+			if o.crossed {
+				unfixed -= 0x100
+			}
+			s.busRead(unfixed)
 		}
-		// Else: NOP
 	}
+
+	in.exec(s)
+	for end := s.cpu.pollAt + 1; s.cpu.clk < end; {
+		s.tick()
+	}
+	s.cpu.pollAt = noPoll
+	s.service()
 }
 
-const byteSize = 256
+// operand Value the Current Instruction Operates on
+func (s *system) operand() byte {
+	if s.cpu.mode == accumulator {
+		return s.cpu.ac
+	}
+	s.cpu.value = s.busRead(s.cpu.addr)
+	return s.cpu.value
+}
+
+// setOperand Write Back the Result of a Read-Modify-Write Instruction,
+// After Writing Back the Unmodified Value as the 6502 Does
+func (s *system) setOperand(v byte) {
+	if s.cpu.mode == accumulator {
+		s.cpu.ac = v
+		return
+	}
+	s.busWrite(s.cpu.addr, s.cpu.value)
+	s.busWrite(s.cpu.addr, v)
+}
+
+// stackPage The Stack Lives in Page 1, Growing Down from 0x01FF
+const stackPage = 0x0100
+
+func (s *system) push(v byte) {
+	s.busWrite(stackPage|uint16(s.cpu.sp), v)
+	s.cpu.sp--
+}
+
+func (s *system) pull() byte {
+	s.cpu.sp++
+	return s.busRead(stackPage | uint16(s.cpu.sp))
+}
+
+// push16 Push a Word, High Byte First
+func (s *system) push16(v uint16) {
+	s.push(byte(v >> 8))
+	s.push(byte(v))
+}
+
+func (s *system) pull16() uint16 {
+	lo := s.pull()
+	return uint16(lo) | uint16(s.pull())<<8
+}
+
+// branch Jump to the Resolved Target if cond Holds, Taking an Extra Cycle,
+// and a Second if the Target is on a Different Page. The Poll Moves to the
+// New Last Cycle.
+func (s *system) branch(cond bool) {
+	if cond {
+		s.cpu.pc = s.cpu.addr
+		s.cpu.pollAt++
+		if s.cpu.crossed {
+			s.cpu.pollAt++
+		}
+	}
+}
+
+// compare Set Flags for r - M, as CMP / CPX / CPY
+func (s *system) compare(r byte) {
+	m := s.operand()
+	s.cpu.sr.c = r >= m
+	s.cpu.sr.setZS(r - m)
+}
 
 // ADC: Add with Carry
 //
@@ -304,189 +426,335 @@ const byteSize = 256
 // Immediate     | code: 69 | reads: 2 | clk: 2
 // Zero Page     | code: 65 | reads: 2 | clk: 3
 // Zero Page,X   | code: 75 | reads: 2 | clk: 4
-// Absolute      | code: 60 | reads: 3 | clk: 4
-// Absolute,X    | code: 70 | reads: 3 | clk: 4*
+// Absolute      | code: 6D | reads: 3 | clk: 4
+// Absolute,X    | code: 7D | reads: 3 | clk: 4*
 // Absolute,Y    | code: 79 | reads: 3 | clk: 4*
 // (Indirect,X)  | code: 61 | reads: 2 | clk: 6
 // (Indirect),Y  | code: 71 | reads: 2 | clk: 5*
 func ADC(s *system) {
-	carry := s.cpu.status.carry()
-	acc := s.cpu.ac
-	var (
-		mem      byte
-		res      byte
-		rescarry byte
-	)
-
-	switch code := s.peek(); code {
-	case 0x69: // Immediate
-		mem = s.next()
-
-	case 0x65: // Zero Page
-		pageIndex := 0
-		memIndex := s.next()
-		mem = s.mem[pageIndex][memIndex]
-
-	case 0x75: // Zero Page,X
-		pageIndex := 0
-		memIndex := s.next()
-		xr := s.cpu.xr
-		mem = s.mem[pageIndex][memIndex+xr]
-
-	case 0x60: // Absolute
-		// Little Endian
-		memIndex := s.next()
-		pageIndex := s.next()
-		mem = s.mem[pageIndex][memIndex]
-
-	case 0x70: // Absolute,X
-		memIndex := s.next()
-		pageIndex := s.next()
-		xr := s.cpu.xr
-		mem = s.mem[pageIndex][memIndex+xr]
-
-	case 0x79: // Absolute,Y
-		memIndex, pageIndex := s.next(), s.next()
-		yr := s.cpu.yr
-		mem = s.mem[pageIndex][memIndex+yr]
-
-	case 0x61: // (Indirect,X)
-		pageIndex := 0
-		memIndex := s.next()
-		xr := s.cpu.xr
-		// Little Endian
-		b1, b0 := s.mem[pageIndex][memIndex+xr], s.mem[pageIndex][memIndex+xr+1]
-		mem = s.mem[b0][b1]
-
-	case 0x71: // (Indirect),Y
-		pageIndex := 0
-		memIndex := s.next()
-		// Little Endian
-		b1, b0 := s.mem[pageIndex][memIndex], s.mem[pageIndex][memIndex+1]
-		yr := s.cpu.yr
-		b0 = (b0 + yr) % byteSize
-		b1 = b1 + (b0+yr)/byteSize
-		mem = s.mem[b0][b1]
-
-	default: // Unknown: NOP
+	acc, mem, carry := s.cpu.ac, s.operand(), s.cpu.sr.carry()
+	sum := uint16(acc) + uint16(mem) + uint16(carry)
+	res := byte(sum)
+
+	s.cpu.sr.z = res == 0
+	if s.cpu.sr.d && s.cpu.decimal {
+		// NMOS 6502: N and V Come from the Intermediate Result, After
+		// Adjusting the Low Digit but Before Adjusting the High One
+		lo := acc&0x0F + mem&0x0F + carry
+		if lo > 0x09 {
+			lo += 0x06
+		}
+		hi := uint16(acc>>4) + uint16(mem>>4)
+		if lo > 0x0F {
+			hi++
+		}
+		s.cpu.sr.s = hi&0x08 != 0
+		s.cpu.sr.v = (acc^byte(hi<<4))&(mem^byte(hi<<4))&0x80 != 0
+		if hi > 0x09 {
+			hi += 0x06
+		}
+		s.cpu.sr.c = hi > 0x0F
+		s.cpu.ac = byte(hi<<4) | lo&0x0F
 		return
 	}
 
-	res = (acc + mem + carry) % byteSize
-	rescarry = (acc + mem + carry) / byteSize
-	s.cpu.status.setCarry(rescarry)
+	s.cpu.sr.setCarry(byte(sum >> 8))
+	s.cpu.sr.v = (acc^res)&(mem^res)&0x80 != 0
+	s.cpu.sr.s = res&0x80 != 0
 	s.cpu.ac = res
 }
 
-func AND(s *system) {}
+// AND: A AND M -> A
+func AND(s *system) {
+	s.cpu.ac &= s.operand()
+	s.cpu.sr.setZS(s.cpu.ac)
+}
 
-func ASL(s *system) {}
+// ASL: C <- [76543210] <- 0
+func ASL(s *system) {
+	m := s.operand()
+	s.cpu.sr.c = m&0x80 != 0
+	m <<= 1
+	s.cpu.sr.setZS(m)
+	s.setOperand(m)
+}
 
-func BCC(s *system) {}
+// BCC: Branch on C = 0
+func BCC(s *system) { s.branch(!s.cpu.sr.c) }
 
-func BCS(s *system) {}
+// BCS: Branch on C = 1
+func BCS(s *system) { s.branch(s.cpu.sr.c) }
 
-func BEQ(s *system) {}
+// BEQ: Branch on Z = 1
+func BEQ(s *system) { s.branch(s.cpu.sr.z) }
 
-func BIT(s *system) {}
+// BIT: A AND M, M7 -> N, M6 -> V
+func BIT(s *system) {
+	m := s.operand()
+	s.cpu.sr.z = s.cpu.ac&m == 0
+	s.cpu.sr.s = m&0x80 != 0
+	s.cpu.sr.v = m&0x40 != 0
+}
 
-func BMI(s *system) {}
+// BMI: Branch on N = 1
+func BMI(s *system) { s.branch(s.cpu.sr.s) }
 
-func BNE(s *system) {}
+// BNE: Branch on Z = 0
+func BNE(s *system) { s.branch(!s.cpu.sr.z) }
 
-func BPL(s *system) {}
+// BPL: Branch on N = 0
+func BPL(s *system) { s.branch(!s.cpu.sr.s) }
 
-func BRK(s *system) {}
+// BRK: Force Break
+//
+// Pushes PC + 2 (BRK Skips a Padding Byte) and the Status with B Set, then
+// Jumps Through the IRQ Vector with Interrupts Disabled.
+func BRK(s *system) {
+	s.next() // Skip the Padding Byte
+	s.interrupt(irqVector, true)
+}
 
-func BVC(s *system) {}
+// BVC: Branch on V = 0
+func BVC(s *system) { s.branch(!s.cpu.sr.v) }
 
-func BVS(s *system) {}
+// BVS: Branch on V = 1
+func BVS(s *system) { s.branch(s.cpu.sr.v) }
 
-func CLC(s *system) {}
+// CLC: 0 -> C
+func CLC(s *system) { s.cpu.sr.c = false }
 
-func CLD(s *system) {}
+// CLD: 0 -> D
+func CLD(s *system) { s.cpu.sr.d = false }
 
-func CLI(s *system) {}
+// CLI: 0 -> I
+func CLI(s *system) { s.cpu.sr.i = false }
 
-func CLV(s *system) {}
+// CLV: 0 -> V
+func CLV(s *system) { s.cpu.sr.v = false }
 
-func CMP(s *system) {}
+// CMP: A - M
+func CMP(s *system) { s.compare(s.cpu.ac) }
 
-func CPX(s *system) {}
+// CPX: X - M
+func CPX(s *system) { s.compare(s.cpu.xr) }
 
-func CPY(s *system) {}
+// CPY: Y - M
+func CPY(s *system) { s.compare(s.cpu.yr) }
 
-func DEC(s *system) {}
+// DEC: M - 1 -> M
+func DEC(s *system) {
+	m := s.operand() - 1
+	s.cpu.sr.setZS(m)
+	s.setOperand(m)
+}
 
-func DEX(s *system) {}
+// DEX: X - 1 -> X
+func DEX(s *system) {
+	s.cpu.xr--
+	s.cpu.sr.setZS(s.cpu.xr)
+}
 
-func DEY(s *system) {}
+// DEY: Y - 1 -> Y
+func DEY(s *system) {
+	s.cpu.yr--
+	s.cpu.sr.setZS(s.cpu.yr)
+}
 
-func EOR(s *system) {}
+// EOR: A EOR M -> A
+func EOR(s *system) {
+	s.cpu.ac ^= s.operand()
+	s.cpu.sr.setZS(s.cpu.ac)
+}
 
-func INC(s *system) {}
+// INC: M + 1 -> M
+func INC(s *system) {
+	m := s.operand() + 1
+	s.cpu.sr.setZS(m)
+	s.setOperand(m)
+}
 
-func INX(s *system) {}
+// INX: X + 1 -> X
+func INX(s *system) {
+	s.cpu.xr++
+	s.cpu.sr.setZS(s.cpu.xr)
+}
 
-func INY(s *system) {}
+// INY: Y + 1 -> Y
+func INY(s *system) {
+	s.cpu.yr++
+	s.cpu.sr.setZS(s.cpu.yr)
+}
 
-func JMP(s *system) {}
+// JMP: Address -> PC
+func JMP(s *system) { s.cpu.pc = s.cpu.addr }
 
-func JSR(s *system) {}
+// JSR: PC + 2 -> Stack, Address -> PC
+//
+// The Pushed Address is that of the Last Byte of the JSR, so RTS Adds One.
+func JSR(s *system) {
+	s.push16(s.cpu.pc - 1)
+	s.cpu.pc = s.cpu.addr
+}
 
-func LDA(s *system) {}
+// LDA: M -> A
+func LDA(s *system) {
+	s.cpu.ac = s.operand()
+	s.cpu.sr.setZS(s.cpu.ac)
+}
 
-func LDX(s *system) {}
+// LDX: M -> X
+func LDX(s *system) {
+	s.cpu.xr = s.operand()
+	s.cpu.sr.setZS(s.cpu.xr)
+}
 
-func LDY(s *system) {}
+// LDY: M -> Y
+func LDY(s *system) {
+	s.cpu.yr = s.operand()
+	s.cpu.sr.setZS(s.cpu.yr)
+}
 
-func LSR(s *system) {}
+// LSR: 0 -> [76543210] -> C
+func LSR(s *system) {
+	m := s.operand()
+	s.cpu.sr.c = m&0x01 != 0
+	m >>= 1
+	s.cpu.sr.setZS(m)
+	s.setOperand(m)
+}
 
+// NOP: No Operation
 func NOP(s *system) {}
 
-func ORA(s *system) {}
-
-func PHA(s *system) {}
-
-func PHP(s *system) {}
+// ORA: A OR M -> A
+func ORA(s *system) {
+	s.cpu.ac |= s.operand()
+	s.cpu.sr.setZS(s.cpu.ac)
+}
 
-func PLA(s *system) {}
+// PHA: A -> Stack
+func PHA(s *system) { s.push(s.cpu.ac) }
 
-func PLP(s *system) {}
+// PHP: P -> Stack, with B Set in the Pushed Copy
+func PHP(s *system) {
+	p := s.cpu.sr
+	p.b = true
+	s.push(p.pack())
+}
 
-func ROL(s *system) {}
+// PLA: Stack -> A
+func PLA(s *system) {
+	s.cpu.ac = s.pull()
+	s.cpu.sr.setZS(s.cpu.ac)
+}
 
-func ROR(s *system) {}
+// PLP: Stack -> P
+func PLP(s *system) { s.cpu.sr.set(s.pull()) }
+
+// ROL: C <- [76543210] <- C
+func ROL(s *system) {
+	m := s.operand()
+	carry := s.cpu.sr.carry()
+	s.cpu.sr.c = m&0x80 != 0
+	m = m<<1 | carry
+	s.cpu.sr.setZS(m)
+	s.setOperand(m)
+}
 
-func RTI(s *system) {}
+// ROR: C -> [76543210] -> C
+func ROR(s *system) {
+	m := s.operand()
+	carry := s.cpu.sr.carry()
+	s.cpu.sr.c = m&0x01 != 0
+	m = m>>1 | carry<<7
+	s.cpu.sr.setZS(m)
+	s.setOperand(m)
+}
 
-func RTS(s *system) {}
+// RTI: Stack -> P, Stack -> PC
+func RTI(s *system) {
+	s.cpu.sr.set(s.pull())
+	s.cpu.pc = s.pull16()
+}
 
-func SBC(s *system) {}
+// RTS: Stack -> PC, PC + 1 -> PC
+func RTS(s *system) { s.cpu.pc = s.pull16() + 1 }
+
+// SBC: A - M - ~C -> A
+func SBC(s *system) {
+	acc, mem, borrow := s.cpu.ac, s.operand(), 1-s.cpu.sr.carry()
+	diff := uint16(acc) - uint16(mem) - uint16(borrow)
+	res := byte(diff)
+
+	// Flags Follow the Binary Result Even in Decimal Mode
+	s.cpu.sr.c = diff < 0x100
+	s.cpu.sr.v = (acc^mem)&(acc^res)&0x80 != 0
+	s.cpu.sr.setZS(res)
+	if s.cpu.sr.d && s.cpu.decimal {
+		lo := int(acc&0x0F) - int(mem&0x0F) - int(borrow)
+		hi := int(acc>>4) - int(mem>>4)
+		if lo < 0 {
+			lo -= 0x06
+			hi--
+		}
+		if hi < 0 {
+			hi -= 0x06
+		}
+		res = byte(hi<<4) | byte(lo&0x0F)
+	}
+	s.cpu.ac = res
+}
 
-func SEC(s *system) {}
+// SEC: 1 -> C
+func SEC(s *system) { s.cpu.sr.c = true }
 
-func SED(s *system) {}
+// SED: 1 -> D
+func SED(s *system) { s.cpu.sr.d = true }
 
-func SEI(s *system) {}
+// SEI: 1 -> I
+func SEI(s *system) { s.cpu.sr.i = true }
 
-func STA(s *system) {}
+// STA: A -> M
+func STA(s *system) { s.busWrite(s.cpu.addr, s.cpu.ac) }
 
-func STX(s *system) {}
+// STX: X -> M
+func STX(s *system) { s.busWrite(s.cpu.addr, s.cpu.xr) }
 
-func STY(s *system) {}
+// STY: Y -> M
+func STY(s *system) { s.busWrite(s.cpu.addr, s.cpu.yr) }
 
-func TAX(s *system) {}
+// TAX: A -> X
+func TAX(s *system) {
+	s.cpu.xr = s.cpu.ac
+	s.cpu.sr.setZS(s.cpu.xr)
+}
 
-func TAY(s *system) {}
+// TAY: A -> Y
+func TAY(s *system) {
+	s.cpu.yr = s.cpu.ac
+	s.cpu.sr.setZS(s.cpu.yr)
+}
 
-func TSX(s *system) {}
+// TSX: SP -> X
+func TSX(s *system) {
+	s.cpu.xr = s.cpu.sp
+	s.cpu.sr.setZS(s.cpu.xr)
+}
 
-func TXA(s *system) {}
+// TXA: X -> A
+func TXA(s *system) {
+	s.cpu.ac = s.cpu.xr
+	s.cpu.sr.setZS(s.cpu.ac)
+}
 
-func TXS(s *system) {}
+// TXS: X -> SP, Without Touching Flags
+func TXS(s *system) { s.cpu.sp = s.cpu.xr }
 
-func TYA(s *system) {}
+// TYA: Y -> A
+func TYA(s *system) {
+	s.cpu.ac = s.cpu.yr
+	s.cpu.sr.setZS(s.cpu.ac)
+}
 
 // func (s *system) exec() {
 // 	switch i := s.next(); i {
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// regs Register File Before or After an Instruction
+//
+// p is the Packed Status Byte; Bit 5 (flagU) is Implied. A Zero sp Stands
+// for the Power-Up Value 0xFD.
+type regs struct {
+	a, x, y, p, sp byte
+	pc             uint16
+}
+
+type instCase struct {
+	name   string
+	prog   []byte          // Loaded at loadAddr
+	in     regs            // pc is Ignored; Execution Starts at loadAddr
+	mem    map[uint16]byte // Memory Before
+	out    regs
+	want   map[uint16]byte // Memory After
+	cycles uint64
+}
+
+const (
+	pcStart = loadAddr
+	sp0     = 0xFD
+)
+
+var instCases = []instCase{
+	// ADC
+	{name: "ADC #imm", prog: []byte{0x69, 0x10}, in: regs{a: 0x20}, out: regs{a: 0x30, pc: pcStart + 2}, cycles: 2},
+	{name: "ADC #imm carry in", prog: []byte{0x69, 0x10}, in: regs{a: 0x20, p: flagC}, out: regs{a: 0x31, pc: pcStart + 2}, cycles: 2},
+	{name: "ADC #imm carry out zero", prog: []byte{0x69, 0x01}, in: regs{a: 0xFF}, out: regs{a: 0x00, p: flagC | flagZ, pc: pcStart + 2}, cycles: 2},
+	{name: "ADC #imm overflow", prog: []byte{0x69, 0x50}, in: regs{a: 0x50}, out: regs{a: 0xA0, p: flagV | flagS, pc: pcStart + 2}, cycles: 2},
+	{name: "ADC #imm negative overflow", prog: []byte{0x69, 0x90}, in: regs{a: 0xD0}, out: regs{a: 0x60, p: flagV | flagC, pc: pcStart + 2}, cycles: 2},
+	{name: "ADC zp", prog: []byte{0x65, 0x10}, in: regs{a: 1}, mem: map[uint16]byte{0x10: 2}, out: regs{a: 3, pc: pcStart + 2}, cycles: 3},
+	{name: "ADC zp,X", prog: []byte{0x75, 0x10}, in: regs{a: 1, x: 2}, mem: map[uint16]byte{0x12: 2}, out: regs{a: 3, x: 2, pc: pcStart + 2}, cycles: 4},
+	{name: "ADC abs", prog: []byte{0x6D, 0x34, 0x12}, in: regs{a: 1}, mem: map[uint16]byte{0x1234: 2}, out: regs{a: 3, pc: pcStart + 3}, cycles: 4},
+	{name: "ADC abs,X", prog: []byte{0x7D, 0x34, 0x12}, in: regs{a: 1, x: 1}, mem: map[uint16]byte{0x1235: 2}, out: regs{a: 3, x: 1, pc: pcStart + 3}, cycles: 4},
+	{name: "ADC abs,Y", prog: []byte{0x79, 0x34, 0x12}, in: regs{a: 1, y: 1}, mem: map[uint16]byte{0x1235: 2}, out: regs{a: 3, y: 1, pc: pcStart + 3}, cycles: 4},
+	{name: "ADC (zp,X)", prog: []byte{0x61, 0x10}, in: regs{a: 1, x: 2}, mem: map[uint16]byte{0x12: 0x34, 0x13: 0x12, 0x1234: 2}, out: regs{a: 3, x: 2, pc: pcStart + 2}, cycles: 6},
+	{name: "ADC (zp),Y", prog: []byte{0x71, 0x10}, in: regs{a: 1, y: 1}, mem: map[uint16]byte{0x10: 0x34, 0x11: 0x12, 0x1235: 2}, out: regs{a: 3, y: 1, pc: pcStart + 2}, cycles: 5},
+
+	// AND
+	{name: "AND #imm", prog: []byte{0x29, 0x0F}, in: regs{a: 0x3C}, out: regs{a: 0x0C, pc: pcStart + 2}, cycles: 2},
+	{name: "AND #imm zero", prog: []byte{0x29, 0x0F}, in: regs{a: 0xF0}, out: regs{a: 0x00, p: flagZ, pc: pcStart + 2}, cycles: 2},
+	{name: "AND zp", prog: []byte{0x25, 0x10}, in: regs{a: 0xFF}, mem: map[uint16]byte{0x10: 0x80}, out: regs{a: 0x80, p: flagS, pc: pcStart + 2}, cycles: 3},
+	{name: "AND zp,X", prog: []byte{0x35, 0x10}, in: regs{a: 0xFF, x: 1}, mem: map[uint16]byte{0x11: 0x01}, out: regs{a: 0x01, x: 1, pc: pcStart + 2}, cycles: 4},
+	{name: "AND abs", prog: []byte{0x2D, 0x00, 0x02}, in: regs{a: 0xFF}, mem: map[uint16]byte{0x0200: 0x01}, out: regs{a: 0x01, pc: pcStart + 3}, cycles: 4},
+	{name: "AND abs,X", prog: []byte{0x3D, 0x00, 0x02}, in: regs{a: 0xFF, x: 1}, mem: map[uint16]byte{0x0201: 0x01}, out: regs{a: 0x01, x: 1, pc: pcStart + 3}, cycles: 4},
+	{name: "AND abs,Y", prog: []byte{0x39, 0x00, 0x02}, in: regs{a: 0xFF, y: 1}, mem: map[uint16]byte{0x0201: 0x01}, out: regs{a: 0x01, y: 1, pc: pcStart + 3}, cycles: 4},
+	{name: "AND (zp,X)", prog: []byte{0x21, 0x10}, in: regs{a: 0xFF, x: 1}, mem: map[uint16]byte{0x11: 0x00, 0x12: 0x02, 0x0200: 0x01}, out: regs{a: 0x01, x: 1, pc: pcStart + 2}, cycles: 6},
+	{name: "AND (zp),Y", prog: []byte{0x31, 0x10}, in: regs{a: 0xFF, y: 1}, mem: map[uint16]byte{0x10: 0x00, 0x11: 0x02, 0x0201: 0x01}, out: regs{a: 0x01, y: 1, pc: pcStart + 2}, cycles: 5},
+
+	// ASL
+	{name: "ASL A", prog: []byte{0x0A}, in: regs{a: 0x81}, out: regs{a: 0x02, p: flagC, pc: pcStart + 1}, cycles: 2},
+	{name: "ASL zp", prog: []byte{0x06, 0x10}, mem: map[uint16]byte{0x10: 0x40}, out: regs{p: flagS, pc: pcStart + 2}, want: map[uint16]byte{0x10: 0x80}, cycles: 5},
+	{name: "ASL zp,X", prog: []byte{0x16, 0x10}, in: regs{x: 1}, mem: map[uint16]byte{0x11: 0x80}, out: regs{x: 1, p: flagC | flagZ, pc: pcStart + 2}, want: map[uint16]byte{0x11: 0x00}, cycles: 6},
+	{name: "ASL abs", prog: []byte{0x0E, 0x00, 0x02}, mem: map[uint16]byte{0x0200: 0x01}, out: regs{pc: pcStart + 3}, want: map[uint16]byte{0x0200: 0x02}, cycles: 6},
+	{name: "ASL abs,X", prog: []byte{0x1E, 0x00, 0x02}, in: regs{x: 1}, mem: map[uint16]byte{0x0201: 0x01}, out: regs{x: 1, pc: pcStart + 3}, want: map[uint16]byte{0x0201: 0x02}, cycles: 7},
+
+	// Branches
+	{name: "BCC taken", prog: []byte{0x90, 0x10}, out: regs{pc: pcStart + 0x12}, cycles: 3},
+	{name: "BCC not taken", prog: []byte{0x90, 0x10}, in: regs{p: flagC}, out: regs{p: flagC, pc: pcStart + 2}, cycles: 2},
+	{name: "BCS taken backwards", prog: []byte{0xB0, 0xFE}, in: regs{p: flagC}, out: regs{p: flagC, pc: pcStart}, cycles: 3},
+	{name: "BCS not taken", prog: []byte{0xB0, 0x10}, out: regs{pc: pcStart + 2}, cycles: 2},
+	{name: "BEQ taken", prog: []byte{0xF0, 0x10}, in: regs{p: flagZ}, out: regs{p: flagZ, pc: pcStart + 0x12}, cycles: 3},
+	{name: "BEQ not taken", prog: []byte{0xF0, 0x10}, out: regs{pc: pcStart + 2}, cycles: 2},
+	{name: "BMI taken", prog: []byte{0x30, 0x10}, in: regs{p: flagS}, out: regs{p: flagS, pc: pcStart + 0x12}, cycles: 3},
+	{name: "BMI not taken", prog: []byte{0x30, 0x10}, out: regs{pc: pcStart + 2}, cycles: 2},
+	{name: "BNE taken", prog: []byte{0xD0, 0x10}, out: regs{pc: pcStart + 0x12}, cycles: 3},
+	{name: "BNE not taken", prog: []byte{0xD0, 0x10}, in: regs{p: flagZ}, out: regs{p: flagZ, pc: pcStart + 2}, cycles: 2},
+	{name: "BPL taken", prog: []byte{0x10, 0x10}, out: regs{pc: pcStart + 0x12}, cycles: 3},
+	{name: "BPL not taken", prog: []byte{0x10, 0x10}, in: regs{p: flagS}, out: regs{p: flagS, pc: pcStart + 2}, cycles: 2},
+	{name: "BVC taken", prog: []byte{0x50, 0x10}, out: regs{pc: pcStart + 0x12}, cycles: 3},
+	{name: "BVC not taken", prog: []byte{0x50, 0x10}, in: regs{p: flagV}, out: regs{p: flagV, pc: pcStart + 2}, cycles: 2},
+	{name: "BVS taken", prog: []byte{0x70, 0x10}, in: regs{p: flagV}, out: regs{p: flagV, pc: pcStart + 0x12}, cycles: 3},
+	{name: "BVS not taken", prog: []byte{0x70, 0x10}, out: regs{pc: pcStart + 2}, cycles: 2},
+
+	// BIT
+	{name: "BIT zp", prog: []byte{0x24, 0x10}, in: regs{a: 0x01}, mem: map[uint16]byte{0x10: 0xC0}, out: regs{a: 0x01, p: flagS | flagV | flagZ, pc: pcStart + 2}, cycles: 3},
+	{name: "BIT abs", prog: []byte{0x2C, 0x00, 0x02}, in: regs{a: 0x01, p: flagV}, mem: map[uint16]byte{0x0200: 0x01}, out: regs{a: 0x01, pc: pcStart + 3}, cycles: 4},
+
+	// BRK / RTI
+	{name: "BRK", prog: []byte{0x00, 0xEA}, in: regs{p: flagC}, mem: map[uint16]byte{0xFFFE: 0x00, 0xFFFF: 0x90},
+		out: regs{p: flagC | flagI, sp: sp0 - 3, pc: 0x9000}, want: map[uint16]byte{0x01FD: 0x80, 0x01FC: 0x02, 0x01FB: flagC | flagB | flagU}, cycles: 7},
+	{name: "RTI", prog: []byte{0x40}, in: regs{sp: 0xFA}, mem: map[uint16]byte{0x01FB: flagC | flagB | flagS, 0x01FC: 0x34, 0x01FD: 0x12},
+		out: regs{p: flagC | flagS, sp: 0xFD, pc: 0x1234}, cycles: 6},
+
+	// Flag Instructions
+	{name: "CLC", prog: []byte{0x18}, in: regs{p: flagC | flagZ}, out: regs{p: flagZ, pc: pcStart + 1}, cycles: 2},
+	{name: "CLD", prog: []byte{0xD8}, in: regs{p: flagD}, out: regs{pc: pcStart + 1}, cycles: 2},
+	{name: "CLI", prog: []byte{0x58}, in: regs{p: flagI}, out: regs{pc: pcStart + 1}, cycles: 2},
+	{name: "CLV", prog: []byte{0xB8}, in: regs{p: flagV}, out: regs{pc: pcStart + 1}, cycles: 2},
+	{name: "SEC", prog: []byte{0x38}, out: regs{p: flagC, pc: pcStart + 1}, cycles: 2},
+	{name: "SED", prog: []byte{0xF8}, out: regs{p: flagD, pc: pcStart + 1}, cycles: 2},
+	{name: "SEI", prog: []byte{0x78}, out: regs{p: flagI, pc: pcStart + 1}, cycles: 2},
+
+	// CMP
+	{name: "CMP #imm greater", prog: []byte{0xC9, 0x10}, in: regs{a: 0x20}, out: regs{a: 0x20, p: flagC, pc: pcStart + 2}, cycles: 2},
+	{name: "CMP #imm equal", prog: []byte{0xC9, 0x20}, in: regs{a: 0x20}, out: regs{a: 0x20, p: flagC | flagZ, pc: pcStart + 2}, cycles: 2},
+	{name: "CMP #imm less", prog: []byte{0xC9, 0x21}, in: regs{a: 0x20}, out: regs{a: 0x20, p: flagS, pc: pcStart + 2}, cycles: 2},
+	{name: "CMP zp", prog: []byte{0xC5, 0x10}, in: regs{a: 5}, mem: map[uint16]byte{0x10: 5}, out: regs{a: 5, p: flagC | flagZ, pc: pcStart + 2}, cycles: 3},
+	{name: "CMP zp,X", prog: []byte{0xD5, 0x10}, in: regs{a: 5, x: 1}, mem: map[uint16]byte{0x11: 5}, out: regs{a: 5, x: 1, p: flagC | flagZ, pc: pcStart + 2}, cycles: 4},
+	{name: "CMP abs", prog: []byte{0xCD, 0x00, 0x02}, in: regs{a: 5}, mem: map[uint16]byte{0x0200: 5}, out: regs{a: 5, p: flagC | flagZ, pc: pcStart + 3}, cycles: 4},
+	{name: "CMP abs,X", prog: []byte{0xDD, 0x00, 0x02}, in: regs{a: 5, x: 1}, mem: map[uint16]byte{0x0201: 5}, out: regs{a: 5, x: 1, p: flagC | flagZ, pc: pcStart + 3}, cycles: 4},
+	{name: "CMP abs,Y", prog: []byte{0xD9, 0x00, 0x02}, in: regs{a: 5, y: 1}, mem: map[uint16]byte{0x0201: 5}, out: regs{a: 5, y: 1, p: flagC | flagZ, pc: pcStart + 3}, cycles: 4},
+	{name: "CMP (zp,X)", prog: []byte{0xC1, 0x10}, in: regs{a: 5, x: 1}, mem: map[uint16]byte{0x11: 0x00, 0x12: 0x02, 0x0200: 5}, out: regs{a: 5, x: 1, p: flagC | flagZ, pc: pcStart + 2}, cycles: 6},
+	{name: "CMP (zp),Y", prog: []byte{0xD1, 0x10}, in: regs{a: 5, y: 1}, mem: map[uint16]byte{0x10: 0x00, 0x11: 0x02, 0x0201: 5}, out: regs{a: 5, y: 1, p: flagC | flagZ, pc: pcStart + 2}, cycles: 5},
+
+	// CPX / CPY
+	{name: "CPX #imm", prog: []byte{0xE0, 0x10}, in: regs{x: 0x08}, out: regs{x: 0x08, p: flagS, pc: pcStart + 2}, cycles: 2},
+	{name: "CPX zp", prog: []byte{0xE4, 0x10}, in: regs{x: 5}, mem: map[uint16]byte{0x10: 4}, out: regs{x: 5, p: flagC, pc: pcStart + 2}, cycles: 3},
+	{name: "CPX abs", prog: []byte{0xEC, 0x00, 0x02}, in: regs{x: 5}, mem: map[uint16]byte{0x0200: 5}, out: regs{x: 5, p: flagC | flagZ, pc: pcStart + 3}, cycles: 4},
+	{name: "CPY #imm", prog: []byte{0xC0, 0x10}, in: regs{y: 0x08}, out: regs{y: 0x08, p: flagS, pc: pcStart + 2}, cycles: 2},
+	{name: "CPY zp", prog: []byte{0xC4, 0x10}, in: regs{y: 5}, mem: map[uint16]byte{0x10: 4}, out: regs{y: 5, p: flagC, pc: pcStart + 2}, cycles: 3},
+	{name: "CPY abs", prog: []byte{0xCC, 0x00, 0x02}, in: regs{y: 5}, mem: map[uint16]byte{0x0200: 5}, out: regs{y: 5, p: flagC | flagZ, pc: pcStart + 3}, cycles: 4},
+
+	// DEC / DEX / DEY
+	{name: "DEC zp", prog: []byte{0xC6, 0x10}, mem: map[uint16]byte{0x10: 1}, out: regs{p: flagZ, pc: pcStart + 2}, want: map[uint16]byte{0x10: 0}, cycles: 5},
+	{name: "DEC zp,X", prog: []byte{0xD6, 0x10}, in: regs{x: 1}, mem: map[uint16]byte{0x11: 0}, out: regs{x: 1, p: flagS, pc: pcStart + 2}, want: map[uint16]byte{0x11: 0xFF}, cycles: 6},
+	{name: "DEC abs", prog: []byte{0xCE, 0x00, 0x02}, mem: map[uint16]byte{0x0200: 5}, out: regs{pc: pcStart + 3}, want: map[uint16]byte{0x0200: 4}, cycles: 6},
+	{name: "DEC abs,X", prog: []byte{0xDE, 0x00, 0x02}, in: regs{x: 1}, mem: map[uint16]byte{0x0201: 5}, out: regs{x: 1, pc: pcStart + 3}, want: map[uint16]byte{0x0201: 4}, cycles: 7},
+	{name: "DEX", prog: []byte{0xCA}, in: regs{x: 0}, out: regs{x: 0xFF, p: flagS, pc: pcStart + 1}, cycles: 2},
+	{name: "DEY", prog: []byte{0x88}, in: regs{y: 1}, out: regs{y: 0, p: flagZ, pc: pcStart + 1}, cycles: 2},
+
+	// EOR
+	{name: "EOR #imm", prog: []byte{0x49, 0xFF}, in: regs{a: 0x0F}, out: regs{a: 0xF0, p: flagS, pc: pcStart + 2}, cycles: 2},
+	{name: "EOR zp", prog: []byte{0x45, 0x10}, in: regs{a: 0x0F}, mem: map[uint16]byte{0x10: 0x0F}, out: regs{p: flagZ, pc: pcStart + 2}, cycles: 3},
+	{name: "EOR zp,X", prog: []byte{0x55, 0x10}, in: regs{a: 0x0F, x: 1}, mem: map[uint16]byte{0x11: 0x01}, out: regs{a: 0x0E, x: 1, pc: pcStart + 2}, cycles: 4},
+	{name: "EOR abs", prog: []byte{0x4D, 0x00, 0x02}, in: regs{a: 0x0F}, mem: map[uint16]byte{0x0200: 0x01}, out: regs{a: 0x0E, pc: pcStart + 3}, cycles: 4},
+	{name: "EOR abs,X", prog: []byte{0x5D, 0x00, 0x02}, in: regs{a: 0x0F, x: 1}, mem: map[uint16]byte{0x0201: 0x01}, out: regs{a: 0x0E, x: 1, pc: pcStart + 3}, cycles: 4},
+	{name: "EOR abs,Y", prog: []byte{0x59, 0x00, 0x02}, in: regs{a: 0x0F, y: 1}, mem: map[uint16]byte{0x0201: 0x01}, out: regs{a: 0x0E, y: 1, pc: pcStart + 3}, cycles: 4},
+	{name: "EOR (zp,X)", prog: []byte{0x41, 0x10}, in: regs{a: 0x0F, x: 1}, mem: map[uint16]byte{0x11: 0x00, 0x12: 0x02, 0x0200: 0x01}, out: regs{a: 0x0E, x: 1, pc: pcStart + 2}, cycles: 6},
+	{name: "EOR (zp),Y", prog: []byte{0x51, 0x10}, in: regs{a: 0x0F, y: 1}, mem: map[uint16]byte{0x10: 0x00, 0x11: 0x02, 0x0201: 0x01}, out: regs{a: 0x0E, y: 1, pc: pcStart + 2}, cycles: 5},
+
+	// INC / INX / INY
+	{name: "INC zp", prog: []byte{0xE6, 0x10}, mem: map[uint16]byte{0x10: 0xFF}, out: regs{p: flagZ, pc: pcStart + 2}, want: map[uint16]byte{0x10: 0}, cycles: 5},
+	{name: "INC zp,X", prog: []byte{0xF6, 0x10}, in: regs{x: 1}, mem: map[uint16]byte{0x11: 0x7F}, out: regs{x: 1, p: flagS, pc: pcStart + 2}, want: map[uint16]byte{0x11: 0x80}, cycles: 6},
+	{name: "INC abs", prog: []byte{0xEE, 0x00, 0x02}, mem: map[uint16]byte{0x0200: 5}, out: regs{pc: pcStart + 3}, want: map[uint16]byte{0x0200: 6}, cycles: 6},
+	{name: "INC abs,X", prog: []byte{0xFE, 0x00, 0x02}, in: regs{x: 1}, mem: map[uint16]byte{0x0201: 5}, out: regs{x: 1, pc: pcStart + 3}, want: map[uint16]byte{0x0201: 6}, cycles: 7},
+	{name: "INX", prog: []byte{0xE8}, in: regs{x: 0xFF}, out: regs{x: 0, p: flagZ, pc: pcStart + 1}, cycles: 2},
+	{name: "INY", prog: []byte{0xC8}, in: regs{y: 0x7F}, out: regs{y: 0x80, p: flagS, pc: pcStart + 1}, cycles: 2},
+
+	// JMP / JSR / RTS
+	{name: "JMP abs", prog: []byte{0x4C, 0x34, 0x12}, out: regs{pc: 0x1234}, cycles: 3},
+	{name: "JMP (ind)", prog: []byte{0x6C, 0x00, 0x02}, mem: map[uint16]byte{0x0200: 0x34, 0x0201: 0x12}, out: regs{pc: 0x1234}, cycles: 5},
+	{name: "JSR", prog: []byte{0x20, 0x34, 0x12}, out: regs{sp: sp0 - 2, pc: 0x1234}, want: map[uint16]byte{0x01FD: 0x80, 0x01FC: 0x02}, cycles: 6},
+	{name: "RTS", prog: []byte{0x60}, in: regs{sp: 0xFB}, mem: map[uint16]byte{0x01FC: 0x33, 0x01FD: 0x12}, out: regs{sp: 0xFD, pc: 0x1234}, cycles: 6},
+
+	// LDA
+	{name: "LDA #imm", prog: []byte{0xA9, 0x42}, out: regs{a: 0x42, pc: pcStart + 2}, cycles: 2},
+	{name: "LDA #imm zero", prog: []byte{0xA9, 0x00}, in: regs{a: 1}, out: regs{p: flagZ, pc: pcStart + 2}, cycles: 2},
+	{name: "LDA #imm negative", prog: []byte{0xA9, 0x80}, out: regs{a: 0x80, p: flagS, pc: pcStart + 2}, cycles: 2},
+	{name: "LDA zp", prog: []byte{0xA5, 0x10}, mem: map[uint16]byte{0x10: 0x42}, out: regs{a: 0x42, pc: pcStart + 2}, cycles: 3},
+	{name: "LDA zp,X wraps", prog: []byte{0xB5, 0xFF}, in: regs{x: 2}, mem: map[uint16]byte{0x01: 0x42}, out: regs{a: 0x42, x: 2, pc: pcStart + 2}, cycles: 4},
+	{name: "LDA abs", prog: []byte{0xAD, 0x00, 0x02}, mem: map[uint16]byte{0x0200: 0x42}, out: regs{a: 0x42, pc: pcStart + 3}, cycles: 4},
+	{name: "LDA abs,X", prog: []byte{0xBD, 0x00, 0x02}, in: regs{x: 1}, mem: map[uint16]byte{0x0201: 0x42}, out: regs{a: 0x42, x: 1, pc: pcStart + 3}, cycles: 4},
+	{name: "LDA abs,Y", prog: []byte{0xB9, 0x00, 0x02}, in: regs{y: 1}, mem: map[uint16]byte{0x0201: 0x42}, out: regs{a: 0x42, y: 1, pc: pcStart + 3}, cycles: 4},
+	{name: "LDA (zp,X)", prog: []byte{0xA1, 0x10}, in: regs{x: 1}, mem: map[uint16]byte{0x11: 0x00, 0x12: 0x02, 0x0200: 0x42}, out: regs{a: 0x42, x: 1, pc: pcStart + 2}, cycles: 6},
+	{name: "LDA (zp),Y", prog: []byte{0xB1, 0x10}, in: regs{y: 1}, mem: map[uint16]byte{0x10: 0x00, 0x11: 0x02, 0x0201: 0x42}, out: regs{a: 0x42, y: 1, pc: pcStart + 2}, cycles: 5},
+
+	// LDX / LDY
+	{name: "LDX #imm", prog: []byte{0xA2, 0x42}, out: regs{x: 0x42, pc: pcStart + 2}, cycles: 2},
+	{name: "LDX zp", prog: []byte{0xA6, 0x10}, mem: map[uint16]byte{0x10: 0x80}, out: regs{x: 0x80, p: flagS, pc: pcStart + 2}, cycles: 3},
+	{name: "LDX zp,Y", prog: []byte{0xB6, 0x10}, in: regs{y: 1}, mem: map[uint16]byte{0x11: 0x42}, out: regs{x: 0x42, y: 1, pc: pcStart + 2}, cycles: 4},
+	{name: "LDX abs", prog: []byte{0xAE, 0x00, 0x02}, mem: map[uint16]byte{0x0200: 0x42}, out: regs{x: 0x42, pc: pcStart + 3}, cycles: 4},
+	{name: "LDX abs,Y", prog: []byte{0xBE, 0x00, 0x02}, in: regs{y: 1}, mem: map[uint16]byte{0x0201: 0x42}, out: regs{x: 0x42, y: 1, pc: pcStart + 3}, cycles: 4},
+	{name: "LDY #imm", prog: []byte{0xA0, 0x00}, in: regs{y: 5}, out: regs{p: flagZ, pc: pcStart + 2}, cycles: 2},
+	{name: "LDY zp", prog: []byte{0xA4, 0x10}, mem: map[uint16]byte{0x10: 0x42}, out: regs{y: 0x42, pc: pcStart + 2}, cycles: 3},
+	{name: "LDY zp,X", prog: []byte{0xB4, 0x10}, in: regs{x: 1}, mem: map[uint16]byte{0x11: 0x42}, out: regs{x: 1, y: 0x42, pc: pcStart + 2}, cycles: 4},
+	{name: "LDY abs", prog: []byte{0xAC, 0x00, 0x02}, mem: map[uint16]byte{0x0200: 0x42}, out: regs{y: 0x42, pc: pcStart + 3}, cycles: 4},
+	{name: "LDY abs,X", prog: []byte{0xBC, 0x00, 0x02}, in: regs{x: 1}, mem: map[uint16]byte{0x0201: 0x42}, out: regs{x: 1, y: 0x42, pc: pcStart + 3}, cycles: 4},
+
+	// LSR
+	{name: "LSR A", prog: []byte{0x4A}, in: regs{a: 0x01, p: flagS}, out: regs{a: 0x00, p: flagC | flagZ, pc: pcStart + 1}, cycles: 2},
+	{name: "LSR zp", prog: []byte{0x46, 0x10}, mem: map[uint16]byte{0x10: 0x80}, out: regs{pc: pcStart + 2}, want: map[uint16]byte{0x10: 0x40}, cycles: 5},
+	{name: "LSR zp,X", prog: []byte{0x56, 0x10}, in: regs{x: 1}, mem: map[uint16]byte{0x11: 0x03}, out: regs{x: 1, p: flagC, pc: pcStart + 2}, want: map[uint16]byte{0x11: 0x01}, cycles: 6},
+	{name: "LSR abs", prog: []byte{0x4E, 0x00, 0x02}, mem: map[uint16]byte{0x0200: 0x02}, out: regs{pc: pcStart + 3}, want: map[uint16]byte{0x0200: 0x01}, cycles: 6},
+	{name: "LSR abs,X", prog: []byte{0x5E, 0x00, 0x02}, in: regs{x: 1}, mem: map[uint16]byte{0x0201: 0x02}, out: regs{x: 1, pc: pcStart + 3}, want: map[uint16]byte{0x0201: 0x01}, cycles: 7},
+
+	// NOP
+	{name: "NOP", prog: []byte{0xEA}, in: regs{a: 1, p: flagC}, out: regs{a: 1, p: flagC, pc: pcStart + 1}, cycles: 2},
+
+	// ORA
+	{name: "ORA #imm", prog: []byte{0x09, 0x80}, in: regs{a: 0x01}, out: regs{a: 0x81, p: flagS, pc: pcStart + 2}, cycles: 2},
+	{name: "ORA zp", prog: []byte{0x05, 0x10}, mem: map[uint16]byte{0x10: 0x00}, out: regs{p: flagZ, pc: pcStart + 2}, cycles: 3},
+	{name: "ORA zp,X", prog: []byte{0x15, 0x10}, in: regs{a: 0x01, x: 1}, mem: map[uint16]byte{0x11: 0x02}, out: regs{a: 0x03, x: 1, pc: pcStart + 2}, cycles: 4},
+	{name: "ORA abs", prog: []byte{0x0D, 0x00, 0x02}, in: regs{a: 0x01}, mem: map[uint16]byte{0x0200: 0x02}, out: regs{a: 0x03, pc: pcStart + 3}, cycles: 4},
+	{name: "ORA abs,X", prog: []byte{0x1D, 0x00, 0x02}, in: regs{a: 0x01, x: 1}, mem: map[uint16]byte{0x0201: 0x02}, out: regs{a: 0x03, x: 1, pc: pcStart + 3}, cycles: 4},
+	{name: "ORA abs,Y", prog: []byte{0x19, 0x00, 0x02}, in: regs{a: 0x01, y: 1}, mem: map[uint16]byte{0x0201: 0x02}, out: regs{a: 0x03, y: 1, pc: pcStart + 3}, cycles: 4},
+	{name: "ORA (zp,X)", prog: []byte{0x01, 0x10}, in: regs{a: 0x01, x: 1}, mem: map[uint16]byte{0x11: 0x00, 0x12: 0x02, 0x0200: 0x02}, out: regs{a: 0x03, x: 1, pc: pcStart + 2}, cycles: 6},
+	{name: "ORA (zp),Y", prog: []byte{0x11, 0x10}, in: regs{a: 0x01, y: 1}, mem: map[uint16]byte{0x10: 0x00, 0x11: 0x02, 0x0201: 0x02}, out: regs{a: 0x03, y: 1, pc: pcStart + 2}, cycles: 5},
+
+	// Stack
+	{name: "PHA", prog: []byte{0x48}, in: regs{a: 0x42}, out: regs{a: 0x42, sp: sp0 - 1, pc: pcStart + 1}, want: map[uint16]byte{0x01FD: 0x42}, cycles: 3},
+	{name: "PHP", prog: []byte{0x08}, in: regs{p: flagC | flagS}, out: regs{p: flagC | flagS, sp: sp0 - 1, pc: pcStart + 1}, want: map[uint16]byte{0x01FD: flagC | flagS | flagB | flagU}, cycles: 3},
+	{name: "PLA", prog: []byte{0x68}, in: regs{sp: 0xFC}, mem: map[uint16]byte{0x01FD: 0x80}, out: regs{a: 0x80, p: flagS, sp: 0xFD, pc: pcStart + 1}, cycles: 4},
+	{name: "PLP", prog: []byte{0x28}, in: regs{sp: 0xFC}, mem: map[uint16]byte{0x01FD: 0xFF}, out: regs{p: 0xFF &^ flagB, sp: 0xFD, pc: pcStart + 1}, cycles: 4},
+
+	// ROL / ROR
+	{name: "ROL A", prog: []byte{0x2A}, in: regs{a: 0x80, p: flagC}, out: regs{a: 0x01, p: flagC, pc: pcStart + 1}, cycles: 2},
+	{name: "ROL zp", prog: []byte{0x26, 0x10}, mem: map[uint16]byte{0x10: 0x40}, out: regs{p: flagS, pc: pcStart + 2}, want: map[uint16]byte{0x10: 0x80}, cycles: 5},
+	{name: "ROL zp,X", prog: []byte{0x36, 0x10}, in: regs{x: 1}, mem: map[uint16]byte{0x11: 0x80}, out: regs{x: 1, p: flagC | flagZ, pc: pcStart + 2}, want: map[uint16]byte{0x11: 0x00}, cycles: 6},
+	{name: "ROL abs", prog: []byte{0x2E, 0x00, 0x02}, in: regs{p: flagC}, mem: map[uint16]byte{0x0200: 0x01}, out: regs{pc: pcStart + 3}, want: map[uint16]byte{0x0200: 0x03}, cycles: 6},
+	{name: "ROL abs,X", prog: []byte{0x3E, 0x00, 0x02}, in: regs{x: 1}, mem: map[uint16]byte{0x0201: 0x01}, out: regs{x: 1, pc: pcStart + 3}, want: map[uint16]byte{0x0201: 0x02}, cycles: 7},
+	{name: "ROR A", prog: []byte{0x6A}, in: regs{a: 0x01, p: flagC}, out: regs{a: 0x80, p: flagC | flagS, pc: pcStart + 1}, cycles: 2},
+	{name: "ROR zp", prog: []byte{0x66, 0x10}, mem: map[uint16]byte{0x10: 0x01}, out: regs{p: flagC | flagZ, pc: pcStart + 2}, want: map[uint16]byte{0x10: 0x00}, cycles: 5},
+	{name: "ROR zp,X", prog: []byte{0x76, 0x10}, in: regs{x: 1}, mem: map[uint16]byte{0x11: 0x02}, out: regs{x: 1, pc: pcStart + 2}, want: map[uint16]byte{0x11: 0x01}, cycles: 6},
+	{name: "ROR abs", prog: []byte{0x6E, 0x00, 0x02}, mem: map[uint16]byte{0x0200: 0x02}, out: regs{pc: pcStart + 3}, want: map[uint16]byte{0x0200: 0x01}, cycles: 6},
+	{name: "ROR abs,X", prog: []byte{0x7E, 0x00, 0x02}, in: regs{x: 1, p: flagC}, mem: map[uint16]byte{0x0201: 0x02}, out: regs{x: 1, p: flagS, pc: pcStart + 3}, want: map[uint16]byte{0x0201: 0x81}, cycles: 7},
+
+	// SBC
+	{name: "SBC #imm", prog: []byte{0xE9, 0x10}, in: regs{a: 0x30, p: flagC}, out: regs{a: 0x20, p: flagC, pc: pcStart + 2}, cycles: 2},
+	{name: "SBC #imm borrow in", prog: []byte{0xE9, 0x10}, in: regs{a: 0x30}, out: regs{a: 0x1F, p: flagC, pc: pcStart + 2}, cycles: 2},
+	{name: "SBC #imm borrow out", prog: []byte{0xE9, 0x01}, in: regs{a: 0x00, p: flagC}, out: regs{a: 0xFF, p: flagS, pc: pcStart + 2}, cycles: 2},
+	{name: "SBC #imm overflow", prog: []byte{0xE9, 0x01}, in: regs{a: 0x80, p: flagC}, out: regs{a: 0x7F, p: flagC | flagV, pc: pcStart + 2}, cycles: 2},
+	{name: "SBC zp", prog: []byte{0xE5, 0x10}, in: regs{a: 5, p: flagC}, mem: map[uint16]byte{0x10: 5}, out: regs{p: flagC | flagZ, pc: pcStart + 2}, cycles: 3},
+	{name: "SBC zp,X", prog: []byte{0xF5, 0x10}, in: regs{a: 5, x: 1, p: flagC}, mem: map[uint16]byte{0x11: 2}, out: regs{a: 3, x: 1, p: flagC, pc: pcStart + 2}, cycles: 4},
+	{name: "SBC abs", prog: []byte{0xED, 0x00, 0x02}, in: regs{a: 5, p: flagC}, mem: map[uint16]byte{0x0200: 2}, out: regs{a: 3, p: flagC, pc: pcStart + 3}, cycles: 4},
+	{name: "SBC abs,X", prog: []byte{0xFD, 0x00, 0x02}, in: regs{a: 5, x: 1, p: flagC}, mem: map[uint16]byte{0x0201: 2}, out: regs{a: 3, x: 1, p: flagC, pc: pcStart + 3}, cycles: 4},
+	{name: "SBC abs,Y", prog: []byte{0xF9, 0x00, 0x02}, in: regs{a: 5, y: 1, p: flagC}, mem: map[uint16]byte{0x0201: 2}, out: regs{a: 3, y: 1, p: flagC, pc: pcStart + 3}, cycles: 4},
+	{name: "SBC (zp,X)", prog: []byte{0xE1, 0x10}, in: regs{a: 5, x: 1, p: flagC}, mem: map[uint16]byte{0x11: 0x00, 0x12: 0x02, 0x0200: 2}, out: regs{a: 3, x: 1, p: flagC, pc: pcStart + 2}, cycles: 6},
+	{name: "SBC (zp),Y", prog: []byte{0xF1, 0x10}, in: regs{a: 5, y: 1, p: flagC}, mem: map[uint16]byte{0x10: 0x00, 0x11: 0x02, 0x0201: 2}, out: regs{a: 3, y: 1, p: flagC, pc: pcStart + 2}, cycles: 5},
+
+	// STA / STX / STY
+	{name: "STA zp", prog: []byte{0x85, 0x10}, in: regs{a: 0x42}, out: regs{a: 0x42, pc: pcStart + 2}, want: map[uint16]byte{0x10: 0x42}, cycles: 3},
+	{name: "STA zp,X", prog: []byte{0x95, 0x10}, in: regs{a: 0x42, x: 1}, out: regs{a: 0x42, x: 1, pc: pcStart + 2}, want: map[uint16]byte{0x11: 0x42}, cycles: 4},
+	{name: "STA abs", prog: []byte{0x8D, 0x00, 0x02}, in: regs{a: 0x42}, out: regs{a: 0x42, pc: pcStart + 3}, want: map[uint16]byte{0x0200: 0x42}, cycles: 4},
+	{name: "STA abs,X", prog: []byte{0x9D, 0x00, 0x02}, in: regs{a: 0x42, x: 1}, out: regs{a: 0x42, x: 1, pc: pcStart + 3}, want: map[uint16]byte{0x0201: 0x42}, cycles: 5},
+	{name: "STA abs,Y", prog: []byte{0x99, 0x00, 0x02}, in: regs{a: 0x42, y: 1}, out: regs{a: 0x42, y: 1, pc: pcStart + 3}, want: map[uint16]byte{0x0201: 0x42}, cycles: 5},
+	{name: "STA (zp,X)", prog: []byte{0x81, 0x10}, in: regs{a: 0x42, x: 1}, mem: map[uint16]byte{0x11: 0x00, 0x12: 0x02}, out: regs{a: 0x42, x: 1, pc: pcStart + 2}, want: map[uint16]byte{0x0200: 0x42}, cycles: 6},
+	{name: "STA (zp),Y", prog: []byte{0x91, 0x10}, in: regs{a: 0x42, y: 1}, mem: map[uint16]byte{0x10: 0x00, 0x11: 0x02}, out: regs{a: 0x42, y: 1, pc: pcStart + 2}, want: map[uint16]byte{0x0201: 0x42}, cycles: 6},
+	{name: "STX zp", prog: []byte{0x86, 0x10}, in: regs{x: 0x42}, out: regs{x: 0x42, pc: pcStart + 2}, want: map[uint16]byte{0x10: 0x42}, cycles: 3},
+	{name: "STX zp,Y", prog: []byte{0x96, 0x10}, in: regs{x: 0x42, y: 1}, out: regs{x: 0x42, y: 1, pc: pcStart + 2}, want: map[uint16]byte{0x11: 0x42}, cycles: 4},
+	{name: "STX abs", prog: []byte{0x8E, 0x00, 0x02}, in: regs{x: 0x42}, out: regs{x: 0x42, pc: pcStart + 3}, want: map[uint16]byte{0x0200: 0x42}, cycles: 4},
+	{name: "STY zp", prog: []byte{0x84, 0x10}, in: regs{y: 0x42}, out: regs{y: 0x42, pc: pcStart + 2}, want: map[uint16]byte{0x10: 0x42}, cycles: 3},
+	{name: "STY zp,X", prog: []byte{0x94, 0x10}, in: regs{x: 1, y: 0x42}, out: regs{x: 1, y: 0x42, pc: pcStart + 2}, want: map[uint16]byte{0x11: 0x42}, cycles: 4},
+	{name: "STY abs", prog: []byte{0x8C, 0x00, 0x02}, in: regs{y: 0x42}, out: regs{y: 0x42, pc: pcStart + 3}, want: map[uint16]byte{0x0200: 0x42}, cycles: 4},
+
+	// Transfers
+	{name: "TAX", prog: []byte{0xAA}, in: regs{a: 0x80}, out: regs{a: 0x80, x: 0x80, p: flagS, pc: pcStart + 1}, cycles: 2},
+	{name: "TAY", prog: []byte{0xA8}, in: regs{a: 0x00, y: 1}, out: regs{p: flagZ, pc: pcStart + 1}, cycles: 2},
+	{name: "TSX", prog: []byte{0xBA}, in: regs{sp: 0x80}, out: regs{x: 0x80, sp: 0x80, p: flagS, pc: pcStart + 1}, cycles: 2},
+	{name: "TXA", prog: []byte{0x8A}, in: regs{x: 0x42}, out: regs{a: 0x42, x: 0x42, pc: pcStart + 1}, cycles: 2},
+	{name: "TXS", prog: []byte{0x9A}, in: regs{x: 0x80}, out: regs{x: 0x80, sp: 0x80, pc: pcStart + 1}, cycles: 2},
+	{name: "TYA", prog: []byte{0x98}, in: regs{y: 0x42}, out: regs{a: 0x42, y: 0x42, pc: pcStart + 1}, cycles: 2},
+}
+
+// newTestSystem System Over a Flat 64KiB of RAM, with prog at loadAddr
+func newTestSystem(prog []byte) *system {
+	m := make(ram, 0x10000)
+	copy(m[loadAddr:], prog)
+	return newBusSystem(m)
+}
+
+// run Execute a Single Instruction from c and Check the Result
+func (c instCase) run(t *testing.T) {
+	s := newTestSystem(c.prog)
+	s.cpu.ac, s.cpu.xr, s.cpu.yr = c.in.a, c.in.x, c.in.y
+	s.cpu.sr.set(c.in.p)
+	if c.in.sp != 0 {
+		s.cpu.sp = c.in.sp
+	}
+	for addr, v := range c.mem {
+		s.write(addr, v)
+	}
+
+	s.exec()
+
+	want := c.out
+	want.p |= flagU
+	if c.out.sp == 0 {
+		want.sp = sp0
+	}
+	got := regs{a: s.cpu.ac, x: s.cpu.xr, y: s.cpu.yr, p: s.cpu.sr.pack(), sp: s.cpu.sp, pc: s.cpu.pc}
+	if got != want {
+		t.Errorf("Expected registers %+v, got: %+v", want, got)
+	}
+	if s.cpu.clk != c.cycles {
+		t.Errorf("Expected %d cycles, got: %d", c.cycles, s.cpu.clk)
+	}
+	for addr, v := range c.want {
+		if m := s.read(addr); m != v {
+			t.Errorf("Expected $%04X = $%02X, got: $%02X", addr, v, m)
+		}
+	}
+}
+
+func Test_Instructions_ShouldMatchReference(t *testing.T) {
+	for _, c := range instCases {
+		t.Run(fmt.Sprintf("%02X %s", c.prog[0], c.name), c.run)
+	}
+}
+
+func Test_Instructions_ShouldCoverEveryDocumentedOpcode(t *testing.T) {
+	covered := make(map[byte]bool)
+	for _, c := range instCases {
+		covered[c.prog[0]] = true
+	}
+	count := 0
+	for op, in := range instructionSet {
+		if !documented(op) {
+			continue
+		}
+		count++
+		if !covered[op] {
+			t.Errorf("No test case for opcode $%02X (%s)", op, in.name)
+		}
+	}
+	if count != 151 {
+		t.Errorf("Expected 151 documented opcodes, got: %d", count)
+	}
+	if len(instructionSet) != 256 {
+		t.Errorf("Expected all 256 opcodes in the table, got: %d", len(instructionSet))
+	}
+}
+
+func Test_Instructions_ShouldAddInDecimalMode(t *testing.T) {
+	for _, c := range []struct {
+		a, m, carry, want byte
+		wantCarry         bool
+	}{
+		{0x15, 0x27, 0, 0x42, false},
+		{0x09, 0x01, 0, 0x10, false},
+		{0x99, 0x01, 0, 0x00, true},
+		{0x58, 0x46, 1, 0x05, true},
+	} {
+		s := newTestSystem([]byte{0x69, c.m})
+		s.cpu.decimal = true
+		s.cpu.ac = c.a
+		s.cpu.sr = status{d: true, c: c.carry != 0}
+		s.exec()
+		if s.cpu.ac != c.want || s.cpu.sr.c != c.wantCarry {
+			t.Errorf("Expected $%02X + $%02X + %d = $%02X (carry %v), got: $%02X (carry %v)",
+				c.a, c.m, c.carry, c.want, c.wantCarry, s.cpu.ac, s.cpu.sr.c)
+		}
+	}
+}
+
+func Test_Instructions_ShouldSubtractInDecimalMode(t *testing.T) {
+	for _, c := range []struct {
+		a, m, carry, want byte
+		wantCarry         bool
+	}{
+		{0x42, 0x15, 1, 0x27, true},
+		{0x10, 0x01, 1, 0x09, true},
+		{0x00, 0x01, 1, 0x99, false},
+		{0x46, 0x12, 0, 0x33, true},
+	} {
+		s := newTestSystem([]byte{0xE9, c.m})
+		s.cpu.decimal = true
+		s.cpu.ac = c.a
+		s.cpu.sr = status{d: true, c: c.carry != 0}
+		s.exec()
+		if s.cpu.ac != c.want || s.cpu.sr.c != c.wantCarry {
+			t.Errorf("Expected $%02X - $%02X - %d = $%02X (carry %v), got: $%02X (carry %v)",
+				c.a, c.m, 1-c.carry, c.want, c.wantCarry, s.cpu.ac, s.cpu.sr.c)
+		}
+	}
+}
+
+func Test_Instructions_ShouldIgnoreDecimalFlagByDefault(t *testing.T) {
+	s := newTestSystem([]byte{0x69, 0x01})
+	s.cpu.ac = 0x09
+	s.cpu.sr.d = true
+	s.exec()
+	if s.cpu.ac != 0x0A {
+		t.Errorf("Expected binary result $0A, got: $%02X", s.cpu.ac)
+	}
+}
+
+func Test_Instructions_ShouldReturnFromSubroutine(t *testing.T) {
+	// JSR $8010; LDX #$01; ... $8010: LDA #$42; RTS
+	prog := make([]byte, 0x20)
+	copy(prog, []byte{0x20, 0x10, 0x80, 0xA2, 0x01})
+	copy(prog[0x10:], []byte{0xA9, 0x42, 0x60})
+	s := newTestSystem(prog)
+	for i := 0; i < 4; i++ {
+		s.exec()
+	}
+	if s.cpu.ac != 0x42 || s.cpu.xr != 0x01 || s.cpu.sp != sp0 || s.cpu.pc != pcStart+5 {
+		t.Errorf("Expected A=$42 X=$01 SP=$FD PC=$8005, got: A=$%02X X=$%02X SP=$%02X PC=$%04X",
+			s.cpu.ac, s.cpu.xr, s.cpu.sp, s.cpu.pc)
+	}
+	if s.cpu.clk != 6+2+6+2 {
+		t.Errorf("Expected 16 cycles, got: %d", s.cpu.clk)
+	}
+}
+
+func Test_Instructions_ShouldWrapStackWithinPageOne(t *testing.T) {
+	// PHA; PLA
+	s := newTestSystem([]byte{0x48, 0x68})
+	s.cpu.ac, s.cpu.sp = 0x42, 0x00
+	s.exec()
+	if s.cpu.sp != 0xFF || s.read(0x0100) != 0x42 {
+		t.Errorf("Expected push to $0100 and SP=$FF, got: SP=$%02X $0100=$%02X", s.cpu.sp, s.read(0x0100))
+	}
+	s.cpu.ac = 0
+	s.exec()
+	if s.cpu.sp != 0x00 || s.cpu.ac != 0x42 {
+		t.Errorf("Expected pull from $0100 and SP=$00, got: SP=$%02X A=$%02X", s.cpu.sp, s.cpu.ac)
+	}
+}
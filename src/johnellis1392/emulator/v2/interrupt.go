@@ -0,0 +1,110 @@
+package main
+
+// Interrupts
+//
+// RESET, NMI and IRQ Share the BRK Sequence: Push PC (High Byte First),
+// Push P with B Clear, Set I, then Load PC from the Vector. Hardware
+// Interrupts Take 7 Cycles.
+//
+// The CPU Polls its Interrupt Lines Before the Last Cycle of Each
+// Instruction, and Services What it Saw Once the Instruction Finishes, so a
+// Line Raised on an Instruction's Final Cycle Waits for the Next One. NMI is
+// Edge Triggered and Latched; IRQ is Level Triggered, Held by Any Source
+// and Ignored While I is Set. CLI, SEI and PLP Change I After the Poll, so
+// an IRQ Pending at CLI is Taken One Instruction Later, and One Pending at
+// SEI is Still Taken.
+
+const (
+	nmiVector   = 0xFFFA
+	resetVector = 0xFFFC
+	irqVector   = 0xFFFE // Shared with BRK
+)
+
+// interruptCycles Length of the Hardware Interrupt and RESET Sequences
+const interruptCycles = 7
+
+// clocked Device Stepped Once per CPU Cycle, e.g. to Raise an Interrupt at
+// an Exact Clock Count
+type clocked interface {
+	clock()
+}
+
+// tick Advance One CPU Cycle, Clocking Every Device. The Interrupt Poll
+// Happens at the Start of an Instruction's Last Cycle.
+func (s *system) tick() {
+	if s.cpu.clk == s.cpu.pollAt {
+		masked := s.cpu.sr.i
+		if s.cpu.pollHoldI {
+			masked = s.cpu.pollI
+		}
+		s.poll(masked)
+	}
+	s.cpu.tick()
+	for _, d := range s.devices {
+		d.clock()
+	}
+}
+
+// reset Run the RESET Sequence. It's an Interrupt with Writes Suppressed,
+// so SP Still Drops by 3 (from $00 to $FD at Power-On).
+func (s *system) reset() {
+	s.cpu.sp -= 3
+	s.cpu.sr.i = true
+	s.cpu.nmi, s.cpu.takeNMI, s.cpu.takeIRQ = false, false, false
+	for i := 0; i < interruptCycles-2; i++ {
+		s.tick()
+	}
+	s.cpu.pc = s.busRead16(resetVector)
+}
+
+// setNMI Drive the NMI Line; Raising it Latches an NMI
+func (s *system) setNMI(level bool) {
+	if level && !s.cpu.nmiLine {
+		s.cpu.nmi = true
+	}
+	s.cpu.nmiLine = level
+}
+
+// setIRQ Assert or Release the IRQ Line for the Sources in mask
+func (s *system) setIRQ(mask byte, level bool) {
+	if level {
+		s.cpu.irq |= mask
+	} else {
+		s.cpu.irq &^= mask
+	}
+}
+
+// poll Sample the Interrupt Lines; masked is the I Flag as the Poll Sees it
+func (s *system) poll(masked bool) {
+	s.cpu.takeNMI = s.cpu.nmi
+	s.cpu.takeIRQ = s.cpu.irq != 0 && !masked
+}
+
+// service Run the Interrupt Sequence for Whatever the Last Poll Saw
+func (s *system) service() {
+	var vector uint16
+	switch {
+	case s.cpu.takeNMI:
+		s.cpu.nmi = false
+		vector = nmiVector
+	case s.cpu.takeIRQ:
+		vector = irqVector
+	default:
+		return
+	}
+	s.cpu.takeNMI, s.cpu.takeIRQ = false, false
+	s.tick() // Two Reads of the Next Opcode, Discarded
+	s.tick()
+	s.interrupt(vector, false)
+}
+
+// interrupt Push the Return Address and Status, Set I and Jump Through
+// vector. brk Sets B in the Pushed Status, Telling BRK from IRQ.
+func (s *system) interrupt(vector uint16, brk bool) {
+	s.push16(s.cpu.pc)
+	p := s.cpu.sr
+	p.b = brk
+	s.push(p.pack())
+	s.cpu.sr.i = true
+	s.cpu.pc = s.busRead16(vector)
+}
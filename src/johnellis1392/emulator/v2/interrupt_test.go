@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const handler = 0x9000
+
+// newInterruptSystem Test System with Every Vector Pointing at an RTI at
+// handler
+func newInterruptSystem(prog []byte) *system {
+	s := newTestSystem(prog)
+	for _, v := range []uint16{nmiVector, resetVector, irqVector} {
+		s.write(v, handler&0xFF)
+		s.write(v+1, handler>>8)
+	}
+	s.write(handler, 0x40) // RTI
+	return s
+}
+
+// stacked Return Address and Status Pushed by the Last Interrupt
+func stacked(s *system) (uint16, byte) {
+	return s.read16(stackPage | uint16(s.cpu.sp+2)), s.read(stackPage | uint16(s.cpu.sp+1))
+}
+
+// lineAt Raises NMI or IRQ During the Cycle that Brings the Clock to clk
+type lineAt struct {
+	s   *system
+	clk uint64
+	nmi bool
+}
+
+func (d *lineAt) clock() {
+	if d.s.cpu.clk != d.clk {
+		return
+	}
+	if d.nmi {
+		d.s.setNMI(true)
+	} else {
+		d.s.setIRQ(0x01, true)
+	}
+}
+
+func Test_System_Reset_ShouldLoadResetVector(t *testing.T) {
+	s := newInterruptSystem([]byte{0xEA})
+	s.cpu.sp, s.cpu.sr.i = 0, false
+	s.reset()
+	if s.cpu.pc != handler || s.cpu.sp != 0xFD || !s.cpu.sr.i || s.cpu.clk != 7 {
+		t.Errorf("Expected PC=$%04X SP=$FD I Set After 7 Cycles, got: %s", handler, newDebugger(s, nil).regs())
+	}
+}
+
+func Test_System_NMI_ShouldPushStateAndReturn(t *testing.T) {
+	s := newInterruptSystem([]byte{0xEA, 0xEA})
+	s.cpu.sr.c = true
+	s.setNMI(true)
+	s.exec()
+	ret, p := stacked(s)
+	if s.cpu.pc != handler || ret != 0x8001 || p != flagU|flagI|flagC || s.cpu.clk != 2+7 {
+		t.Errorf("Expected NMI After NOP, got: PC=$%04X Return=$%04X P=$%02X CYC=%d", s.cpu.pc, ret, p, s.cpu.clk)
+	}
+	s.exec()
+	if s.cpu.pc != 0x8001 || s.cpu.sp != sp0 || s.cpu.clk != 2+7+6 {
+		t.Errorf("Expected RTI Back to $8001, got: PC=$%04X SP=$%02X CYC=%d", s.cpu.pc, s.cpu.sp, s.cpu.clk)
+	}
+
+	// Edge Triggered: Holding the Line Doesn't Fire Again
+	s.exec()
+	if s.cpu.pc != 0x8002 {
+		t.Errorf("Expected No Second NMI While the Line Stays High, got: PC=$%04X", s.cpu.pc)
+	}
+}
+
+func Test_System_IRQ_ShouldWaitOneInstructionAfterCLI(t *testing.T) {
+	s := newInterruptSystem([]byte{0x58, 0xEA, 0xEA}) // CLI; NOP; NOP
+	s.setIRQ(0x01, true)
+	s.exec()
+	if s.cpu.pc != 0x8001 {
+		t.Fatalf("Expected IRQ Held Off Through CLI, got: PC=$%04X", s.cpu.pc)
+	}
+	s.exec()
+	if ret, p := stacked(s); s.cpu.pc != handler || ret != 0x8002 || p&flagB != 0 {
+		t.Errorf("Expected IRQ After the NOP with B Clear, got: PC=$%04X Return=$%04X P=$%02X", s.cpu.pc, ret, p)
+	}
+}
+
+func Test_System_IRQ_ShouldStillFireAfterSEI(t *testing.T) {
+	s := newInterruptSystem([]byte{0x78, 0xEA}) // SEI; NOP
+	s.cpu.sr.i = false
+	s.setIRQ(0x01, true)
+	s.exec()
+	// SEI has Already Run, so the Pushed Status has I Set
+	if ret, p := stacked(s); s.cpu.pc != handler || ret != 0x8001 || p&flagI == 0 {
+		t.Errorf("Expected IRQ After SEI, got: PC=$%04X Return=$%04X P=$%02X", s.cpu.pc, ret, p)
+	}
+}
+
+func Test_System_IRQ_ShouldHoldWhileAnySourceAsserts(t *testing.T) {
+	s := newInterruptSystem([]byte{0xEA, 0xEA})
+	s.cpu.sr.i = false
+	s.setIRQ(0x01, true)
+	s.setIRQ(0x02, true)
+	s.setIRQ(0x01, false)
+	s.setIRQ(0x02, false)
+	s.exec()
+	if s.cpu.pc != 0x8001 {
+		t.Fatalf("Expected No IRQ Once Released, got: PC=$%04X", s.cpu.pc)
+	}
+	s.setIRQ(0x01, true)
+	s.setIRQ(0x02, true)
+	s.setIRQ(0x02, false)
+	s.exec()
+	if s.cpu.pc != handler {
+		t.Errorf("Expected IRQ While Source 1 Holds the Line, got: PC=$%04X", s.cpu.pc)
+	}
+}
+
+func Test_System_ShouldPollBeforeTheLastCycle(t *testing.T) {
+	cases := []struct {
+		name string
+		nmi  bool
+		clk  uint64 // Cycle the Line Rises on; NOP Runs in Cycles 1-2
+		ret  uint16
+	}{
+		{"NMI on Penultimate Cycle", true, 1, 0x8001},
+		{"NMI on Last Cycle", true, 2, 0x8002},
+		{"IRQ on Penultimate Cycle", false, 1, 0x8001},
+		{"IRQ on Last Cycle", false, 2, 0x8002},
+	}
+	for _, c := range cases {
+		s := newInterruptSystem([]byte{0xEA, 0xEA, 0xEA})
+		s.cpu.sr.i = false
+		s.devices = append(s.devices, &lineAt{s, c.clk, c.nmi})
+		for s.cpu.pc != handler && s.cpu.clk < 10 {
+			s.exec()
+		}
+		if ret, _ := stacked(s); s.cpu.pc != handler || ret != c.ret {
+			t.Errorf("%s: expected interrupt returning to $%04X, got: PC=$%04X Return=$%04X", c.name, c.ret, s.cpu.pc, ret)
+		}
+	}
+}
+
+func Test_System_ShouldClockEachBusAccessAsItHappens(t *testing.T) {
+	m := make(ram, 0x10000)
+	copy(m[loadAddr:], []byte{
+		0xA2, 0x01, // LDX #$01: Cycles 1-2
+		0xAD, 0x01, 0x20, // LDA $2001: Cycles 3-6
+		0xBD, 0xFF, 0x20, // LDA $20FF,X: Cycles 7-11, Crossing
+		0xEE, 0x02, 0x20, // INC $2002: Cycles 12-17
+		0x9D, 0x02, 0x20, // STA $2002,X: Cycles 18-22
+	})
+	var log []string
+	bus := newAddressSpace()
+	bus.mapRange(0x0000, 0xFFFF, m)
+	var s *system
+	bus.mapRange(0x2000, 0x21FF, device{
+		read: func(addr uint16) byte {
+			log = append(log, fmt.Sprintf("R $%04X @%d", 0x2000+addr, s.cpu.clk))
+			return 0x41
+		},
+		write: func(addr uint16, v byte) {
+			log = append(log, fmt.Sprintf("W $%04X=$%02X @%d", 0x2000+addr, v, s.cpu.clk))
+		},
+	})
+	s = newBusSystem(bus)
+	for i := 0; i < 5; i++ {
+		s.exec()
+	}
+
+	want := []string{
+		"R $2001 @6",
+		"R $2000 @10", // Dummy Read Before the Carry
+		"R $2100 @11",
+		"R $2002 @15",
+		"W $2002=$41 @16", // Dummy Write of the Unmodified Value
+		"W $2002=$42 @17",
+		"R $2003 @21", // Stores Always Read First
+		"W $2003=$41 @22",
+	}
+	if strings.Join(log, "\n") != strings.Join(want, "\n") || s.cpu.clk != 22 {
+		t.Errorf("Expected accesses:\n%s\ngot (clk %d):\n%s", strings.Join(want, "\n"), s.cpu.clk, strings.Join(log, "\n"))
+	}
+}
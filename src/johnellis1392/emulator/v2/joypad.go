@@ -0,0 +1,78 @@
+package main
+
+// Standard Controllers
+//
+// $4016  Write: Strobe; While Bit 0 is High Both Pads Keep Reloading their
+//        Shift Registers from the Buttons
+// $4016  Read: Pad 1's Next Button in Bit 0
+// $4017  Read: Pad 2's Next Button in Bit 0; Writes Go to the APU
+//
+// Buttons Come Out in the Order A, B, Select, Start, Up, Down, Left, Right,
+// then 1s. The Upper Bits are Open Bus, Usually $40 from the Address.
+
+const (
+	joypadAddr = 0x4016
+	openBus    = 0x40
+)
+
+// Button Bits, in Shift Order
+const (
+	buttonA byte = 1 << iota
+	buttonB
+	buttonSelect
+	buttonStart
+	buttonUp
+	buttonDown
+	buttonLeft
+	buttonRight
+)
+
+type joypad struct {
+	buttons byte // Held Now; Set by Playback or the Debugger
+	shift   byte
+	strobe  bool
+}
+
+func (j *joypad) read() byte {
+	if j.strobe {
+		return j.buttons&1 | openBus
+	}
+	v := j.shift & 1
+	j.shift = j.shift>>1 | 0x80
+	return v | openBus
+}
+
+func (j *joypad) write(v byte) {
+	if j.strobe = v&1 != 0; j.strobe {
+		j.shift = j.buttons
+	}
+}
+
+// joypadPorts $4016-$4017, Sending $4017 Writes to the APU's Frame Counter
+func joypadPorts(pads [2]*joypad, a *apu) device {
+	return device{
+		read: func(addr uint16) byte {
+			return pads[addr&1].read()
+		},
+		write: func(addr uint16, v byte) {
+			if addr&1 == 0 {
+				pads[0].write(v)
+				pads[1].write(v)
+			} else if a != nil {
+				a.writeRegister(0x17, v)
+			}
+		},
+	}
+}
+
+func (j *joypad) save(w *stateWriter) {
+	w.u8(j.buttons)
+	w.u8(j.shift)
+	w.bool(j.strobe)
+}
+
+func (j *joypad) load(r *stateReader) {
+	j.buttons = r.u8()
+	j.shift = r.u8()
+	j.strobe = r.bool()
+}
@@ -1,8 +1,14 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"image"
+	"io"
 	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
 )
 
 // Addressing Modes:
@@ -76,8 +82,23 @@ import (
 // TXS => Transfer XR to SP
 // TYA => Transfer YR to Acc
 
-const screenRes = 640 * 480
-const memSize = 1024
+// Screen Resolution of the NES Picture
+const (
+	screenWidth  = 256
+	screenHeight = 240
+)
+
+// Status Register Bits
+const (
+	flagC byte = 1 << iota // Carry
+	flagZ                  // Zero
+	flagI                  // Interrupt Disable
+	flagD                  // Decimal Mode
+	flagB                  // Break: Only Set in Copies Pushed by BRK / PHP
+	flagU                  // Unused: Always Reads as 1
+	flagV                  // Overflow
+	flagS                  // Sign
+)
 
 type status struct {
 	s bool // Sign Flag: Set if Result of Arithmetic Operation is Negative
@@ -105,56 +126,225 @@ func (s *status) setCarry(i byte) {
 	s.c = false
 }
 
+// setZS Set the Zero and Sign Flags from a Result
+func (s *status) setZS(v byte) {
+	s.z = v == 0
+	s.s = v&0x80 != 0
+}
+
+// pack Pack the Flags into the Processor Status Byte
+func (s status) pack() byte {
+	p := flagU
+	for _, f := range []struct {
+		set bool
+		bit byte
+	}{{s.s, flagS}, {s.v, flagV}, {s.b, flagB}, {s.d, flagD}, {s.i, flagI}, {s.z, flagZ}, {s.c, flagC}} {
+		if f.set {
+			p |= f.bit
+		}
+	}
+	return p
+}
+
+// set Unpack a Processor Status Byte. B and Bit 5 Have no Storage in the
+// Register, so they are Dropped.
+func (s *status) set(p byte) {
+	*s = status{
+		s: p&flagS != 0,
+		v: p&flagV != 0,
+		d: p&flagD != 0,
+		i: p&flagI != 0,
+		z: p&flagZ != 0,
+		c: p&flagC != 0,
+	}
+}
+
 type cpu struct {
 	ac  byte   // Accumulator
 	xr  byte   // X Index Register
 	yr  byte   // Y Index Register
 	sr  status // Status Register
-	pc  byte   // Program Counter
+	pc  uint16 // Program Counter
 	sp  byte   // Stack Pointer
 	clk uint64 // Clock Timer
 
-	inst byte // Current Instruction
+	inst    byte   // Current Instruction
+	mode    mode   // Addressing Mode of the Current Instruction
+	addr    uint16 // Effective Address of the Current Instruction
+	crossed bool   // Whether Resolving addr Crossed a Page
+
+	// Decimal Mode Arithmetic for ADC / SBC. The NES's 2A03 Lacks it, so
+	// it's Off Unless Enabled.
+	decimal bool
+
+	// Interrupt Lines and the Last Poll of Them
+	nmiLine          bool // NMI Input Level
+	nmi              bool // NMI Edge Latched, Not Yet Serviced
+	irq              byte // IRQ Input: One Bit per Source Holding it
+	takeNMI, takeIRQ bool // Seen by the Poll Before the Last Cycle
+
+	// The Current Instruction's Poll: the Clock Count of its Last Cycle,
+	// and for CLI, SEI and PLP the I Flag from Before they Changed it
+	pollAt    uint64
+	pollHoldI bool
+	pollI     bool
+
+	value byte // Operand Last Read, for the Read-Modify-Write Dummy Write
 }
 
+// noPoll pollAt Between Instructions
+const noPoll = ^uint64(0)
+
 func (c *cpu) tick() {
 	c.clk++
 }
 
-type page [memSize]byte
-
-type mem [memSize]page
-
 type system struct {
 	cpu
-	mem
+	bus    Bus
+	mapper Mapper     // Cartridge, if Any
+	ppu    *ppu       // Video, if Any
+	apu    *apu       // Audio, if Any
+	pads   [2]*joypad // Controllers, if Any
+	trace  io.Writer  // Execution Trace Sink, if Any
+
+	devices []clocked // Stepped Every Cycle
 }
 
+// loadAddr Address Programs are Loaded and Started at
+const loadAddr = 0x8000
+
 func (s *system) stopped() bool {
 	return false
 }
 
+// read / write Access the Bus Directly, Outside the CPU's Timing, as DMA,
+// Tests and Save States Do
+func (s *system) read(addr uint16) byte {
+	return s.bus.Read(addr)
+}
+
+func (s *system) write(addr uint16, v byte) {
+	s.bus.Write(addr, v)
+}
+
+// ioStart / ioEnd PPU, APU and Controller Registers, Where Reading Has Side
+// Effects: Clearing VBlank, Advancing the PPU's Read Buffer, Shifting a Pad
+const (
+	ioStart = 0x2000
+	ioEnd   = 0x401F
+)
+
+func isIO(addr uint16) bool {
+	return addr >= ioStart && addr <= ioEnd
+}
+
+// inspect Read addr Without Side Effects or CPU Cycles, for the Debugger
+// and the Trace. I/O Registers aren't Read, and Show as $FF.
+func (s *system) inspect(addr uint16) byte {
+	if isIO(addr) {
+		return 0xFF
+	}
+	return s.read(addr)
+}
+
+// read16 Read a Little Endian Word
+func (s *system) read16(addr uint16) uint16 {
+	return uint16(s.read(addr)) | uint16(s.read(addr+1))<<8
+}
+
+// busRead / busWrite Access the Bus as a CPU Cycle: the Cycle is Clocked,
+// then the Access Happens, so Devices See it at the Clock Count it Takes
+// Place
+func (s *system) busRead(addr uint16) byte {
+	s.tick()
+	return s.read(addr)
+}
+
+func (s *system) busWrite(addr uint16, v byte) {
+	s.tick()
+	s.write(addr, v)
+}
+
+// busRead16 Read a Little Endian Word in Two CPU Cycles
+func (s *system) busRead16(addr uint16) uint16 {
+	lo := s.busRead(addr)
+	return uint16(lo) | uint16(s.busRead(addr+1))<<8
+}
+
+// next Fetch the Byte at the Program Counter
 func (s *system) next() byte {
-	pc := int(s.cpu.pc)
-	i := s.mem[pc]
+	i := s.busRead(s.cpu.pc)
 	s.cpu.pc++
 	return i
 }
 
+// next16 Read a Little Endian Word at the Program Counter
+func (s *system) next16() uint16 {
+	lo := s.next()
+	return uint16(lo) | uint16(s.next())<<8
+}
+
 func (s *system) peek() byte {
 	return s.cpu.inst
 }
 
+// newSystem Build a System with RAM Below loadAddr and data as ROM Above
+// it, Repeated to Fill the Space if it's Shorter
 func newSystem(data []byte) *system {
+	if len(data) == 0 {
+		data = []byte{0xEA} // NOP
+	}
+	if len(data) > 0x10000-loadAddr {
+		data = data[:0x10000-loadAddr]
+	}
+	bus := newAddressSpace()
+	bus.mapRange(0x0000, loadAddr-1, make(ram, loadAddr))
+	bus.mapRange(loadAddr, 0xFFFF, mirror{rom(data), uint16(len(data))})
+	return newBusSystem(bus)
+}
+
+// newBusSystem Build a System Over Any Bus, Starting at loadAddr
+func newBusSystem(bus Bus) *system {
 	return &system{
-		cpu: cpu{},
-		mem: mem{},
-		rom: rom(data),
+		cpu: cpu{pc: loadAddr, sp: 0xFD, sr: status{i: true}, pollAt: noPoll},
+		bus: bus,
 	}
 }
 
 func main() {
-	const filename = "./test.rom"
+	debug := flag.Bool("debug", false, "Run the ROM Under the Interactive Debugger")
+	asm := flag.Bool("asm", false, "Assemble the Source File Instead of Running it")
+	out := flag.String("o", "a.out", "Output File for -asm")
+	nes := flag.Bool("ines", false, "Wrap -asm Output in an iNES Image")
+	dis := flag.Bool("dis", false, "Disassemble the File to Stdout Instead of Running it")
+	rewindN := flag.Int("rewind", rewindFrames, "Frames the Debugger Keeps for Rewinding")
+	trace := flag.String("trace", "", "Write an Execution Trace to this File (- for Stdout)")
+	frames := flag.Uint64("frames", 0, "Stop After this Many Frames; 0 Runs Forever")
+	dump := flag.String("dump", "", "Comma-Separated Frame Numbers to Save as PNG")
+	pngName := flag.String("png", "frame%04d.png", "File Name Pattern for -dump")
+	wav := flag.String("wav", "", "Write the Audio to this WAV File; Use with -frames")
+	rate := flag.Int("rate", defaultSampleRate, "Audio Sample Rate for -wav")
+	play := flag.String("play", "", "Play this Movie and Print the RAM Checksum at its End")
+	record := flag.String("record", "", "Record the Debugger's pad Commands to this Movie")
+	flag.Parse()
+	filename := "./test.rom"
+	if flag.NArg() > 0 {
+		filename = flag.Arg(0)
+	}
+
+	switch {
+	case *asm:
+		if err := assembleFile(filename, *out, *nes); err != nil {
+			panic(err)
+		}
+		return
+	case *dis:
+		if err := disassembleFile(filename); err != nil {
+			panic(err)
+		}
+		return
+	}
 	fmt.Printf("Reading File: %s\n", filename)
 
 	data, err := ioutil.ReadFile(filename)
@@ -162,8 +352,159 @@ func main() {
 		panic(err)
 	}
 
+	cart, err := loadINES(data)
+	if err != nil {
+		panic(err)
+	}
+	m, err := cart.newMapper()
+	if err != nil {
+		panic(err)
+	}
+
+	s := newNES(m)
+	switch *trace {
+	case "":
+	case "-":
+		s.trace = os.Stdout
+	default:
+		f, err := os.Create(*trace)
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		s.trace = f
+	}
+	if *debug {
+		d := newDebugger(s, os.Stdout)
+		d.rewind = newRewind(*rewindN, frameCycles)
+		if *record != "" {
+			d.movie = &movieRecorder{}
+		}
+		if err := d.run(os.Stdin); err != nil {
+			panic(err)
+		}
+		if *record != "" {
+			if err := saveMovie(*record, d.movie.finish(s)); err != nil {
+				panic(err)
+			}
+		}
+		return
+	}
+	if *record != "" {
+		panic("-record needs -debug")
+	}
+
+	if *dump != "" {
+		if err := dumpFrames(s.ppu, *dump, *pngName); err != nil {
+			panic(err)
+		}
+	}
+
+	if *wav != "" {
+		f, err := os.Create(*wav)
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		w, err := recordAudio(s.apu, f, *rate)
+		if err != nil {
+			panic(err)
+		}
+		defer func() {
+			if err := w.Close(); err != nil {
+				panic(err)
+			}
+		}()
+	}
+
+	if *play != "" {
+		m, err := loadMovie(*play)
+		if err != nil {
+			panic(err)
+		}
+		sum, err := s.playMovie(m)
+		fmt.Printf("frame %d ram %08X\n", m.end, sum)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	fmt.Println("Running File.")
-	for s := newSystem(data); !s.stopped(); {
+	for !s.stopped() && (*frames == 0 || s.ppu.frame < *frames) {
 		s.exec()
 	}
 }
+
+// dumpFrames Save the Frames Numbered in list, e.g. "1,60,120", as PNGs
+// Named by Formatting the Frame Number into pattern
+func dumpFrames(p *ppu, list, pattern string) error {
+	want := make(map[uint64]bool)
+	for _, f := range strings.Split(list, ",") {
+		n, err := strconv.ParseUint(strings.TrimSpace(f), 10, 64)
+		if err != nil {
+			return fmt.Errorf("bad frame number %q", f)
+		}
+		want[n] = true
+	}
+	p.onFrame = func(img *image.RGBA, n uint64) {
+		if want[n] {
+			if err := writePNG(fmt.Sprintf(pattern, n), img); err != nil {
+				panic(err)
+			}
+		}
+	}
+	return nil
+}
+
+// recordAudio Stream the APU's Output to a WAV File at rate Samples per
+// Second; Close the Writer to Finish the File
+func recordAudio(a *apu, f io.WriteSeeker, rate int) (*wavWriter, error) {
+	if rate <= 0 || rate > cpuClockRate {
+		return nil, fmt.Errorf("bad sample rate %d", rate)
+	}
+	w, err := newWAVWriter(f, rate)
+	if err != nil {
+		return nil, err
+	}
+	a.sampleRate, a.onSample = rate, w.sample
+	return w, nil
+}
+
+// assembleFile Assemble src into a Flat Binary, or an iNES Image, at out
+func assembleFile(src, out string, nes bool) error {
+	text, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	p, err := assemble(string(text))
+	if err != nil {
+		return fmt.Errorf("%s: %v", src, err)
+	}
+	data := p.code
+	if nes {
+		if data, err = p.ines(); err != nil {
+			return err
+		}
+	}
+	return ioutil.WriteFile(out, data, 0644)
+}
+
+// disassembleFile Print a Flat Binary Loaded at loadAddr, or an iNES Image's
+// PRG ROM Placed at the Top of the Address Space
+func disassembleFile(filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	origin := uint16(loadAddr)
+	if cart, err := loadINES(data); err == nil {
+		data = cart.prg
+		if len(data) <= 0x8000 {
+			origin = uint16(0x10000 - len(data))
+		}
+	}
+	fmt.Print(disassembleProgram(data, origin))
+	return nil
+}
@@ -0,0 +1,247 @@
+package main
+
+// Mapper Cartridge Board Logic: Bank Switching Between the CPU / PPU
+// Address Spaces and the Cartridge's ROM and RAM
+type Mapper interface {
+	// ReadPRG / WritePRG Take CPU Addresses in $6000-$FFFF
+	ReadPRG(addr uint16) byte
+	WritePRG(addr uint16, v byte)
+
+	// ReadCHR / WriteCHR Take PPU Addresses in $0000-$1FFF
+	ReadCHR(addr uint16) byte
+	WriteCHR(addr uint16, v byte)
+
+	// Mirroring Current Nametable Arrangement
+	Mirroring() mirroring
+}
+
+// prgRAMBase Start of the Cartridge's PRG RAM Window
+const prgRAMBase = 0x6000
+
+// prgBus Exposes a Mapper's CPU Side on the Bus, Mapped at prgRAMBase
+type prgBus struct {
+	Mapper
+}
+
+func (b prgBus) Read(addr uint16) byte     { return b.ReadPRG(addr + prgRAMBase) }
+func (b prgBus) Write(addr uint16, v byte) { b.WritePRG(addr+prgRAMBase, v) }
+
+// board Memory Common to Every Mapper
+type board struct {
+	prg      []byte
+	chr      []byte
+	chrIsRAM bool
+	ram      []byte // PRG RAM at $6000-$7FFF
+	mirror   mirroring
+}
+
+func newBoard(c *cartridge) board {
+	b := board{prg: c.prg, chr: c.chr, mirror: c.mirroring}
+	if len(b.chr) == 0 {
+		b.chr, b.chrIsRAM = make([]byte, max(c.chrRAM, chrBankSize)), true
+	}
+	if c.prgRAM > 0 || c.trainer != nil {
+		b.ram = make([]byte, 0x2000)
+		copy(b.ram[0x1000:], c.trainer)
+	}
+	return b
+}
+
+// prgAt Byte at offset in the size-Byte PRG Bank bank, Wrapping Past the
+// End of the ROM the Way Unconnected Address Lines Do
+func (b *board) prgAt(bank, size int, offset uint16) byte {
+	return b.prg[(bank*size+int(offset))%len(b.prg)]
+}
+
+func (b *board) chrIndex(bank, size int, offset uint16) int {
+	return (bank*size + int(offset)) % len(b.chr)
+}
+
+func (b *board) readRAM(addr uint16) byte {
+	if len(b.ram) == 0 {
+		return 0
+	}
+	return b.ram[int(addr-prgRAMBase)%len(b.ram)]
+}
+
+func (b *board) writeRAM(addr uint16, v byte) {
+	if len(b.ram) > 0 {
+		b.ram[int(addr-prgRAMBase)%len(b.ram)] = v
+	}
+}
+
+func (b *board) writeCHR(i int, v byte) {
+	if b.chrIsRAM {
+		b.chr[i] = v
+	}
+}
+
+func (b *board) Mirroring() mirroring { return b.mirror }
+
+// nrom Mapper 0: No Bank Switching; 16KiB of PRG is Mirrored into Both Halves
+type nrom struct {
+	board
+}
+
+func newNROM(c *cartridge) *nrom { return &nrom{newBoard(c)} }
+
+func (m *nrom) ReadPRG(addr uint16) byte {
+	if addr < 0x8000 {
+		return m.readRAM(addr)
+	}
+	return m.prgAt(0, 0, addr-0x8000)
+}
+
+func (m *nrom) WritePRG(addr uint16, v byte) {
+	if addr < 0x8000 {
+		m.writeRAM(addr, v)
+	}
+}
+
+func (m *nrom) ReadCHR(addr uint16) byte     { return m.chr[m.chrIndex(0, 0, addr)] }
+func (m *nrom) WriteCHR(addr uint16, v byte) { m.writeCHR(m.chrIndex(0, 0, addr), v) }
+
+// uxrom Mapper 2: Switchable 16KiB PRG Bank at $8000, Last Bank Fixed at
+// $C000
+type uxrom struct {
+	board
+	bank int
+}
+
+func newUxROM(c *cartridge) *uxrom { return &uxrom{board: newBoard(c)} }
+
+func (m *uxrom) ReadPRG(addr uint16) byte {
+	switch {
+	case addr < 0x8000:
+		return m.readRAM(addr)
+	case addr < 0xC000:
+		return m.prgAt(m.bank, prgBankSize, addr-0x8000)
+	}
+	return m.prgAt(len(m.prg)/prgBankSize-1, prgBankSize, addr-0xC000)
+}
+
+func (m *uxrom) WritePRG(addr uint16, v byte) {
+	if addr < 0x8000 {
+		m.writeRAM(addr, v)
+		return
+	}
+	m.bank = int(v)
+}
+
+func (m *uxrom) ReadCHR(addr uint16) byte     { return m.chr[m.chrIndex(0, 0, addr)] }
+func (m *uxrom) WriteCHR(addr uint16, v byte) { m.writeCHR(m.chrIndex(0, 0, addr), v) }
+
+// cnrom Mapper 3: Fixed PRG as in NROM, Switchable 8KiB CHR Bank
+type cnrom struct {
+	nrom
+	bank int
+}
+
+func newCNROM(c *cartridge) *cnrom { return &cnrom{nrom: nrom{newBoard(c)}} }
+
+func (m *cnrom) WritePRG(addr uint16, v byte) {
+	if addr < 0x8000 {
+		m.writeRAM(addr, v)
+		return
+	}
+	m.bank = int(v)
+}
+
+func (m *cnrom) ReadCHR(addr uint16) byte {
+	return m.chr[m.chrIndex(m.bank, chrBankSize, addr)]
+}
+
+func (m *cnrom) WriteCHR(addr uint16, v byte) {
+	m.writeCHR(m.chrIndex(m.bank, chrBankSize, addr), v)
+}
+
+// mmc1 Mapper 1: Registers are Loaded a Bit at a Time Through a Serial
+// Shift Register
+//
+// Writes to $8000-$FFFF Shift in Bit 0, LSB First; the Fifth Write Copies
+// the Value into the Register Selected by Address Bits 13-14. Writing a
+// Value with Bit 7 Set Resets the Shift Register and Locks the Last PRG
+// Bank at $C000.
+//
+// Control ($8000): Bits 0-1 Mirroring, Bits 2-3 PRG Mode, Bit 4 CHR Mode
+// CHR Bank 0 ($A000), CHR Bank 1 ($C000)
+// PRG Bank ($E000): Bits 0-3 Bank, Bit 4 PRG RAM Disable
+type mmc1 struct {
+	board
+	shift, count byte
+	control      byte
+	chr0, chr1   byte
+	prgBank      byte
+}
+
+func newMMC1(c *cartridge) *mmc1 { return &mmc1{board: newBoard(c), control: 0x0C} }
+
+func (m *mmc1) ReadPRG(addr uint16) byte {
+	if addr < 0x8000 {
+		if m.prgBank&0x10 != 0 {
+			return 0
+		}
+		return m.readRAM(addr)
+	}
+	bank := int(m.prgBank & 0x0F)
+	switch m.control >> 2 & 0x03 {
+	case 0, 1: // 32KiB, Ignoring the Low Bit of the Bank Number
+		return m.prgAt(bank>>1, 2*prgBankSize, addr-0x8000)
+	case 2: // First Bank Fixed at $8000
+		if addr < 0xC000 {
+			return m.prgAt(0, prgBankSize, addr-0x8000)
+		}
+		return m.prgAt(bank, prgBankSize, addr-0xC000)
+	}
+	// Last Bank Fixed at $C000
+	if addr < 0xC000 {
+		return m.prgAt(bank, prgBankSize, addr-0x8000)
+	}
+	return m.prgAt(len(m.prg)/prgBankSize-1, prgBankSize, addr-0xC000)
+}
+
+func (m *mmc1) WritePRG(addr uint16, v byte) {
+	if addr < 0x8000 {
+		if m.prgBank&0x10 == 0 {
+			m.writeRAM(addr, v)
+		}
+		return
+	}
+	if v&0x80 != 0 {
+		m.shift, m.count = 0, 0
+		m.control |= 0x0C
+		return
+	}
+	m.shift |= (v & 0x01) << m.count
+	if m.count++; m.count < 5 {
+		return
+	}
+	switch addr >> 13 & 0x03 {
+	case 0:
+		m.control = m.shift
+	case 1:
+		m.chr0 = m.shift
+	case 2:
+		m.chr1 = m.shift
+	case 3:
+		m.prgBank = m.shift
+	}
+	m.shift, m.count = 0, 0
+}
+
+func (m *mmc1) chrAt(addr uint16) int {
+	if m.control&0x10 == 0 { // 8KiB, Ignoring the Low Bit
+		return m.chrIndex(int(m.chr0>>1), chrBankSize, addr)
+	}
+	if addr < 0x1000 {
+		return m.chrIndex(int(m.chr0), 0x1000, addr)
+	}
+	return m.chrIndex(int(m.chr1), 0x1000, addr-0x1000)
+}
+
+func (m *mmc1) ReadCHR(addr uint16) byte     { return m.chr[m.chrAt(addr)] }
+func (m *mmc1) WriteCHR(addr uint16, v byte) { m.writeCHR(m.chrAt(addr), v) }
+
+func (m *mmc1) Mirroring() mirroring {
+	return [...]mirroring{singleLower, singleUpper, vertical, horizontal}[m.control&0x03]
+}
@@ -0,0 +1,177 @@
+package main
+
+import "testing"
+
+func loadMapper(t *testing.T, data []byte) Mapper {
+	c, err := loadINES(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := c.newMapper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// mmc1Write Load a Register Through the MMC1's Serial Port
+func mmc1Write(m Mapper, addr uint16, v byte) {
+	for i := 0; i < 5; i++ {
+		m.WritePRG(addr, v>>i&1)
+	}
+}
+
+func Test_NROM_ShouldMirror16KiBPRG(t *testing.T) {
+	m := loadMapper(t, inesImage(0, 1, 1, 0))
+	if m.ReadPRG(0x8000) != 0 || m.ReadPRG(0xC000) != 0 || m.ReadPRG(0xFFFF) != 0 {
+		t.Errorf("Expected bank 0 in both halves")
+	}
+	m = loadMapper(t, inesImage(0, 2, 1, 0))
+	if m.ReadPRG(0xBFFF) != 0 || m.ReadPRG(0xC000) != 1 {
+		t.Errorf("Expected banks 0 and 1 for NROM-256")
+	}
+}
+
+func Test_NROM_ShouldProvidePRGRAMAndProtectCHRROM(t *testing.T) {
+	m := loadMapper(t, inesImage(0, 1, 1, 0))
+	m.WritePRG(0x6123, 0x42)
+	m.WritePRG(0x8000, 0x42)
+	m.WriteCHR(0x0010, 0x42)
+	if m.ReadPRG(0x6123) != 0x42 || m.ReadPRG(0x8000) != 0 || m.ReadCHR(0x0010) != 0x80 {
+		t.Errorf("Expected writable PRG RAM and read-only ROM")
+	}
+	if m.Mirroring() != horizontal {
+		t.Errorf("Expected horizontal mirroring, got: %d", m.Mirroring())
+	}
+}
+
+func Test_NROM_ShouldLoadTrainerAt7000(t *testing.T) {
+	m := loadMapper(t, inesImage(0, 1, 1, 0x04))
+	if m.ReadPRG(0x7000) != 0x7E || m.ReadPRG(0x71FF) != 0x7E || m.ReadPRG(0x7200) != 0 {
+		t.Errorf("Expected trainer at $7000-$71FF")
+	}
+}
+
+func Test_UxROM_ShouldSwitchLowBankAndFixLast(t *testing.T) {
+	m := loadMapper(t, inesImage(2, 8, 0, 0))
+	for bank := byte(0); bank < 8; bank++ {
+		m.WritePRG(0x8000, bank)
+		if m.ReadPRG(0x8000) != bank || m.ReadPRG(0xC000) != 7 {
+			t.Errorf("Expected bank %d at $8000 and 7 at $C000, got: %d %d", bank, m.ReadPRG(0x8000), m.ReadPRG(0xC000))
+		}
+	}
+	m.WriteCHR(0x1FFF, 0x42)
+	if m.ReadCHR(0x1FFF) != 0x42 {
+		t.Errorf("Expected CHR RAM to be writable")
+	}
+}
+
+func Test_CNROM_ShouldSwitchCHRBank(t *testing.T) {
+	m := loadMapper(t, inesImage(3, 2, 4, 0x01))
+	for bank := byte(0); bank < 4; bank++ {
+		m.WritePRG(0xFFFF, bank)
+		if m.ReadCHR(0x0000) != 0x80|bank || m.ReadCHR(0x1FFF) != 0x80|bank {
+			t.Errorf("Expected CHR bank %d, got: $%02X", bank, m.ReadCHR(0))
+		}
+	}
+	if m.ReadPRG(0x8000) != 0 || m.ReadPRG(0xC000) != 1 || m.Mirroring() != vertical {
+		t.Errorf("Expected fixed PRG and vertical mirroring")
+	}
+}
+
+func Test_MMC1_ShouldPowerUpWithLastBankFixed(t *testing.T) {
+	m := loadMapper(t, inesImage(1, 8, 2, 0))
+	if m.ReadPRG(0x8000) != 0 || m.ReadPRG(0xC000) != 7 {
+		t.Errorf("Expected banks 0 and 7, got: %d %d", m.ReadPRG(0x8000), m.ReadPRG(0xC000))
+	}
+}
+
+func Test_MMC1_ShouldSwitchPRGInEveryMode(t *testing.T) {
+	m := loadMapper(t, inesImage(1, 8, 2, 0))
+
+	mmc1Write(m, 0xE000, 5)
+	if m.ReadPRG(0x8000) != 5 || m.ReadPRG(0xC000) != 7 {
+		t.Errorf("Mode 3: Expected banks 5 and 7, got: %d %d", m.ReadPRG(0x8000), m.ReadPRG(0xC000))
+	}
+
+	mmc1Write(m, 0x8000, 0x08) // PRG Mode 2
+	if m.ReadPRG(0x8000) != 0 || m.ReadPRG(0xC000) != 5 {
+		t.Errorf("Mode 2: Expected banks 0 and 5, got: %d %d", m.ReadPRG(0x8000), m.ReadPRG(0xC000))
+	}
+
+	mmc1Write(m, 0x8000, 0x00) // PRG Mode 0: 32KiB
+	if m.ReadPRG(0x8000) != 4 || m.ReadPRG(0xC000) != 5 {
+		t.Errorf("Mode 0: Expected banks 4 and 5, got: %d %d", m.ReadPRG(0x8000), m.ReadPRG(0xC000))
+	}
+
+	m.WritePRG(0x8000, 0x80) // Reset Restores Mode 3
+	if m.ReadPRG(0x8000) != 5 || m.ReadPRG(0xC000) != 7 {
+		t.Errorf("Reset: Expected banks 5 and 7, got: %d %d", m.ReadPRG(0x8000), m.ReadPRG(0xC000))
+	}
+}
+
+func Test_MMC1_ShouldDiscardPartialWritesOnReset(t *testing.T) {
+	m := loadMapper(t, inesImage(1, 8, 2, 0))
+	m.WritePRG(0xE000, 1)
+	m.WritePRG(0xE000, 1)
+	m.WritePRG(0xE000, 0x80)
+	mmc1Write(m, 0xE000, 2)
+	if m.ReadPRG(0x8000) != 2 {
+		t.Errorf("Expected bank 2, got: %d", m.ReadPRG(0x8000))
+	}
+}
+
+func Test_MMC1_ShouldSwitchCHRAndMirroring(t *testing.T) {
+	m := loadMapper(t, inesImage(1, 2, 4, 0)) // 4 x 8KiB = 8 x 4KiB CHR Banks
+
+	mmc1Write(m, 0xA000, 5) // 8KiB Mode Ignores the Low Bit: Bank 2
+	if m.ReadCHR(0x0000) != 0x82 || m.ReadCHR(0x1FFF) != 0x82 {
+		t.Errorf("8KiB mode: Expected CHR bank 2, got: $%02X $%02X", m.ReadCHR(0), m.ReadCHR(0x1FFF))
+	}
+
+	mmc1Write(m, 0x8000, 0x1C|0x02) // 4KiB CHR, Vertical
+	mmc1Write(m, 0xC000, 2)
+	if m.ReadCHR(0x0000) != 0x82 || m.ReadCHR(0x1000) != 0x81 {
+		t.Errorf("4KiB mode: Expected halves of banks 2 and 1, got: $%02X $%02X", m.ReadCHR(0), m.ReadCHR(0x1000))
+	}
+	if m.Mirroring() != vertical {
+		t.Errorf("Expected vertical mirroring, got: %d", m.Mirroring())
+	}
+	for ctrl, want := range []mirroring{singleLower, singleUpper, vertical, horizontal} {
+		mmc1Write(m, 0x8000, byte(ctrl))
+		if m.Mirroring() != want {
+			t.Errorf("Expected mirroring %d for control %d, got: %d", want, ctrl, m.Mirroring())
+		}
+	}
+}
+
+func Test_MMC1_ShouldDisablePRGRAM(t *testing.T) {
+	m := loadMapper(t, inesImage(1, 2, 1, 0))
+	m.WritePRG(0x6000, 0x42)
+	mmc1Write(m, 0xE000, 0x10)
+	m.WritePRG(0x6001, 0x42)
+	if m.ReadPRG(0x6000) != 0 {
+		t.Errorf("Expected PRG RAM to read as 0 while disabled")
+	}
+	mmc1Write(m, 0xE000, 0x00)
+	if m.ReadPRG(0x6000) != 0x42 || m.ReadPRG(0x6001) != 0 {
+		t.Errorf("Expected PRG RAM to keep its contents and ignore writes while disabled")
+	}
+}
+
+func Test_NES_ShouldRunCartridgeCode(t *testing.T) {
+	data := inesImage(0, 1, 1, 0)
+	// LDA #$42; STA $0801 (Mirror of $0001); STA $6000
+	copy(data[inesHeaderSize:], []byte{0xA9, 0x42, 0x8D, 0x01, 0x08, 0x8D, 0x00, 0x60})
+	copy(data[inesHeaderSize+resetVector-0xC000:], []byte{0x00, 0x80})
+	s := newNES(loadMapper(t, data))
+	for i := 0; i < 3; i++ {
+		s.exec()
+	}
+	if s.read(0x0001) != 0x42 || s.read(0x1801) != 0x42 || s.read(0x6000) != 0x42 {
+		t.Errorf("Expected writes to internal RAM mirrors and PRG RAM")
+	}
+	if s.read(0xC000) != 0xA9 {
+		t.Errorf("Expected PRG ROM mirrored at $C000")
+	}
+}
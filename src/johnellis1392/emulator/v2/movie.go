@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Movies
+//
+// A Movie is Controller Input by Frame, as Text:
+//
+//	# frame  pad 1     pad 2
+//	0        ........  ........
+//	30       .......A  ........
+//	32       ........  ........
+//	end      120       5D1C2A3F
+//
+// Each Line Sets Both Pads from its Frame On. Buttons are Written RLDUTSBA
+// (Right, Left, Down, Up, sTart, Select, B, A), . Where Released. Frames
+// Count the PPU's Finished Frames, so Frame 0 Runs Up to the First VBlank.
+// The end Line Gives the Frame Playback Stops at and, Optionally, the
+// CRC-32 of Internal RAM Expected There.
+
+// buttonLetters Movie Button Letters, Bit 7 First
+const buttonLetters = "RLDUTSBA"
+
+type movie struct {
+	inputs   []movieInput
+	end      uint64
+	checksum uint32
+	checked  bool // checksum is Set
+}
+
+type movieInput struct {
+	frame uint64
+	pads  [2]byte
+}
+
+// formatButtons Render a Button Byte as RLDUTSBA
+func formatButtons(b byte) string {
+	out := []byte("........")
+	for i := range out {
+		if b&(0x80>>uint(i)) != 0 {
+			out[i] = buttonLetters[i]
+		}
+	}
+	return string(out)
+}
+
+// parseButtons Accept the Letters of RLDUTSBA in Any Order, and .s
+func parseButtons(s string) (byte, error) {
+	var b byte
+	for _, c := range s {
+		if c == '.' {
+			continue
+		}
+		i := strings.IndexRune(buttonLetters, c)
+		if i < 0 {
+			return 0, fmt.Errorf("bad button %q in %q", c, s)
+		}
+		b |= 0x80 >> uint(i)
+	}
+	return b, nil
+}
+
+func parseMovie(r io.Reader) (*movie, error) {
+	m := &movie{}
+	sc := bufio.NewScanner(r)
+	ended := false
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		f := strings.Fields(line)
+		if len(f) == 0 {
+			continue
+		}
+		fail := func(format string, args ...interface{}) (*movie, error) {
+			return nil, fmt.Errorf("movie line %d: %s", n, fmt.Sprintf(format, args...))
+		}
+		if ended {
+			return fail("input after end")
+		}
+
+		if f[0] == "end" {
+			if len(f) < 2 || len(f) > 3 {
+				return fail("expected: end <frame> [checksum]")
+			}
+			var err error
+			if m.end, err = strconv.ParseUint(f[1], 10, 64); err != nil {
+				return fail("bad frame %q", f[1])
+			}
+			if len(f) == 3 {
+				v, err := strconv.ParseUint(f[2], 16, 32)
+				if err != nil {
+					return fail("bad checksum %q", f[2])
+				}
+				m.checksum, m.checked = uint32(v), true
+			}
+			ended = true
+			continue
+		}
+
+		if len(f) < 2 || len(f) > 3 {
+			return fail("expected: <frame> <pad 1> [pad 2]")
+		}
+		var in movieInput
+		var err error
+		if in.frame, err = strconv.ParseUint(f[0], 10, 64); err != nil {
+			return fail("bad frame %q", f[0])
+		}
+		if k := len(m.inputs); k > 0 && in.frame <= m.inputs[k-1].frame {
+			return fail("frame %d out of order", in.frame)
+		}
+		for i, s := range f[1:] {
+			if in.pads[i], err = parseButtons(s); err != nil {
+				return fail("%v", err)
+			}
+		}
+		m.inputs = append(m.inputs, in)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if !ended {
+		return nil, fmt.Errorf("movie: missing end line")
+	}
+	if k := len(m.inputs); k > 0 && m.inputs[k-1].frame > m.end {
+		return nil, fmt.Errorf("movie: input at frame %d after the end at %d", m.inputs[k-1].frame, m.end)
+	}
+	return m, nil
+}
+
+func loadMovie(name string) (*movie, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	m, err := parseMovie(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return m, nil
+}
+
+func saveMovie(name string, m *movie) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := m.write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// write Format the Movie so parseMovie Reads it Back
+func (m *movie) write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# frame  pad 1     pad 2\n")
+	for _, in := range m.inputs {
+		fmt.Fprintf(bw, "%-8d %s  %s\n", in.frame, formatButtons(in.pads[0]), formatButtons(in.pads[1]))
+	}
+	fmt.Fprintf(bw, "end      %d", m.end)
+	if m.checked {
+		fmt.Fprintf(bw, "  %08X", m.checksum)
+	}
+	fmt.Fprintf(bw, "\n")
+	return bw.Flush()
+}
+
+// ramChecksum CRC-32 of the 2KiB Internal RAM
+func (s *system) ramChecksum() uint32 {
+	ram := make([]byte, internalRAMSize)
+	for i := range ram {
+		ram[i] = s.read(uint16(i))
+	}
+	return crc32.ChecksumIEEE(ram)
+}
+
+// setPads Hold Buttons on Both Controllers
+func (s *system) setPads(pads [2]byte) {
+	for i, p := range s.pads {
+		if p != nil {
+			p.buttons = pads[i]
+		}
+	}
+}
+
+// playMovie Run s from its Current State Until the PPU Reaches the Movie's
+// End Frame, Feeding it the Movie's Input, and Return the RAM Checksum
+// There. It's an Error if the Movie Expects a Different One.
+func (s *system) playMovie(m *movie) (uint32, error) {
+	if s.ppu == nil || s.pads[0] == nil {
+		return 0, fmt.Errorf("movie: system has no PPU or controllers")
+	}
+	next := 0
+	for {
+		frame := s.ppu.frame
+		for next < len(m.inputs) && m.inputs[next].frame <= frame {
+			s.setPads(m.inputs[next].pads)
+			next++
+		}
+		if frame >= m.end {
+			break
+		}
+		s.exec()
+	}
+	sum := s.ramChecksum()
+	if m.checked && sum != m.checksum {
+		return sum, fmt.Errorf("movie: RAM checksum %08X at frame %d, expected %08X", sum, m.end, m.checksum)
+	}
+	return sum, nil
+}
+
+// movieRecorder Builds a Movie from the Pads' Buttons; Call record at the
+// Start of Each Frame, Where Playback Applies Input
+type movieRecorder struct {
+	m    movie
+	last [2]byte
+}
+
+func (r *movieRecorder) record(s *system) {
+	frame := s.ppu.frame
+	// After a Rewind or a Loaded State, Forget Input from Later Frames
+	for k := len(r.m.inputs); k > 0 && r.m.inputs[k-1].frame > frame; k-- {
+		r.m.inputs = r.m.inputs[:k-1]
+		r.last = [2]byte{}
+		if k > 1 {
+			r.last = r.m.inputs[k-2].pads
+		}
+	}
+	pads := [2]byte{s.pads[0].buttons, s.pads[1].buttons}
+	if pads == r.last && len(r.m.inputs) > 0 {
+		return
+	}
+	if k := len(r.m.inputs); k > 0 && r.m.inputs[k-1].frame == frame {
+		r.m.inputs[k-1].pads = pads
+	} else {
+		r.m.inputs = append(r.m.inputs, movieInput{frame, pads})
+	}
+	r.last = pads
+}
+
+// finish Run to the Start of the Next Frame and End the Movie There, with
+// the RAM Checksum Playback Will Find
+func (r *movieRecorder) finish(s *system) *movie {
+	for f := s.ppu.frame; s.ppu.frame == f; {
+		s.exec()
+	}
+	m := r.m
+	m.inputs = append([]movieInput(nil), r.m.inputs...)
+	m.end, m.checksum, m.checked = s.ppu.frame, s.ramChecksum(), true
+	return &m
+}
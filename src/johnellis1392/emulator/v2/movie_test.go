@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// padTestSystem NES Running testdata/pad.s
+func padTestSystem(t *testing.T) *system {
+	t.Helper()
+	src, err := ioutil.ReadFile("testdata/pad.s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	image, err := mustAssemble(t, string(src)).ines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newNES(loadMapper(t, image))
+}
+
+func Test_Joypad_ShouldShiftButtonsOut(t *testing.T) {
+	s := padTestSystem(t)
+	s.setPads([2]byte{buttonA | buttonStart | buttonRight, buttonB})
+	s.write(joypadAddr, 1)
+	if s.read(joypadAddr) != openBus|1 || s.read(joypadAddr) != openBus|1 {
+		t.Error("Expected A while strobed")
+	}
+	s.write(joypadAddr, 0)
+	var got1, got2 []byte
+	for i := 0; i < 10; i++ {
+		got1 = append(got1, s.read(joypadAddr)&1)
+		got2 = append(got2, s.read(joypadAddr+1)&1)
+	}
+	if want := []byte{1, 0, 0, 1, 0, 0, 0, 1, 1, 1}; !bytes.Equal(got1, want) {
+		t.Errorf("Expected pad 1 %v, got %v", want, got1)
+	}
+	if want := []byte{0, 1, 0, 0, 0, 0, 0, 0, 1, 1}; !bytes.Equal(got2, want) {
+		t.Errorf("Expected pad 2 %v, got %v", want, got2)
+	}
+}
+
+func Test_Movie_ShouldRoundTrip(t *testing.T) {
+	src, err := ioutil.ReadFile("testdata/pad.movie")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := parseMovie(bytes.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &movie{
+		inputs:   []movieInput{{0, [2]byte{}}, {3, [2]byte{buttonA}}, {5, [2]byte{buttonA | buttonRight}}, {8, [2]byte{}}},
+		end:      12,
+		checksum: 0xB1411C1C,
+		checked:  true,
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Fatalf("Expected %+v, got %+v", want, m)
+	}
+	var buf bytes.Buffer
+	if err := m.write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if again, err := parseMovie(&buf); err != nil || !reflect.DeepEqual(again, m) {
+		t.Errorf("Expected the written movie to parse back, got %+v, %v", again, err)
+	}
+}
+
+func Test_Movie_ShouldRejectBadInput(t *testing.T) {
+	for _, src := range []string{
+		"0 ........\n",
+		"0 ....X...\nend 1\n",
+		"5 A\n3 A\nend 9\n",
+		"0 A\nend 4\n1 A\n",
+		"9 A\nend 4\n",
+		"end 4 nothex\n",
+	} {
+		if _, err := parseMovie(strings.NewReader(src)); err == nil {
+			t.Errorf("Expected an error for %q", src)
+		}
+	}
+}
+
+func Test_Movie_ShouldPlayBackDeterministically(t *testing.T) {
+	m, err := loadMovie("testdata/pad.movie")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for run := 0; run < 2; run++ {
+		s := padTestSystem(t)
+		if _, err := s.playMovie(m); err != nil {
+			t.Fatal(err)
+		}
+		// Frames 3-4 Hold A, 5-7 Right+A: 2*$01 + 3*$81
+		sum := uint16(s.read(0x10)) | uint16(s.read(0x11))<<8
+		if sum != 389 || s.read(0x13) != 11 {
+			t.Errorf("Expected sum 389 over 11 frames, got %d over %d", sum, s.read(0x13))
+		}
+	}
+
+	m.checksum++
+	if _, err := padTestSystem(t).playMovie(m); err == nil {
+		t.Error("Expected a checksum mismatch")
+	}
+}
+
+func Test_Debugger_ShouldRecordMovie(t *testing.T) {
+	s := padTestSystem(t)
+	d := newDebugger(s, ioutil.Discard)
+	d.movie = &movieRecorder{}
+	runTo := func(frame uint64) {
+		for s.ppu.frame < frame {
+			d.step()
+		}
+	}
+	// runTo Stops Just as a Frame Starts, Before Input is Applied, so the
+	// Input Takes Effect from that Frame
+	runTo(3)
+	d.command("pad 1 A")
+	runTo(5)
+	d.command("pad 1 RA")
+	d.command("pad 2 B") // Replaced Before it Takes Effect
+	d.command("pad 2 .")
+	runTo(8)
+	d.command("pad 1 .")
+	runTo(11)
+	got := d.movie.finish(s)
+
+	want, err := loadMovie("testdata/pad.movie")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		var buf bytes.Buffer
+		got.write(&buf)
+		t.Errorf("Expected the recording to match testdata/pad.movie, got:\n%s", buf.String())
+	}
+}
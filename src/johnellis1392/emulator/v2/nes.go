@@ -0,0 +1,40 @@
+package main
+
+// NES CPU Memory Map:
+// $0000-$07FF  2KiB Internal RAM
+// $0800-$1FFF  Mirrors of $0000-$07FF
+// $2000-$2007  PPU Registers
+// $2008-$3FFF  Mirrors of $2000-$2007
+// $4000-$4017  APU and I/O Registers; $4014 is OAM DMA, $4016-$4017 the Pads
+// $4018-$5FFF  Cartridge Expansion, Unused Here
+// $6000-$7FFF  Cartridge PRG RAM
+// $8000-$FFFF  Cartridge PRG ROM
+
+const internalRAMSize = 0x0800
+
+// newNES Build a System with the NES Memory Map Around a Cartridge and
+// Reset it, Starting at the Cartridge's Reset Vector
+func newNES(m Mapper) *system {
+	bus := newAddressSpace()
+	bus.mapRange(0x0000, 0x1FFF, mirror{make(ram, internalRAMSize), internalRAMSize})
+	bus.mapRange(prgRAMBase, 0xFFFF, prgBus{m})
+
+	p := newPPU(m)
+	bus.mapRange(0x2000, 0x3FFF, device{read: p.readRegister, write: p.writeRegister})
+
+	s := newBusSystem(bus)
+	a := newAPU(s.read)
+	bus.mapRange(0x4000, 0x4017, device{read: a.readRegister, write: a.writeRegister})
+
+	s.pads = [2]*joypad{{}, {}}
+	bus.mapRange(joypadAddr, joypadAddr+1, joypadPorts(s.pads, a))
+
+	s.mapper, s.ppu, s.apu = m, p, a
+	p.nmi = s.setNMI
+	a.irq = s.setIRQ
+	s.devices = append(s.devices, p, a)
+	bus.mapRange(oamDMAAddr, oamDMAAddr, device{write: func(_ uint16, v byte) { s.oamDMA(p, v) }})
+	s.cpu.sp = 0 // Power-On; RESET Leaves it at $FD
+	s.reset()
+	return s
+}
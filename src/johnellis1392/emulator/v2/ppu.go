@@ -0,0 +1,529 @@
+package main
+
+import (
+	"image"
+	"image/png"
+	"math/bits"
+	"os"
+)
+
+// NES Picture Processing Unit (2C02)
+//
+// CPU Registers, Mirrored Every 8 Bytes Through $3FFF:
+// $2000  PPUCTRL    Nametable, Increment, Pattern Tables, Sprite Size, NMI Enable
+// $2001  PPUMASK    Grayscale, Left Column Clipping, Background / Sprite Enable
+// $2002  PPUSTATUS  Sprite Overflow, Sprite 0 Hit, VBlank; Reading Clears VBlank
+// $2003  OAMADDR
+// $2004  OAMDATA
+// $2005  PPUSCROLL  Two Writes: X then Y
+// $2006  PPUADDR    Two Writes: High then Low
+// $2007  PPUDATA    Reads Below the Palette are Buffered
+// $4014  OAM DMA    Copy a CPU Page into OAM
+//
+// PPU Address Space:
+// $0000-$1FFF  Pattern Tables, from the Cartridge
+// $2000-$2FFF  Four Nametables, Folded onto 2KiB of VRAM by the Mirroring
+// $3000-$3EFF  Mirror of $2000-$2EFF
+// $3F00-$3F1F  Palette RAM, Mirrored Through $3FFF
+//
+// A Frame is 262 Lines of 341 Dots, Three Dots per CPU Cycle. Lines 0-239
+// are Drawn, 240 is Idle, VBlank Starts on 241, and 261 Prepares the Next
+// Frame. Each Visible Line is Drawn at Once at its First Dot from the
+// Scroll Registers as They Stand, so Mid-Frame Scroll Changes Take Effect a
+// Line at a Time, the Way Games Use Them.
+
+const (
+	dotsPerLine   = 341
+	linesPerFrame = 262
+	vblankLine    = 241
+	preRenderLine = 261
+	oamDMAAddr    = 0x4014
+)
+
+// PPUCTRL, PPUMASK and PPUSTATUS Bits
+const (
+	ctrlIncrement32 = 0x04
+	ctrlSpriteTable = 0x08
+	ctrlBgTable     = 0x10
+	ctrlTallSprites = 0x20
+	ctrlNMI         = 0x80
+
+	maskGray       = 0x01
+	maskBgLeft     = 0x02
+	maskSpriteLeft = 0x04
+	maskBg         = 0x08
+	maskSprites    = 0x10
+
+	statusOverflow = 0x20
+	statusHit      = 0x40
+	statusVBlank   = 0x80
+)
+
+type ppu struct {
+	mapper Mapper
+	nmi    func(level bool) // Drives the CPU's NMI Line
+
+	ctrl, mask, status byte
+	oamAddr            byte
+	v, t               uint16 // Current and Temporary VRAM Address
+	x                  byte   // Fine X Scroll
+	w                  bool   // Write Toggle Shared by $2005 and $2006
+	buffer             byte   // $2007 Read Buffer
+	latch              byte   // Last Value Written, Read Back from Write-Only Registers
+
+	dot, line int
+	odd       bool   // Odd Frames Skip a Dot While Rendering
+	frame     uint64 // Frames Completed
+	hitDot    int    // Dot Sprite 0 Hits on the Current Line, or -1
+
+	oam     [256]byte
+	vram    [0x1000]byte // Nametables; Only Four-Screen Boards Use it All
+	palette [32]byte
+
+	image *image.RGBA
+
+	// onFrame Called at the Start of VBlank with the Finished Frame. The
+	// Image is Drawn Over by the Next Frame, so Copy it to Keep it.
+	onFrame func(img *image.RGBA, frame uint64)
+}
+
+func newPPU(m Mapper) *ppu {
+	return &ppu{
+		mapper: m,
+		hitDot: -1,
+		image:  image.NewRGBA(image.Rect(0, 0, screenWidth, screenHeight)),
+	}
+}
+
+// clock Three Dots per CPU Cycle
+func (p *ppu) clock() {
+	for i := 0; i < 3; i++ {
+		p.step()
+	}
+}
+
+// step Advance One Dot
+func (p *ppu) step() {
+	rendering := p.mask&(maskBg|maskSprites) != 0
+	switch {
+	case p.line < screenHeight:
+		if p.dot == 1 {
+			p.renderLine()
+		}
+		if p.dot == p.hitDot {
+			p.status |= statusHit
+		}
+		if rendering && p.dot == 256 {
+			p.incrementY()
+		}
+		if rendering && p.dot == 257 {
+			p.v = p.v&^0x041F | p.t&0x041F
+		}
+
+	case p.line == vblankLine && p.dot == 1:
+		p.status |= statusVBlank
+		p.frame++
+		if p.onFrame != nil {
+			p.onFrame(p.image, p.frame)
+		}
+		p.updateNMI()
+
+	case p.line == preRenderLine:
+		if p.dot == 1 {
+			p.status &^= statusVBlank | statusHit | statusOverflow
+			p.updateNMI()
+		}
+		if rendering && p.dot == 257 {
+			p.v = p.v&^0x041F | p.t&0x041F
+		}
+		if rendering && p.dot >= 280 && p.dot <= 304 {
+			p.v = p.v&^0x7BE0 | p.t&0x7BE0
+		}
+		if rendering && p.odd && p.dot == 339 {
+			p.dot++
+		}
+	}
+
+	if p.dot++; p.dot == dotsPerLine {
+		p.dot = 0
+		if p.line++; p.line == linesPerFrame {
+			p.line = 0
+			p.odd = !p.odd
+		}
+	}
+}
+
+// updateNMI The NMI Output is Low While VBlank and NMI Enable are Both Set
+func (p *ppu) updateNMI() {
+	if p.nmi != nil {
+		p.nmi(p.ctrl&ctrlNMI != 0 && p.status&statusVBlank != 0)
+	}
+}
+
+// incrementY Move v Down a Pixel Row, Wrapping into the Next Nametable
+// after Row 29; Rows 30-31 are Attributes and Wrap Without Switching
+func (p *ppu) incrementY() {
+	if p.v&0x7000 != 0x7000 {
+		p.v += 0x1000
+		return
+	}
+	p.v &^= 0x7000
+	y := p.v & 0x03E0 >> 5
+	switch y {
+	case 29:
+		y = 0
+		p.v ^= 0x0800
+	case 31:
+		y = 0
+	default:
+		y++
+	}
+	p.v = p.v&^0x03E0 | y<<5
+}
+
+func (p *ppu) incrementAddr() {
+	if p.ctrl&ctrlIncrement32 != 0 {
+		p.v += 32
+	} else {
+		p.v++
+	}
+}
+
+// readRegister / writeRegister CPU Side, addr Relative to $2000
+func (p *ppu) readRegister(addr uint16) byte {
+	switch addr & 7 {
+	case 2:
+		v := p.status&0xE0 | p.latch&0x1F
+		p.status &^= statusVBlank
+		p.w = false
+		p.updateNMI()
+		return v
+	case 4:
+		return p.oam[p.oamAddr]
+	case 7:
+		v := p.buffer
+		if p.v&0x3FFF >= 0x3F00 {
+			// Palette Reads Skip the Buffer, Which Gets the Nametable Below
+			v = p.readVRAM(p.v)
+			p.buffer = p.readVRAM(p.v - 0x1000)
+		} else {
+			p.buffer = p.readVRAM(p.v)
+		}
+		p.incrementAddr()
+		return v
+	}
+	return p.latch
+}
+
+func (p *ppu) writeRegister(addr uint16, v byte) {
+	p.latch = v
+	switch addr & 7 {
+	case 0:
+		p.ctrl = v
+		p.t = p.t&^0x0C00 | uint16(v&0x03)<<10
+		p.updateNMI()
+	case 1:
+		p.mask = v
+	case 3:
+		p.oamAddr = v
+	case 4:
+		p.oam[p.oamAddr] = v
+		p.oamAddr++
+	case 5:
+		if !p.w {
+			p.t = p.t&^0x001F | uint16(v>>3)
+			p.x = v & 0x07
+		} else {
+			p.t = p.t&^0x73E0 | uint16(v&0x07)<<12 | uint16(v&0xF8)<<2
+		}
+		p.w = !p.w
+	case 6:
+		if !p.w {
+			p.t = p.t&^0x7F00 | uint16(v&0x3F)<<8
+		} else {
+			p.t = p.t&^0x00FF | uint16(v)
+			p.v = p.t
+		}
+		p.w = !p.w
+	case 7:
+		p.writeVRAM(p.v, v)
+		p.incrementAddr()
+	}
+}
+
+// oamDMA Copy a CPU Page into OAM, Stalling the CPU for 513 Cycles, or 514
+// if it Starts on an Odd One
+func (s *system) oamDMA(p *ppu, page byte) {
+	if s.cpu.clk%2 == 1 {
+		s.tick()
+	}
+	s.tick()
+	for i := 0; i < 0x100; i++ {
+		v := s.read(uint16(page)<<8 | uint16(i))
+		s.tick()
+		p.oam[p.oamAddr] = v
+		p.oamAddr++
+		s.tick()
+	}
+}
+
+func (p *ppu) readVRAM(addr uint16) byte {
+	switch addr &= 0x3FFF; {
+	case addr < 0x2000:
+		return p.mapper.ReadCHR(addr)
+	case addr < 0x3F00:
+		return p.vram[p.nametable(addr)]
+	}
+	return p.palette[paletteIndex(addr)]
+}
+
+func (p *ppu) writeVRAM(addr uint16, v byte) {
+	switch addr &= 0x3FFF; {
+	case addr < 0x2000:
+		p.mapper.WriteCHR(addr, v)
+	case addr < 0x3F00:
+		p.vram[p.nametable(addr)] = v
+	default:
+		p.palette[paletteIndex(addr)] = v & 0x3F
+	}
+}
+
+// nametable VRAM Offset of a Nametable Address Under the Cartridge's
+// Mirroring
+func (p *ppu) nametable(addr uint16) int {
+	table := int(addr >> 10 & 0x03)
+	switch p.mapper.Mirroring() {
+	case horizontal:
+		table >>= 1
+	case vertical:
+		table &= 1
+	case singleLower:
+		table = 0
+	case singleUpper:
+		table = 1
+	}
+	return table<<10 | int(addr&0x03FF)
+}
+
+// paletteIndex Sprite Palette Entry 0 of Each Palette Mirrors the
+// Background One, so $3F10 is $3F00 and so on
+func paletteIndex(addr uint16) int {
+	i := int(addr & 0x1F)
+	if i&0x13 == 0x10 {
+		i &^= 0x10
+	}
+	return i
+}
+
+// lineSprite Sprite Found on the Line Being Drawn, with its Pattern Row
+type lineSprite struct {
+	index   int
+	x       int
+	lo, hi  byte // Pattern Planes, Already Flipped
+	palette byte
+	behind  bool // Drawn Behind Opaque Background
+}
+
+// pixel Sprite's 2-Bit Color at Screen Column x; 0 is Transparent
+func (s *lineSprite) pixel(x int) byte {
+	dx := x - s.x
+	if dx < 0 || dx > 7 {
+		return 0
+	}
+	bit := uint(7 - dx)
+	return s.lo>>bit&1 | s.hi>>bit&1<<1
+}
+
+// evaluateSprites Find the First Eight Sprites on Line y, Flagging
+// Overflow if there are More. OAM Holds Each Sprite's Top Line Less One.
+func (p *ppu) evaluateSprites(y int) []lineSprite {
+	height := 8
+	if p.ctrl&ctrlTallSprites != 0 {
+		height = 16
+	}
+	var found []lineSprite
+	for i := 0; i < 64; i++ {
+		o := p.oam[i*4 : i*4+4]
+		row := y - int(o[0]) - 1
+		if row < 0 || row >= height {
+			continue
+		}
+		if len(found) == 8 {
+			p.status |= statusOverflow
+			break
+		}
+		tile, attr := uint16(o[1]), o[2]
+		if attr&0x80 != 0 {
+			row = height - 1 - row
+		}
+		var addr uint16
+		if height == 16 {
+			table := tile & 1 * 0x1000
+			tile &^= 1
+			if row >= 8 {
+				tile, row = tile+1, row-8
+			}
+			addr = table + tile*16 + uint16(row)
+		} else {
+			addr = tile*16 + uint16(row)
+			if p.ctrl&ctrlSpriteTable != 0 {
+				addr += 0x1000
+			}
+		}
+		sp := lineSprite{
+			index:   i,
+			x:       int(o[3]),
+			lo:      p.readVRAM(addr),
+			hi:      p.readVRAM(addr + 8),
+			palette: attr & 0x03,
+			behind:  attr&0x20 != 0,
+		}
+		if attr&0x40 != 0 {
+			sp.lo, sp.hi = bits.Reverse8(sp.lo), bits.Reverse8(sp.hi)
+		}
+		found = append(found, sp)
+	}
+	return found
+}
+
+// fetchBackground Background Palette Index (Palette << 2 | Color) of Each
+// Pixel on the Current Line, Walking v Across 33 Tiles to Cover Fine X
+func (p *ppu) fetchBackground(bg *[screenWidth]byte) {
+	v := p.v
+	table := uint16(0)
+	if p.ctrl&ctrlBgTable != 0 {
+		table = 0x1000
+	}
+	for i := 0; i < 33; i++ {
+		tile := uint16(p.readVRAM(0x2000 | v&0x0FFF))
+		attr := p.readVRAM(0x23C0 | v&0x0C00 | v>>4&0x38 | v>>2&0x07)
+		pal := attr >> (v>>4&0x04 | v&0x02) & 0x03
+		addr := table + tile*16 + v>>12
+		lo, hi := p.readVRAM(addr), p.readVRAM(addr+8)
+		for b := 0; b < 8; b++ {
+			x := i*8 + b - int(p.x)
+			if x < 0 || x >= screenWidth {
+				continue
+			}
+			bit := uint(7 - b)
+			if px := lo>>bit&1 | hi>>bit&1<<1; px != 0 {
+				bg[x] = pal<<2 | px
+			}
+		}
+		if v&0x001F == 31 {
+			v = v&^0x001F ^ 0x0400
+		} else {
+			v++
+		}
+	}
+}
+
+// renderLine Draw the Current Line into the Image, Noting Where Sprite 0
+// Hits
+func (p *ppu) renderLine() {
+	var bg [screenWidth]byte
+	if p.mask&maskBg != 0 {
+		p.fetchBackground(&bg)
+	}
+	var sprites []lineSprite
+	if p.mask&maskSprites != 0 {
+		sprites = p.evaluateSprites(p.line)
+	}
+
+	p.hitDot = -1
+	row := p.image.Pix[p.line*p.image.Stride:]
+	for x := 0; x < screenWidth; x++ {
+		b := bg[x]
+		if x < 8 && p.mask&maskBgLeft == 0 {
+			b = 0
+		}
+		color := byte(0)
+		if b&0x03 != 0 {
+			color = b
+		}
+		if x >= 8 || p.mask&maskSpriteLeft != 0 {
+			for i := range sprites {
+				sp := &sprites[i]
+				px := sp.pixel(x)
+				if px == 0 {
+					continue
+				}
+				if sp.index == 0 && b&0x03 != 0 && x != 255 && p.hitDot < 0 {
+					p.hitDot = x + 1
+				}
+				if !sp.behind || b&0x03 == 0 {
+					color = 0x10 | sp.palette<<2 | px
+				}
+				break
+			}
+		}
+
+		c := p.palette[color]
+		if p.mask&maskGray != 0 {
+			c &= 0x30
+		}
+		rgb := nesPalette[c&0x3F]
+		copy(row[x*4:], []byte{byte(rgb >> 16), byte(rgb >> 8), byte(rgb), 0xFF})
+	}
+}
+
+// writePNG Save a Frame
+func writePNG(name string, img image.Image) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// nesPalette RGB of the 2C02's 64 Colors
+var nesPalette = [64]uint32{
+	0x666666, 0x002A88, 0x1412A7, 0x3B00A4, 0x5C007E, 0x6E0040, 0x6C0600, 0x561D00,
+	0x333500, 0x0B4800, 0x005200, 0x004F08, 0x00404D, 0x000000, 0x000000, 0x000000,
+	0xADADAD, 0x155FD9, 0x4240FF, 0x7527FE, 0xA01ACC, 0xB71E7B, 0xB53120, 0x994E00,
+	0x6B6D00, 0x388700, 0x0C9300, 0x008F32, 0x007C8D, 0x000000, 0x000000, 0x000000,
+	0xFFFEFF, 0x64B0FF, 0x9290FF, 0xC676FF, 0xF36AFF, 0xFE6ECC, 0xFE8170, 0xEA9E22,
+	0xBCBE00, 0x88D800, 0x5CE430, 0x45E082, 0x48CDDE, 0x4F4F4F, 0x000000, 0x000000,
+	0xFFFEFF, 0xC0DFFF, 0xD3D2FF, 0xE8C8FF, 0xFBC2FF, 0xFEC4EA, 0xFECCC5, 0xF7D8A5,
+	0xE4E594, 0xCFEF96, 0xBDF4AB, 0xB3F3CC, 0xB5EBF2, 0xB8B8B8, 0x000000, 0x000000,
+}
+
+// PPU State
+
+func (p *ppu) save(w *stateWriter) {
+	for _, v := range []byte{p.ctrl, p.mask, p.status, p.oamAddr, p.x, p.buffer, p.latch} {
+		w.u8(v)
+	}
+	w.u16(p.v)
+	w.u16(p.t)
+	w.bool(p.w)
+	w.u16(uint16(p.dot))
+	w.u16(uint16(p.line))
+	w.u16(uint16(p.hitDot + 1))
+	w.bool(p.odd)
+	w.u64(p.frame)
+	w.block(p.oam[:])
+	w.block(p.vram[:])
+	w.block(p.palette[:])
+}
+
+func (p *ppu) load(r *stateReader) {
+	for _, v := range []*byte{&p.ctrl, &p.mask, &p.status, &p.oamAddr, &p.x, &p.buffer, &p.latch} {
+		*v = r.u8()
+	}
+	p.v = r.u16()
+	p.t = r.u16()
+	p.w = r.bool()
+	p.dot = int(r.u16())
+	p.line = int(r.u16())
+	p.hitDot = int(r.u16()) - 1
+	p.odd = r.bool()
+	p.frame = r.u64()
+	r.block(p.oam[:])
+	r.block(p.vram[:])
+	r.block(p.palette[:])
+}
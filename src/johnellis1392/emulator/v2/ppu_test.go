@@ -0,0 +1,236 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// ppuTestCHR Tile 1 Solid Color 1, Tile 2 Solid Color 3, Tile 3 Two Columns
+// of Color 1 on the Left and Two of Color 2 on the Right
+func ppuTestCHR() []byte {
+	chr := make([]byte, chrBankSize)
+	for row := 0; row < 8; row++ {
+		chr[1*16+row] = 0xFF
+		chr[2*16+row], chr[2*16+8+row] = 0xFF, 0xFF
+		chr[3*16+row], chr[3*16+8+row] = 0xC0, 0x03
+	}
+	return chr
+}
+
+// ppuTestSystem NES Running testdata/ppu.s
+func ppuTestSystem(t *testing.T) *system {
+	t.Helper()
+	src, err := ioutil.ReadFile("testdata/ppu.s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	image, err := mustAssemble(t, string(src)).ines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(image[len(image)-chrBankSize:], ppuTestCHR())
+	return newNES(loadMapper(t, image))
+}
+
+// runFrames Run Until the PPU Finishes Frame n, Returning a Copy of it
+func runFrames(s *system, n uint64) *image.RGBA {
+	var frame *image.RGBA
+	s.ppu.onFrame = func(img *image.RGBA, f uint64) {
+		if f == n {
+			frame = image.NewRGBA(img.Bounds())
+			copy(frame.Pix, img.Pix)
+		}
+	}
+	for frame == nil {
+		s.exec()
+	}
+	return frame
+}
+
+func nesColor(c byte) color.RGBA {
+	rgb := nesPalette[c]
+	return color.RGBA{byte(rgb >> 16), byte(rgb >> 8), byte(rgb), 0xFF}
+}
+
+// diffImages First Pixel Where a and b Differ
+func diffImages(a, b image.Image) (image.Point, bool) {
+	if a.Bounds() != b.Bounds() {
+		return a.Bounds().Min, false
+	}
+	r := a.Bounds()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if color.RGBAModel.Convert(a.At(x, y)) != color.RGBAModel.Convert(b.At(x, y)) {
+				return image.Pt(x, y), false
+			}
+		}
+	}
+	return image.Point{}, true
+}
+
+func Test_PPU_ShouldRenderTestProgram(t *testing.T) {
+	s := ppuTestSystem(t)
+	frame := runFrames(s, 5)
+
+	cases := []struct {
+		name string
+		x, y int
+		want byte
+	}{
+		{"Row 0 Under Attribute Palette 1", 0, 0, 0x2A},
+		{"Row 0 Under Palette 0", 40, 0, 0x16},
+		{"Backdrop", 0, 8, 0x0F},
+		{"Flipped Sprite, Color 2", 40, 4, 0x21},
+		{"Flipped Sprite, Color 1", 47, 11, 0x00},
+		{"Sprite's Transparent Middle", 43, 4, 0x16},
+		{"Row 10 Before the Split Would Start Opaque", 0, 80, 0x18},
+		{"Row 10 Scrolled Left by 4", 4, 80, 0x0F},
+		{"Row 10 Scrolled, Next Tile", 12, 87, 0x18},
+	}
+	for _, c := range cases {
+		if got := frame.RGBAAt(c.x, c.y); got != nesColor(c.want) {
+			t.Errorf("%s: expected $%02X %v at (%d, %d), got: %v", c.name, c.want, nesColor(c.want), c.x, c.y, got)
+		}
+	}
+	if s.read(0x0000) < 2 {
+		t.Errorf("Expected the NMI handler to count frames, got: %d", s.read(0x0000))
+	}
+}
+
+func Test_PPU_ShouldMatchGoldenScreenshot(t *testing.T) {
+	frame := runFrames(ppuTestSystem(t), 5)
+	f, err := os.Open("testdata/ppu.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	golden, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at, ok := diffImages(frame, golden); !ok {
+		name := t.TempDir() + "/ppu.png"
+		writePNG(name, frame)
+		t.Errorf("Frame differs from testdata/ppu.png at %v; got %s", at, name)
+	}
+}
+
+func Test_PPU_ShouldSetSprite0HitOnce(t *testing.T) {
+	s := ppuTestSystem(t)
+	runFrames(s, 3)
+	for s.ppu.line != 4 {
+		s.exec()
+	}
+	for s.ppu.line == 4 && s.ppu.status&statusHit == 0 {
+		s.exec()
+	}
+	if s.ppu.status&statusHit == 0 || s.ppu.dot <= 41 {
+		t.Errorf("Expected sprite 0 hit on line 4 after dot 41, got: line %d dot %d", s.ppu.line, s.ppu.dot)
+	}
+	for s.ppu.line != preRenderLine || s.ppu.dot < 2 {
+		s.exec()
+	}
+	if s.ppu.status&statusHit != 0 {
+		t.Errorf("Expected sprite 0 hit cleared on the pre-render line")
+	}
+}
+
+func Test_PPU_ShouldReadAndWriteVRAMThroughRegisters(t *testing.T) {
+	s := newNES(loadMapper(t, inesImage(0, 1, 0, 0x01))) // CHR RAM, Vertical
+	write := func(addr uint16, v ...byte) {
+		for _, b := range v {
+			s.write(addr, b)
+		}
+	}
+
+	write(0x2006, 0x24, 0x00)
+	write(0x2007, 0x11, 0x22)
+	write(0x2006, 0x2C, 0x00) // $2C00 Mirrors $2400 Vertically
+	if first, second := s.read(0x2007), s.read(0x2007); first == 0x11 || second != 0x11 {
+		t.Errorf("Expected reads buffered by one, got: $%02X $%02X", first, second)
+	}
+
+	write(0x2000, ctrlIncrement32)
+	write(0x2006, 0x00, 0x00)
+	write(0x2007, 0xAA, 0xBB)
+	if s.ppu.mapper.ReadCHR(0x0000) != 0xAA || s.ppu.mapper.ReadCHR(0x0020) != 0xBB {
+		t.Errorf("Expected +32 increments into CHR RAM")
+	}
+
+	write(0x2000, 0)
+	write(0x2006, 0x3F, 0x10)
+	write(0x2007, 0x2C)
+	write(0x2006, 0x3F, 0x00)
+	if v := s.read(0x2007); v != 0x2C {
+		t.Errorf("Expected $3F10 to mirror $3F00 and palette reads unbuffered, got: $%02X", v)
+	}
+
+	// A $2002 Read Resets the Write Toggle
+	write(0x2006, 0x21)
+	s.read(0x2002)
+	write(0x2006, 0x23, 0x45)
+	if s.ppu.v != 0x2345 {
+		t.Errorf("Expected v=$2345 after resetting the toggle, got: $%04X", s.ppu.v)
+	}
+}
+
+func Test_PPU_ShouldRaiseNMIAtVBlankOnlyWhenEnabled(t *testing.T) {
+	s := newNES(loadMapper(t, inesImage(0, 1, 1, 0)))
+	p := s.ppu
+	for p.line != vblankLine || p.dot < 2 {
+		p.step()
+	}
+	if p.status&statusVBlank == 0 || s.cpu.nmi {
+		t.Fatalf("Expected VBlank Without NMI While Disabled")
+	}
+	p.writeRegister(0, ctrlNMI)
+	if !s.cpu.nmi {
+		t.Errorf("Expected enabling NMI during VBlank to raise it")
+	}
+	s.cpu.nmi = false
+	if v := p.readRegister(2); v&statusVBlank == 0 || p.status&statusVBlank != 0 || s.cpu.nmiLine {
+		t.Errorf("Expected $2002 to report, then clear, VBlank and drop NMI, got: $%02X", v)
+	}
+}
+
+func Test_PPU_ShouldCopyOAMWithDMA(t *testing.T) {
+	s := newNES(loadMapper(t, inesImage(0, 1, 1, 0)))
+	for i := 0; i < 0x100; i++ {
+		s.write(0x0300+uint16(i), byte(i))
+	}
+	s.write(0x2003, 0x10)
+	for _, start := range []uint64{100, 101} {
+		s.cpu.clk = start
+		s.write(oamDMAAddr, 0x03)
+		if want := start + 513 + start%2; s.cpu.clk != want {
+			t.Errorf("Expected DMA from cycle %d to end at %d, got: %d", start, want, s.cpu.clk)
+		}
+	}
+	if s.ppu.oam[0x10] != 0x00 || s.ppu.oam[0x0F] != 0xFF || s.ppu.oamAddr != 0x10 {
+		t.Errorf("Expected OAM filled from OAMADDR, wrapping")
+	}
+}
+
+func Test_PPU_ShouldFlagSpriteOverflowAndFetchTallSprites(t *testing.T) {
+	s := newNES(loadMapper(t, inesImage(0, 1, 1, 0)))
+	p := s.ppu
+	for i := range p.oam {
+		p.oam[i] = 0xFF
+	}
+	for i := 0; i < 9; i++ {
+		copy(p.oam[i*4:], []byte{19, 0x03, 0x00, byte(i * 8)})
+	}
+	p.ctrl = ctrlTallSprites
+	found := p.evaluateSprites(20 + 8) // Ninth Row: the Bottom Tile
+	if len(found) != 8 || p.status&statusOverflow == 0 {
+		t.Errorf("Expected 8 sprites and overflow, got: %d, status $%02X", len(found), p.status)
+	}
+	// Tile $03 in 8x16 Mode: Table $1000, Tiles 2 and 3; CHR Bank 0 is $80
+	if found[0].lo != 0x80 {
+		t.Errorf("Expected bottom tile fetched from the second table, got: $%02X", found[0].lo)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import "errors"
+
+// frameCycles CPU Cycles in an NTSC Frame, Rounded; Rewind Snapshots are
+// Taken this Far Apart
+const frameCycles = 29781
+
+var errNoRewind = errors.New("rewind: no earlier snapshots")
+
+// rewind Ring Buffer of Periodic Snapshots, Oldest Overwritten First
+type rewind struct {
+	states []snapshot
+	next   int    // Slot the Next Snapshot Goes in
+	count  int    // Slots in Use
+	every  uint64 // Cycles Between Snapshots
+}
+
+type snapshot struct {
+	clk  uint64
+	data []byte
+}
+
+// newRewind Keep up to size Snapshots, Taken every Cycles Apart
+func newRewind(size int, every uint64) *rewind {
+	return &rewind{states: make([]snapshot, size), every: every}
+}
+
+// record Snapshot s if every Cycles Have Passed Since the Last Snapshot;
+// Call Between Instructions
+func (r *rewind) record(s *system) {
+	if len(r.states) == 0 || r.count > 0 && s.cpu.clk < r.newest().clk+r.every {
+		return
+	}
+	r.states[r.next] = snapshot{s.cpu.clk, s.saveState()}
+	r.next = (r.next + 1) % len(r.states)
+	if r.count < len(r.states) {
+		r.count++
+	}
+}
+
+// back Restore s to the nth Snapshot Before its Current Clock, or the
+// Oldest if there Aren't that Many, and Forget the Ones After it. Returns
+// How Far it Went.
+func (r *rewind) back(s *system, n int) (int, error) {
+	for r.count > 0 && r.newest().clk >= s.cpu.clk {
+		r.pop()
+	}
+	if r.count == 0 {
+		return 0, errNoRewind
+	}
+	if n > r.count {
+		n = r.count
+	}
+	for i := 1; i < n; i++ {
+		r.pop()
+	}
+	return n, s.loadState(r.newest().data)
+}
+
+func (r *rewind) newest() *snapshot {
+	return &r.states[(r.next+len(r.states)-1)%len(r.states)]
+}
+
+func (r *rewind) pop() {
+	*r.newest() = snapshot{}
+	r.next = (r.next + len(r.states) - 1) % len(r.states)
+	r.count--
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// Save States
+//
+//	[magic 8][version 1][CPU][Bus Components...][Devices...][Pads...]
+//
+// The CPU is Written First, then Every Component Holding State, in the
+// Order the Bus and the Device List Reach Them: RAM, Cartridge Banks and
+// Registers, and so on. Nothing Names the Blocks, so a State Only Loads
+// into a System Built the Same Way; RAM Blocks Carry their Length, and the
+// Cartridge its PRG Size, to Catch Mismatches.
+
+var stateMagic = []byte("SAV6502\x1A")
+
+// stateVersion Bump Whenever the Layout Changes
+const stateVersion = 4
+
+var (
+	errNotState       = errors.New("state: not a save state")
+	errStateVersion   = errors.New("state: unsupported version")
+	errStateTruncated = errors.New("state: truncated")
+)
+
+// snapshotter Component with State to Save and Restore
+type snapshotter interface {
+	save(w *stateWriter)
+	load(r *stateReader)
+}
+
+// saveAny / loadAny Save or Load v if it Holds State
+func saveAny(w *stateWriter, v interface{}) {
+	if sn, ok := v.(snapshotter); ok {
+		sn.save(w)
+	}
+}
+
+func loadAny(r *stateReader, v interface{}) {
+	if sn, ok := v.(snapshotter); ok {
+		sn.load(r)
+	}
+}
+
+// stateWriter Little-Endian Encoder for Save States
+type stateWriter struct {
+	bytes.Buffer
+}
+
+func (w *stateWriter) u8(v byte)    { w.WriteByte(v) }
+func (w *stateWriter) u16(v uint16) { w.Write([]byte{byte(v), byte(v >> 8)}) }
+func (w *stateWriter) u32(v uint32) { w.u16(uint16(v)); w.u16(uint16(v >> 16)) }
+func (w *stateWriter) u64(v uint64) { w.u32(uint32(v)); w.u32(uint32(v >> 32)) }
+
+func (w *stateWriter) bool(v bool) {
+	if v {
+		w.u8(1)
+	} else {
+		w.u8(0)
+	}
+}
+
+// block Write a Length-Prefixed Byte Slice
+func (w *stateWriter) block(b []byte) {
+	w.u32(uint32(len(b)))
+	w.Write(b)
+}
+
+// stateReader Decoder Matching stateWriter. The First Error Sticks, and
+// Later Reads Return Zeroes.
+type stateReader struct {
+	data []byte
+	err  error
+}
+
+func (r *stateReader) take(n int) []byte {
+	if r.err == nil && len(r.data) < n {
+		r.err = errStateTruncated
+	}
+	if r.err != nil {
+		return make([]byte, n)
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *stateReader) u8() byte { return r.take(1)[0] }
+
+func (r *stateReader) u16() uint16 {
+	b := r.take(2)
+	return uint16(b[0]) | uint16(b[1])<<8
+}
+
+func (r *stateReader) u32() uint32 { return uint32(r.u16()) | uint32(r.u16())<<16 }
+func (r *stateReader) u64() uint64 { return uint64(r.u32()) | uint64(r.u32())<<32 }
+func (r *stateReader) bool() bool  { return r.u8() != 0 }
+
+// block Read a Length-Prefixed Block into dst, Which Must be the Same Size
+func (r *stateReader) block(dst []byte) {
+	n := int(r.u32())
+	if r.err == nil && n != len(dst) {
+		r.err = fmt.Errorf("state: %d byte block where %d expected", n, len(dst))
+	}
+	copy(dst, r.take(n))
+}
+
+// saveState Snapshot the Whole System
+func (s *system) saveState() []byte {
+	w := &stateWriter{}
+	w.Write(stateMagic)
+	w.u8(stateVersion)
+
+	c := &s.cpu
+	w.u8(c.ac)
+	w.u8(c.xr)
+	w.u8(c.yr)
+	w.u8(c.sr.pack())
+	w.u16(c.pc)
+	w.u8(c.sp)
+	w.u64(c.clk)
+	w.bool(c.decimal)
+	w.bool(c.nmiLine)
+	w.bool(c.nmi)
+	w.u8(c.irq)
+
+	saveAny(w, s.bus)
+	for _, d := range s.devices {
+		saveAny(w, d)
+	}
+	for _, p := range s.pads {
+		if p != nil {
+			p.save(w)
+		}
+	}
+	return w.Bytes()
+}
+
+// loadState Restore a Snapshot from saveState. On Error the System is
+// Left as it Was.
+func (s *system) loadState(data []byte) error {
+	if len(data) <= len(stateMagic) || !bytes.Equal(data[:len(stateMagic)], stateMagic) {
+		return errNotState
+	}
+	if data[len(stateMagic)] != stateVersion {
+		return errStateVersion
+	}
+	backup := s.saveState()
+	if err := s.restore(data[len(stateMagic)+1:]); err != nil {
+		s.restore(backup[len(stateMagic)+1:])
+		return err
+	}
+	return nil
+}
+
+func (s *system) restore(data []byte) error {
+	r := &stateReader{data: data}
+	c := &s.cpu
+	c.ac = r.u8()
+	c.xr = r.u8()
+	c.yr = r.u8()
+	c.sr.set(r.u8())
+	c.pc = r.u16()
+	c.sp = r.u8()
+	c.clk = r.u64()
+	c.decimal = r.bool()
+	c.nmiLine = r.bool()
+	c.nmi = r.bool()
+	c.irq = r.u8()
+	c.takeNMI, c.takeIRQ = false, false
+
+	loadAny(r, s.bus)
+	for _, d := range s.devices {
+		loadAny(r, d)
+	}
+	for _, p := range s.pads {
+		if p != nil {
+			p.load(r)
+		}
+	}
+	if r.err == nil && len(r.data) > 0 {
+		r.err = fmt.Errorf("state: %d bytes left over", len(r.data))
+	}
+	return r.err
+}
+
+// saveStateFile / loadStateFile Save States on Disk
+func (s *system) saveStateFile(name string) error {
+	return ioutil.WriteFile(name, s.saveState(), 0644)
+}
+
+func (s *system) loadStateFile(name string) error {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	if err := s.loadState(data); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
+
+// Bus Components
+
+func (r ram) save(w *stateWriter)  { w.block(r) }
+func (r ram) load(rd *stateReader) { rd.block(r) }
+
+func (m mirror) save(w *stateWriter) { saveAny(w, m.Bus) }
+func (m mirror) load(r *stateReader) { loadAny(r, m.Bus) }
+
+func (a *addressSpace) save(w *stateWriter) {
+	for _, reg := range a.regions {
+		saveAny(w, reg.bus)
+	}
+}
+
+func (a *addressSpace) load(r *stateReader) {
+	for _, reg := range a.regions {
+		loadAny(r, reg.bus)
+	}
+}
+
+func (b prgBus) save(w *stateWriter) { saveAny(w, b.Mapper) }
+func (b prgBus) load(r *stateReader) { loadAny(r, b.Mapper) }
+
+// Mappers
+
+func (b *board) save(w *stateWriter) {
+	w.u32(uint32(len(b.prg)))
+	w.block(b.ram)
+	if b.chrIsRAM {
+		w.block(b.chr)
+	}
+}
+
+func (b *board) load(r *stateReader) {
+	if n := int(r.u32()); r.err == nil && n != len(b.prg) {
+		r.err = fmt.Errorf("state: saved with %d bytes of PRG ROM, cartridge has %d", n, len(b.prg))
+	}
+	r.block(b.ram)
+	if b.chrIsRAM {
+		r.block(b.chr)
+	}
+}
+
+func (m *uxrom) save(w *stateWriter) {
+	m.board.save(w)
+	w.u32(uint32(m.bank))
+}
+
+func (m *uxrom) load(r *stateReader) {
+	m.board.load(r)
+	m.bank = int(r.u32())
+}
+
+func (m *cnrom) save(w *stateWriter) {
+	m.board.save(w)
+	w.u32(uint32(m.bank))
+}
+
+func (m *cnrom) load(r *stateReader) {
+	m.board.load(r)
+	m.bank = int(r.u32())
+}
+
+func (m *mmc1) save(w *stateWriter) {
+	m.board.save(w)
+	for _, v := range []byte{m.shift, m.count, m.control, m.chr0, m.chr1, m.prgBank} {
+		w.u8(v)
+	}
+}
+
+func (m *mmc1) load(r *stateReader) {
+	m.board.load(r)
+	for _, v := range []*byte{&m.shift, &m.count, &m.control, &m.chr0, &m.chr1, &m.prgBank} {
+		*v = r.u8()
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+// stateNES MMC1 System with Some of Everything Changed from Power-On
+func stateNES(t *testing.T) (*system, *mmc1) {
+	m := loadMapper(t, inesImage(1, 4, 0, 0)).(*mmc1)
+	s := newNES(m)
+	s.cpu.ac, s.cpu.xr, s.cpu.yr, s.cpu.pc, s.cpu.sp, s.cpu.clk = 1, 2, 3, 0x8123, 0xF0, 12345
+	s.cpu.sr.c, s.cpu.sr.v = true, true
+	s.setIRQ(0x01, true)
+	s.write(0x0042, 0x99)
+	s.write(0x6000, 0x77)
+	mmc1Write(m, 0xE000, 0x02)
+	m.WriteCHR(0x0010, 0x55)
+	m.WritePRG(0x8000, 0x01) // Half-Loaded Shift Register
+	return s, m
+}
+
+func Test_System_State_ShouldRoundTrip(t *testing.T) {
+	s, m := stateNES(t)
+	saved := s.saveState()
+
+	s.cpu = cpu{}
+	s.write(0x0042, 0)
+	s.write(0x6000, 0)
+	m.WritePRG(0x8000, 0x80) // Reset the Shift Register
+	mmc1Write(m, 0xE000, 0x00)
+	m.WriteCHR(0x0010, 0)
+
+	if err := s.loadState(saved); err != nil {
+		t.Fatal(err)
+	}
+	if got := newDebugger(s, nil).regs(); got != "PC=$8123 A=$01 X=$02 Y=$03 SP=$F0 P=$65 [nV-bdIzC] CYC=12345" {
+		t.Errorf("Expected registers restored, got: %s", got)
+	}
+	if s.cpu.irq != 0x01 {
+		t.Errorf("Expected IRQ line restored")
+	}
+	if s.read(0x0042) != 0x99 || s.read(0x6000) != 0x77 || m.ReadCHR(0x0010) != 0x55 {
+		t.Errorf("Expected RAM, PRG RAM and CHR RAM restored")
+	}
+	if m.prgBank != 0x02 || m.shift != 0x01 || m.count != 1 || s.read(0x8000) != 2 {
+		t.Errorf("Expected mapper registers restored, got: bank=%d shift=%d count=%d", m.prgBank, m.shift, m.count)
+	}
+	if !bytes.Equal(s.saveState(), saved) {
+		t.Errorf("Expected saving again to give the same state")
+	}
+}
+
+func Test_System_State_ShouldRoundTripThroughFile(t *testing.T) {
+	s, _ := stateNES(t)
+	name := filepath.Join(t.TempDir(), "test.sav")
+	if err := s.saveStateFile(name); err != nil {
+		t.Fatal(err)
+	}
+	s.write(0x0042, 0)
+	if err := s.loadStateFile(name); err != nil {
+		t.Fatal(err)
+	}
+	if s.read(0x0042) != 0x99 {
+		t.Errorf("Expected RAM restored from file")
+	}
+}
+
+func Test_System_State_ShouldRejectBadStatesUnchanged(t *testing.T) {
+	s, _ := stateNES(t)
+	good := s.saveState()
+
+	version := append([]byte{}, good...)
+	version[len(stateMagic)]++
+	other, _ := loadMapper(t, inesImage(1, 2, 0, 0)).(*mmc1)
+	otherState := newNES(other).saveState()
+
+	cases := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"Not a State", []byte("NES\x1A"), errNotState.Error()},
+		{"Version", version, errStateVersion.Error()},
+		{"Truncated", good[:len(good)-10], errStateTruncated.Error()},
+		{"Extra Bytes", append(append([]byte{}, good...), 0), "left over"},
+		{"Other Cartridge", otherState, "PRG ROM"},
+	}
+	for _, c := range cases {
+		s.write(0x0042, 0x99)
+		err := s.loadState(c.data)
+		if err == nil || !bytes.Contains([]byte(err.Error()), []byte(c.want)) {
+			t.Errorf("%s: expected error containing %q, got: %v", c.name, c.want, err)
+		}
+		if !bytes.Equal(s.saveState(), good) {
+			t.Errorf("%s: expected system unchanged", c.name)
+		}
+	}
+}
+
+func Test_Rewind_ShouldStepBackBySnapshots(t *testing.T) {
+	s := newTestSystem([]byte{0xE8, 0x4C, 0x00, 0x80}) // INX; JMP $8000
+	r := newRewind(3, 10)
+	if _, err := r.back(s, 1); err != errNoRewind {
+		t.Errorf("Expected nothing to rewind, got: %v", err)
+	}
+
+	// Snapshots at Cycles 0, 10, 20, 30 and 40; the Ring Keeps the Last 3
+	var at = map[uint64]byte{}
+	for s.cpu.clk < 45 {
+		r.record(s)
+		if r.newest().clk == s.cpu.clk {
+			at[s.cpu.clk] = s.cpu.xr
+		}
+		s.exec()
+	}
+	if r.count != 3 || r.newest().clk != 40 {
+		t.Fatalf("Expected 3 snapshots ending at cycle 40, got: %d ending at %d", r.count, r.newest().clk)
+	}
+
+	n, err := r.back(s, 1)
+	if err != nil || n != 1 || s.cpu.clk != 40 || s.cpu.xr != at[40] {
+		t.Errorf("Expected back to cycle 40, got: n=%d CYC=%d X=%d err=%v", n, s.cpu.clk, s.cpu.xr, err)
+	}
+	n, err = r.back(s, 5)
+	if err != nil || n != 2 || s.cpu.clk != 20 || s.cpu.xr != at[20] {
+		t.Errorf("Expected back to the oldest, cycle 20, got: n=%d CYC=%d X=%d err=%v", n, s.cpu.clk, s.cpu.xr, err)
+	}
+	if _, err := r.back(s, 1); err != errNoRewind {
+		t.Errorf("Expected nothing earlier, got: %v", err)
+	}
+}
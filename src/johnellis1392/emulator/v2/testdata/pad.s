@@ -0,0 +1,37 @@
+; Controller Test Program: Each NMI Reads Pad 1 and Adds it to a Sum
+PPUCTRL = $2000
+JOY1    = $4016
+sum     = $10   ; 16 Bits
+last    = $12
+frames  = $13
+
+	.org $C000
+reset:	SEI
+	LDX #$FF
+	TXS
+	LDA #$80
+	STA PPUCTRL
+loop:	JMP loop
+
+nmi:	LDA #$01
+	STA JOY1
+	LDA #$00
+	STA JOY1
+	LDX #$08
+read:	LDA JOY1
+	LSR A
+	ROR last
+	DEX
+	BNE read
+	LDA last
+	CLC
+	ADC sum
+	STA sum
+	LDA #$00
+	ADC sum+1
+	STA sum+1
+	INC frames
+irq:	RTI
+
+	.org $FFFA
+	.word nmi, reset, irq
@@ -0,0 +1,119 @@
+; PPU Test Program: a Row of Tiles, a Sprite Over it and a Scroll Split
+PPUCTRL   = $2000
+PPUMASK   = $2001
+PPUSTATUS = $2002
+PPUSCROLL = $2005
+PPUADDR   = $2006
+PPUDATA   = $2007
+OAMDMA    = $4014
+frames    = $00
+shadow    = $0200
+
+	.org $C000
+reset:	SEI
+	LDX #$FF
+	TXS
+	INX
+	STX PPUCTRL
+	STX PPUMASK
+
+	; Two VBlanks for the PPU to Warm Up
+vwait1:	BIT PPUSTATUS
+	BPL vwait1
+vwait2:	BIT PPUSTATUS
+	BPL vwait2
+
+	; Palettes
+	LDA #$3F
+	STA PPUADDR
+	LDA #$00
+	STA PPUADDR
+	LDX #0
+pal:	LDA palettes,X
+	STA PPUDATA
+	INX
+	CPX #32
+	BNE pal
+
+	; Top Row of Tile 1, Then Tile 2 in Every Other Column of Row 10
+	LDA #$20
+	STA PPUADDR
+	LDA #$00
+	STA PPUADDR
+	LDA #1
+	LDX #32
+row0:	STA PPUDATA
+	DEX
+	BNE row0
+	LDA #$21
+	STA PPUADDR
+	LDA #$40
+	STA PPUADDR
+	LDX #16
+row10:	LDA #2
+	STA PPUDATA
+	LDA #0
+	STA PPUDATA
+	DEX
+	BNE row10
+
+	; Attribute: Palette 1 for the Top-Left 32x32
+	LDA #$23
+	STA PPUADDR
+	LDA #$C0
+	STA PPUADDR
+	LDA #%00000001
+	STA PPUDATA
+
+	; Sprites: Hide All, then Sprite 0 Over Row 0 at (40, 4), Flipped
+	LDA #$FF
+	LDX #0
+hide:	STA shadow,X
+	INX
+	BNE hide
+	LDA #3
+	STA shadow+0
+	LDA #3
+	STA shadow+1
+	LDA #%01000001
+	STA shadow+2
+	LDA #40
+	STA shadow+3
+
+	LDA #>shadow
+	STA OAMDMA
+	LDA #0
+	STA PPUSCROLL
+	STA PPUSCROLL
+	LDA #%10000000
+	STA PPUCTRL
+	LDA #%00011110
+	STA PPUMASK
+
+	; Scroll Everything Below Sprite 0 Left by 4 Pixels
+loop:	BIT PPUSTATUS
+	BVS loop
+hit:	BIT PPUSTATUS
+	BVC hit
+	LDA #4
+	STA PPUSCROLL
+	LDA #0
+	STA PPUSCROLL
+	JMP loop
+
+nmi:	PHA
+	LDA #0
+	STA PPUSCROLL
+	STA PPUSCROLL
+	PLA
+	INC frames
+	RTI
+
+irq:	RTI
+
+palettes:
+	.byte $0F, $16, $27, $18,  $0F, $2A, $11, $30,  $0F, $00, $00, $00,  $0F, $00, $00, $00
+	.byte $0F, $00, $00, $00,  $0F, $00, $21, $01,  $0F, $00, $00, $00,  $0F, $00, $00, $00
+
+	.org $FFFA
+	.word nmi, reset, irq
@@ -0,0 +1,34 @@
+; Golden Trace Program: Exercises Each Addressing Mode's Annotation
+; trace.log is this Emulator's Own Output, a Regression Check, Not a Reference
+ptr = $10
+
+	.org $8000
+start:	LDX #$02
+	LDY #$01
+	LDA #$34
+	STA ptr
+	LDA #$02
+	STA ptr+1
+	LDA #$5A
+	STA $0235
+	LDA ($0E,X)
+	LDA (ptr),Y
+	LDA ptr,X
+	LDX $10,Y
+	LDA $0233,X
+	LDA $0234,Y
+	LDA $0235
+	ASL A
+	JSR count
+	LDA #<done
+	STA $0300
+	LDA #>done
+	STA $0301
+	JMP ($0300)
+count:	LDX #$03
+loop:	DEX
+	BNE loop
+	RTS
+done:	PHA
+	PLA
+	JMP done
@@ -0,0 +1,81 @@
+package main
+
+import "fmt"
+
+// Execution Trace in the Column Layout of nestest.log, One Line per
+// Instruction, Taken Before it Runs:
+//
+//	C000  4C F5 C5  JMP $C5F5                       A:00 X:00 Y:00 P:24 SP:FD CYC:7
+//	C5F7  86 00     STX $00 = 00                    A:00 X:00 Y:00 P:24 SP:FD CYC:13
+//	D959  A1 80     LDA ($80,X) @ 80 = 0200 = 5A    A:00 X:00 Y:00 P:24 SP:FD CYC:2188
+//
+// Operands Show the Memory they Touch, Read with inspect. Undocumented Opcodes are Marked
+// with a *. nestest.log Also has a PPU Column, Which we Don't Produce.
+
+// tracePointer zeroPage16 Without Taking CPU Cycles
+func (s *system) tracePointer(p byte) uint16 {
+	return uint16(s.inspect(uint16(p))) | uint16(s.inspect(uint16(p+1)))<<8
+}
+
+// traceLine Render the Instruction at the Program Counter and the CPU
+// State Before it Runs
+func (s *system) traceLine() string {
+	c := &s.cpu
+	op := s.inspect(c.pc)
+	in := instructionSet[op]
+
+	text, size := disassemble(s.inspect, c.pc)
+	mark := " "
+	if !documented(op) {
+		text, size, mark = in.name, 1, "*"
+	}
+	raw := ""
+	for i := 0; i < size; i++ {
+		raw += fmt.Sprintf("%02X ", s.inspect(c.pc+uint16(i)))
+	}
+	if in.name != "JMP" && in.name != "JSR" || in.mode == indirect {
+		text += s.traceMemory(in.mode)
+	}
+	return fmt.Sprintf("%04X  %-9s%s%-32sA:%02X X:%02X Y:%02X P:%02X SP:%02X CYC:%d",
+		c.pc, raw, mark, text, c.ac, c.xr, c.yr, c.sr.pack(), c.sp, c.clk)
+}
+
+// traceMemory Annotate an Operand with the Addresses it Resolves Through
+// and the Value There
+func (s *system) traceMemory(m mode) string {
+	c := &s.cpu
+	lo := s.inspect(c.pc + 1)
+	word := uint16(lo) | uint16(s.inspect(c.pc+2))<<8
+	switch m {
+	case zeroPage:
+		return fmt.Sprintf(" = %02X", s.inspect(uint16(lo)))
+	case zeroPageX, zeroPageY:
+		index := c.xr
+		if m == zeroPageY {
+			index = c.yr
+		}
+		addr := uint16(lo + index)
+		return fmt.Sprintf(" @ %02X = %02X", addr, s.inspect(addr))
+	case absolute:
+		return fmt.Sprintf(" = %02X", s.inspect(word))
+	case absoluteX, absoluteY:
+		index := c.xr
+		if m == absoluteY {
+			index = c.yr
+		}
+		addr := word + uint16(index)
+		return fmt.Sprintf(" @ %04X = %02X", addr, s.inspect(addr))
+	case indirect:
+		hi := word&0xFF00 | uint16(byte(word)+1)
+		return fmt.Sprintf(" = %04X", uint16(s.inspect(word))|uint16(s.inspect(hi))<<8)
+	case indirectX:
+		p := lo + c.xr
+		addr := s.tracePointer(p)
+		return fmt.Sprintf(" @ %02X = %04X = %02X", p, addr, s.inspect(addr))
+	case indirectY:
+		base := s.tracePointer(lo)
+		addr := base + uint16(c.yr)
+		return fmt.Sprintf(" = %04X @ %04X = %02X", base, addr, s.inspect(addr))
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+)
+
+// wavHeaderSize RIFF Header and fmt Chunk Before the Samples
+const wavHeaderSize = 44
+
+// wavWriter Streams Mono 16-Bit PCM to a WAV File. The Header's Sizes
+// Aren't Known Until the End, so Close Seeks Back to Fill Them in.
+type wavWriter struct {
+	w       io.WriteSeeker
+	buf     *bufio.Writer
+	samples uint32
+	err     error
+}
+
+func newWAVWriter(w io.WriteSeeker, rate int) (*wavWriter, error) {
+	ww := &wavWriter{w: w, buf: bufio.NewWriter(w)}
+	ww.header(rate)
+	return ww, ww.err
+}
+
+func (w *wavWriter) header(rate int) {
+	const channels, bits = 1, 16
+	h := make([]byte, wavHeaderSize)
+	copy(h[0:], "RIFF")
+	binary.LittleEndian.PutUint32(h[4:], 36+2*w.samples)
+	copy(h[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(h[16:], 16) // fmt Chunk Size
+	binary.LittleEndian.PutUint16(h[20:], 1)  // PCM
+	binary.LittleEndian.PutUint16(h[22:], channels)
+	binary.LittleEndian.PutUint32(h[24:], uint32(rate))
+	binary.LittleEndian.PutUint32(h[28:], uint32(rate*channels*bits/8))
+	binary.LittleEndian.PutUint16(h[32:], channels*bits/8)
+	binary.LittleEndian.PutUint16(h[34:], bits)
+	copy(h[36:], "data")
+	binary.LittleEndian.PutUint32(h[40:], 2*w.samples)
+	if _, err := w.buf.Write(h); err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// sample Append One Sample; Errors are Kept for Close
+func (w *wavWriter) sample(v int16) {
+	if w.err != nil {
+		return
+	}
+	if err := w.buf.WriteByte(byte(v)); err != nil {
+		w.err = err
+	} else if err := w.buf.WriteByte(byte(uint16(v) >> 8)); err != nil {
+		w.err = err
+	}
+	w.samples++
+}
+
+// Close Flush the Samples and Rewrite the Header with the Final Sizes; it
+// Doesn't Close the Underlying File
+func (w *wavWriter) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	if _, err := w.w.Seek(4, io.SeekStart); err != nil {
+		return err
+	}
+	var n [4]byte
+	binary.LittleEndian.PutUint32(n[:], 36+2*w.samples)
+	if _, err := w.w.Write(n[:]); err != nil {
+		return err
+	}
+	if _, err := w.w.Seek(40, io.SeekStart); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(n[:], 2*w.samples)
+	if _, err := w.w.Write(n[:]); err != nil {
+		return err
+	}
+	_, err := w.w.Seek(0, io.SeekEnd)
+	return err
+}
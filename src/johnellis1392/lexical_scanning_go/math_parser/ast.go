@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// node Expression Tree; pos is the Rune Offset Errors Point at
+type node interface {
+	fmt.Stringer
+	pos() int
+}
+
+type numberNode struct {
+	at  int
+	val float64
+}
+
+type varNode struct {
+	at   int
+	name string
+}
+
+type unaryNode struct {
+	at int
+	op tokenType
+	x  node
+}
+
+type binaryNode struct {
+	at   int // The Operator
+	op   tokenType
+	l, r node
+}
+
+type callNode struct {
+	at   int
+	name string
+	args []node
+}
+
+type assignNode struct {
+	at   int
+	name string
+	x    node
+}
+
+func (n numberNode) pos() int { return n.at }
+func (n varNode) pos() int    { return n.at }
+func (n unaryNode) pos() int  { return n.at }
+func (n binaryNode) pos() int { return n.at }
+func (n callNode) pos() int   { return n.at }
+func (n assignNode) pos() int { return n.at }
+
+// opSymbols Operator Spellings, for Printing Trees
+var opSymbols = map[tokenType]string{
+	tokenPlus:  "+",
+	tokenMinus: "-",
+	tokenMul:   "*",
+	tokenDiv:   "/",
+	tokenMod:   "%",
+	tokenPow:   "^",
+}
+
+// String Fully Parenthesized, so Trees Show their Shape
+func (n numberNode) String() string {
+	return strconv.FormatFloat(n.val, 'g', -1, 64)
+}
+
+func (n varNode) String() string {
+	return n.name
+}
+
+func (n unaryNode) String() string {
+	return fmt.Sprintf("(%s%v)", opSymbols[n.op], n.x)
+}
+
+func (n binaryNode) String() string {
+	return fmt.Sprintf("(%v %s %v)", n.l, opSymbols[n.op], n.r)
+}
+
+func (n callNode) String() string {
+	args := make([]string, len(n.args))
+	for i, a := range n.args {
+		args[i] = a.String()
+	}
+	return fmt.Sprintf("%s(%s)", n.name, strings.Join(args, ", "))
+}
+
+func (n assignNode) String() string {
+	return fmt.Sprintf("%s = %v", n.name, n.x)
+}
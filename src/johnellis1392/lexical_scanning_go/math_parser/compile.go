@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Bytecode
+//
+// A Program is a Stack Machine's Code for One Expression. Each Opcode is a
+// Byte, Followed by its Byte Operands:
+//
+//	const k     Push consts[k]
+//	param i     Push the i-th Parameter of the Current Binding
+//	load t      Push Temporary t
+//	tee t       Copy the Top of the Stack into Temporary t
+//	neg         Negate the Top of the Stack
+//	add ... pow Pop r, Pop l, Push l op r
+//	call f n    Pop n Arguments, Push funcs[f](args)
+//
+// The Compiler Folds Subexpressions Without Parameters into Constants, and
+// Evaluates Repeated Subexpressions Once, Keeping them in Temporaries.
+
+type opcode byte
+
+const (
+	opConst opcode = iota
+	opParam
+	opLoad
+	opTee
+	opNeg
+	opAdd
+	opSub
+	opMul
+	opDiv
+	opMod
+	opPow
+	opCall
+)
+
+// opInfo Mnemonic and Operand Count per Opcode
+var opInfo = [...]struct {
+	name     string
+	operands int
+}{
+	opConst: {"const", 1},
+	opParam: {"param", 1},
+	opLoad:  {"load", 1},
+	opTee:   {"tee", 1},
+	opNeg:   {"neg", 0},
+	opAdd:   {"add", 0},
+	opSub:   {"sub", 0},
+	opMul:   {"mul", 0},
+	opDiv:   {"div", 0},
+	opMod:   {"mod", 0},
+	opPow:   {"pow", 0},
+	opCall:  {"call", 2},
+}
+
+// binaryOps Opcode for Each Binary Operator
+var binaryOps = map[tokenType]opcode{
+	tokenPlus:  opAdd,
+	tokenMinus: opSub,
+	tokenMul:   opMul,
+	tokenDiv:   opDiv,
+	tokenMod:   opMod,
+	tokenPow:   opPow,
+}
+
+// maxOperand Byte Operands Limit Each Table to 256 Entries
+const maxOperand = 0xFF
+
+// program Compiled Expression
+type program struct {
+	code   []byte
+	consts []float64
+	funcs  []function
+	names  []string // Function Names, for Disassembly
+	params []string // Binding Order
+	temps  int
+	depth  int         // Most Values the Stack Holds
+	at     map[int]int // Offsets of div and mod to Input Positions
+}
+
+// compiler Builds a Program from a Folded Tree
+type compiler struct {
+	e      *env
+	p      *program
+	params map[string]int
+	funcs  map[string]int
+	counts map[string]int // Occurrences of Each Subexpression
+	temps  map[string]int // Subexpressions Already in Temporaries
+	depth  int
+}
+
+// compile Compile an Expression over params; Other Variables and Functions
+// are Resolved in e Now, so Later Changes to e Don't Affect the Program
+func compile(n node, params []string, e *env) (*program, error) {
+	c := &compiler{
+		e:      e,
+		p:      &program{params: params, at: map[int]int{}},
+		params: make(map[string]int, len(params)),
+		funcs:  map[string]int{},
+		counts: map[string]int{},
+		temps:  map[string]int{},
+	}
+	if len(params) > maxOperand+1 {
+		return nil, errorAt(n.pos(), "too many parameters")
+	}
+	for i, name := range params {
+		c.params[name] = i
+	}
+	n, err := c.fold(n)
+	if err != nil {
+		return nil, err
+	}
+	c.count(n)
+	if err := c.emit(n); err != nil {
+		return nil, err
+	}
+	return c.p, nil
+}
+
+// fold Replace Subexpressions Without Parameters by their Values
+func (c *compiler) fold(n node) (node, error) {
+	switch n := n.(type) {
+	case numberNode:
+		return n, nil
+
+	case varNode:
+		if _, ok := c.params[n.name]; ok {
+			return n, nil
+		}
+		v, ok := c.e.vars[n.name]
+		if !ok {
+			return nil, errorAt(n.at, "undefined variable %s", n.name)
+		}
+		return numberNode{n.at, v}, nil
+
+	case unaryNode:
+		x, err := c.fold(n.x)
+		if err != nil {
+			return nil, err
+		}
+		if k, ok := x.(numberNode); ok {
+			return numberNode{n.at, -k.val}, nil
+		}
+		return unaryNode{n.at, n.op, x}, nil
+
+	case binaryNode:
+		l, err := c.fold(n.l)
+		if err != nil {
+			return nil, err
+		}
+		r, err := c.fold(n.r)
+		if err != nil {
+			return nil, err
+		}
+		kl, lok := l.(numberNode)
+		kr, rok := r.(numberNode)
+		if lok && rok {
+			v, err := binary(n.op, kl.val, kr.val, n.at)
+			return numberNode{n.at, v}, err
+		}
+		return binaryNode{n.at, n.op, l, r}, nil
+
+	case callNode:
+		f, err := c.e.function(n)
+		if err != nil {
+			return nil, err
+		}
+		args := make([]node, len(n.args))
+		vals := make([]float64, len(n.args))
+		constant := true
+		for i, a := range n.args {
+			if args[i], err = c.fold(a); err != nil {
+				return nil, err
+			}
+			k, ok := args[i].(numberNode)
+			constant = constant && ok
+			vals[i] = k.val
+		}
+		if constant {
+			return numberNode{n.at, f.fn(vals)}, nil
+		}
+		return callNode{n.at, n.name, args}, nil
+
+	case assignNode:
+		return nil, errorAt(n.at, "cannot compile an assignment")
+	}
+	return nil, errorAt(n.pos(), "cannot compile %v", n)
+}
+
+// count Tally Subexpressions by their Text, Not Looking Inside Repeats;
+// Leaves are as Cheap to Push as a Temporary, so they Aren't Counted
+func (c *compiler) count(n node) {
+	switch n.(type) {
+	case numberNode, varNode:
+		return
+	}
+	key := n.String()
+	if c.counts[key]++; c.counts[key] > 1 {
+		return
+	}
+	switch n := n.(type) {
+	case unaryNode:
+		c.count(n.x)
+	case binaryNode:
+		c.count(n.l)
+		c.count(n.r)
+	case callNode:
+		for _, a := range n.args {
+			c.count(a)
+		}
+	}
+}
+
+func (c *compiler) emit(n node) error {
+	key := n.String()
+	if t, ok := c.temps[key]; ok {
+		return c.op(n, opLoad, t)
+	}
+
+	switch n := n.(type) {
+	case numberNode:
+		return c.op(n, opConst, c.constant(n))
+
+	case varNode:
+		return c.op(n, opParam, c.params[n.name])
+
+	case unaryNode:
+		if err := c.emit(n.x); err != nil {
+			return err
+		}
+		if err := c.op(n, opNeg); err != nil {
+			return err
+		}
+
+	case binaryNode:
+		if err := c.emit(n.l); err != nil {
+			return err
+		}
+		if err := c.emit(n.r); err != nil {
+			return err
+		}
+		op := binaryOps[n.op]
+		if op == opDiv || op == opMod {
+			c.p.at[len(c.p.code)] = n.at
+		}
+		if err := c.op(n, op); err != nil {
+			return err
+		}
+
+	case callNode:
+		for _, a := range n.args {
+			if err := c.emit(a); err != nil {
+				return err
+			}
+		}
+		f, ok := c.funcs[n.name]
+		if !ok {
+			f = len(c.p.funcs)
+			c.funcs[n.name] = f
+			c.p.funcs = append(c.p.funcs, c.e.funcs[n.name])
+			c.p.names = append(c.p.names, n.name)
+		}
+		if err := c.op(n, opCall, f, len(n.args)); err != nil {
+			return err
+		}
+
+	default:
+		return errorAt(n.pos(), "cannot compile %v", n)
+	}
+
+	if c.counts[key] > 1 {
+		t := c.p.temps
+		c.p.temps++
+		c.temps[key] = t
+		return c.op(n, opTee, t)
+	}
+	return nil
+}
+
+// constant Pool Index of a Number, Shared by Equal Numbers
+func (c *compiler) constant(n numberNode) int {
+	for k, v := range c.p.consts {
+		if v == n.val || v != v && n.val != n.val { // NaN Matches NaN
+			return k
+		}
+	}
+	c.p.consts = append(c.p.consts, n.val)
+	return len(c.p.consts) - 1
+}
+
+// op Append an Instruction, Tracking the Stack's Depth
+func (c *compiler) op(n node, op opcode, operands ...int) error {
+	for _, v := range operands {
+		if v > maxOperand {
+			return errorAt(n.pos(), "expression too large to compile")
+		}
+	}
+	switch op {
+	case opConst, opParam, opLoad:
+		c.depth++
+	case opAdd, opSub, opMul, opDiv, opMod, opPow:
+		c.depth--
+	case opCall:
+		c.depth += 1 - operands[1]
+	}
+	if c.depth > c.p.depth {
+		c.p.depth = c.depth
+	}
+	c.p.code = append(c.p.code, byte(op))
+	for _, v := range operands {
+		c.p.code = append(c.p.code, byte(v))
+	}
+	return nil
+}
+
+// String Disassemble the Program
+func (p *program) String() string {
+	var b bytes.Buffer
+	for pc := 0; pc < len(p.code); {
+		op := opcode(p.code[pc])
+		var arg string
+		switch args := p.code[pc+1 : pc+1+opInfo[op].operands]; op {
+		case opConst:
+			arg = fmt.Sprint(p.consts[args[0]])
+		case opParam:
+			arg = p.params[args[0]]
+		case opLoad, opTee:
+			arg = fmt.Sprintf("t%d", args[0])
+		case opCall:
+			arg = fmt.Sprintf("%s/%d", p.names[args[0]], args[1])
+		}
+		if arg == "" {
+			fmt.Fprintf(&b, "%4d  %s", pc, opInfo[op].name)
+		} else {
+			fmt.Fprintf(&b, "%4d  %-5s  %s", pc, opInfo[op].name, arg)
+		}
+		b.WriteByte('\n')
+		pc += 1 + opInfo[op].operands
+	}
+	return b.String()
+}
+
+// freeVars Variables of n Not Defined in e, in Order of Appearance
+func freeVars(n node, e *env) []string {
+	var names []string
+	seen := map[string]bool{}
+	var walk func(node)
+	walk = func(n node) {
+		switch n := n.(type) {
+		case varNode:
+			if _, ok := e.vars[n.name]; !ok && !seen[n.name] {
+				seen[n.name] = true
+				names = append(names, n.name)
+			}
+		case unaryNode:
+			walk(n.x)
+		case binaryNode:
+			walk(n.l)
+			walk(n.r)
+		case callNode:
+			for _, a := range n.args {
+				walk(a)
+			}
+		case assignNode:
+			walk(n.x)
+		}
+	}
+	walk(n)
+	return names
+}
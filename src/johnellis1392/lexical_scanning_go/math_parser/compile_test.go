@@ -0,0 +1,141 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// mustCompile Parse and Compile input over params
+func mustCompile(t testing.TB, input string, params ...string) *program {
+	n, err := parse(input)
+	if err != nil {
+		t.Fatalf("%q: %v", input, err)
+	}
+	p, err := compile(n, params, newEnv())
+	if err != nil {
+		t.Fatalf("%q: %v", input, err)
+	}
+	return p
+}
+
+func TestCompileMatchesEval(t *testing.T) {
+	inputs := []string{
+		"x + y * 2 - z",
+		"-x ^ 2 + (y % 3) / z",
+		"max(x, y, z) - min(x, sin(y)) * pow(z, 2)",
+		"sqrt(x*x + y*y) + (x*x + y*y) / floor(abs(z) + 1)",
+		"2 ^ -x ^ 0.5 * e / pi",
+	}
+	rows := [][]float64{{1, 2, 3}, {-4.5, 0.25, 7}, {10, -3, 1e-3}}
+
+	for _, input := range inputs {
+		p := mustCompile(t, input, "x", "y", "z")
+		n, _ := parse(input)
+		got, err := new(vm).runAll(p, rows)
+		if err != nil {
+			t.Fatalf("%q: %v", input, err)
+		}
+		for i, row := range rows {
+			e := newEnv()
+			e.vars["x"], e.vars["y"], e.vars["z"] = row[0], row[1], row[2]
+			want, err := e.eval(n)
+			if err != nil {
+				t.Fatalf("%q: %v", input, err)
+			}
+			if got[i] != want && !(math.IsNaN(got[i]) && math.IsNaN(want)) {
+				t.Errorf("%q with %v: expected %v, got %v", input, row, want, got[i])
+			}
+		}
+	}
+}
+
+func TestCompileFoldsConstants(t *testing.T) {
+	p := mustCompile(t, "x * (2 * pi / 4 + max(1, 2, 3)) - -sqrt(16)", "x")
+	if len(p.code) != 8 || len(p.consts) != 2 || p.consts[0] != math.Pi/2+3 || p.consts[1] != -4 {
+		t.Errorf("Expected x * k1 - k2, got\n%v%v", p, p.consts)
+	}
+}
+
+func TestCompileSharesSubexpressions(t *testing.T) {
+	p := mustCompile(t, "sin(x + y) * sin(x + y) + cos(x + y)", "x", "y")
+	if p.temps != 2 {
+		t.Errorf("Expected temporaries for x + y and sin(x + y), got %d\n%v", p.temps, p)
+	}
+	calls := 0
+	for pc := 0; pc < len(p.code); pc += 1 + opInfo[p.code[pc]].operands {
+		if opcode(p.code[pc]) == opCall {
+			calls++
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Expected sin and cos called once each, got %d calls\n%v", calls, p)
+	}
+	if v, _ := new(vm).run(p, []float64{0.5, 1}); v != math.Pow(math.Sin(1.5), 2)+math.Cos(1.5) {
+		t.Errorf("Expected sin(1.5)^2 + cos(1.5), got %v", v)
+	}
+}
+
+func TestCompileErrorColumns(t *testing.T) {
+	cases := []struct {
+		input string
+		col   int
+	}{
+		{"x + y", 5},
+		{"x = 1", 3},
+		{"x + 1 / (2 - 2)", 7},
+		{"x + nope(x)", 5},
+	}
+	for _, c := range cases {
+		n, _ := parse(c.input)
+		_, err := compile(n, []string{"x"}, newEnv())
+		if perr, ok := err.(*Error); !ok || perr.Col != c.col {
+			t.Errorf("%q: expected an error at column %d, got %v", c.input, c.col, err)
+		}
+	}
+
+	p := mustCompile(t, "1 + 1 % x", "x")
+	if _, err := new(vm).run(p, []float64{0}); err == nil || err.(*Error).Col != 7 {
+		t.Errorf("Expected division by zero at column 7, got %v", err)
+	}
+}
+
+const benchFormula = "sqrt(x^2 + y^2) * sin(2 * pi * t / 50) + (x^2 + y^2) / (1 + 60 / 60)"
+
+// benchRows Bindings for x, y and t
+func benchRows() [][]float64 {
+	rows := make([][]float64, 1000)
+	for i := range rows {
+		rows[i] = []float64{float64(i%37) - 18, float64(i%11) / 3, float64(i)}
+	}
+	return rows
+}
+
+func BenchmarkTreeWalk(b *testing.B) {
+	n, err := parse(benchFormula)
+	if err != nil {
+		b.Fatal(err)
+	}
+	e, rows := newEnv(), benchRows()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, row := range rows {
+			e.vars["x"], e.vars["y"], e.vars["t"] = row[0], row[1], row[2]
+			if _, err := e.eval(n); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkVM(b *testing.B) {
+	p := mustCompile(b, benchFormula, "x", "y", "t")
+	m, rows := new(vm), benchRows()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, row := range rows {
+			if _, err := m.run(p, row); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
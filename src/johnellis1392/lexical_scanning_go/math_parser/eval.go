@@ -0,0 +1,162 @@
+package main
+
+import (
+	"math"
+)
+
+// function Built-in; arity -1 Takes One or More Arguments
+type function struct {
+	arity int
+	fn    func(args []float64) float64
+}
+
+func unary(f func(float64) float64) function {
+	return function{1, func(a []float64) float64 { return f(a[0]) }}
+}
+
+// builtins Functions Every Environment Starts with
+var builtins = map[string]function{
+	"sin":   unary(math.Sin),
+	"cos":   unary(math.Cos),
+	"tan":   unary(math.Tan),
+	"sqrt":  unary(math.Sqrt),
+	"abs":   unary(math.Abs),
+	"ln":    unary(math.Log),
+	"log":   unary(math.Log10),
+	"exp":   unary(math.Exp),
+	"floor": unary(math.Floor),
+	"ceil":  unary(math.Ceil),
+	"pow":   {2, func(a []float64) float64 { return math.Pow(a[0], a[1]) }},
+	"min": {-1, func(a []float64) float64 {
+		m := a[0]
+		for _, v := range a[1:] {
+			m = math.Min(m, v)
+		}
+		return m
+	}},
+	"max": {-1, func(a []float64) float64 {
+		m := a[0]
+		for _, v := range a[1:] {
+			m = math.Max(m, v)
+		}
+		return m
+	}},
+}
+
+// env Variables and Functions an Expression Can Use
+type env struct {
+	vars  map[string]float64
+	funcs map[string]function
+}
+
+// newEnv Environment with pi, e and the Built-in Functions
+func newEnv() *env {
+	e := &env{
+		vars:  map[string]float64{"pi": math.Pi, "e": math.E},
+		funcs: make(map[string]function, len(builtins)),
+	}
+	for name, f := range builtins {
+		e.funcs[name] = f
+	}
+	return e
+}
+
+// eval Evaluate a Tree; Assignments Update the Environment
+func (e *env) eval(n node) (float64, error) {
+	switch n := n.(type) {
+	case numberNode:
+		return n.val, nil
+
+	case varNode:
+		v, ok := e.vars[n.name]
+		if !ok {
+			return 0, errorAt(n.at, "undefined variable %s", n.name)
+		}
+		return v, nil
+
+	case assignNode:
+		v, err := e.eval(n.x)
+		if err == nil {
+			e.vars[n.name] = v
+		}
+		return v, err
+
+	case unaryNode:
+		v, err := e.eval(n.x)
+		return -v, err
+
+	case binaryNode:
+		l, err := e.eval(n.l)
+		if err != nil {
+			return 0, err
+		}
+		r, err := e.eval(n.r)
+		if err != nil {
+			return 0, err
+		}
+		return binary(n.op, l, r, n.at)
+
+	case callNode:
+		f, err := e.function(n)
+		if err != nil {
+			return 0, err
+		}
+		args := make([]float64, len(n.args))
+		for i, a := range n.args {
+			v, err := e.eval(a)
+			if err != nil {
+				return 0, err
+			}
+			args[i] = v
+		}
+		return f.fn(args), nil
+	}
+	return 0, errorAt(n.pos(), "cannot evaluate %v", n)
+}
+
+// binary Apply a Binary Operator; at Locates Division by Zero
+func binary(op tokenType, l, r float64, at int) (float64, error) {
+	switch op {
+	case tokenPlus:
+		return l + r, nil
+	case tokenMinus:
+		return l - r, nil
+	case tokenMul:
+		return l * r, nil
+	case tokenDiv, tokenMod:
+		if r == 0 {
+			return 0, errorAt(at, "division by zero")
+		}
+		if op == tokenDiv {
+			return l / r, nil
+		}
+		return math.Mod(l, r), nil
+	case tokenPow:
+		return math.Pow(l, r), nil
+	}
+	return 0, errorAt(at, "unknown operator %v", op)
+}
+
+// function Look Up a Call's Function and Check its Arity
+func (e *env) function(n callNode) (function, error) {
+	f, ok := e.funcs[n.name]
+	if !ok {
+		return f, errorAt(n.at, "unknown function %s", n.name)
+	}
+	if f.arity >= 0 && len(n.args) != f.arity {
+		return f, errorAt(n.at, "%s takes %d argument(s), got %d", n.name, f.arity, len(n.args))
+	}
+	if f.arity < 0 && len(n.args) == 0 {
+		return f, errorAt(n.at, "%s needs at least one argument", n.name)
+	}
+	return f, nil
+}
+
+// evalString Parse and Evaluate One Statement
+func (e *env) evalString(input string) (float64, error) {
+	n, err := parse(input)
+	if err != nil {
+		return 0, err
+	}
+	return e.eval(n)
+}
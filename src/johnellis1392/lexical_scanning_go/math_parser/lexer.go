@@ -6,13 +6,17 @@ import (
 	"unicode/utf8"
 )
 
+const debug = false
+
 const (
 	digits        = "0123456789"
 	alphabetLower = "abcdefghijklmnopqrstuvwxyz"
 	alphabetUpper = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
 	alphabetFull  = alphabetLower + alphabetUpper
+	identStart    = alphabetFull + "_"
+	identChars    = identStart + digits
 	whitespace    = " \n\r\t"
-	operators     = "+*"
+	operators     = "+-*/%^(),="
 )
 
 const eof rune = -1
@@ -24,8 +28,17 @@ const (
 	tokenEOF
 
 	tokenNumber
+	tokenIdent
 	tokenPlus
+	tokenMinus
 	tokenMul
+	tokenDiv
+	tokenMod
+	tokenPow
+	tokenLParen
+	tokenRParen
+	tokenComma
+	tokenAssign
 )
 
 func (t tokenType) String() string {
@@ -36,18 +49,51 @@ func (t tokenType) String() string {
 		return "tokenEOF"
 	case tokenNumber:
 		return "tokenNumber"
+	case tokenIdent:
+		return "tokenIdent"
 	case tokenPlus:
 		return "tokenPlus"
+	case tokenMinus:
+		return "tokenMinus"
 	case tokenMul:
 		return "tokenMul"
+	case tokenDiv:
+		return "tokenDiv"
+	case tokenMod:
+		return "tokenMod"
+	case tokenPow:
+		return "tokenPow"
+	case tokenLParen:
+		return "tokenLParen"
+	case tokenRParen:
+		return "tokenRParen"
+	case tokenComma:
+		return "tokenComma"
+	case tokenAssign:
+		return "tokenAssign"
 	default:
 		return "(unknown)"
 	}
 }
 
+// opTokens Single-Rune Operators
+var opTokens = map[rune]tokenType{
+	'+': tokenPlus,
+	'-': tokenMinus,
+	'*': tokenMul,
+	'/': tokenDiv,
+	'%': tokenMod,
+	'^': tokenPow,
+	'(': tokenLParen,
+	')': tokenRParen,
+	',': tokenComma,
+	'=': tokenAssign,
+}
+
 type token struct {
 	typ tokenType
 	val string
+	pos int // Rune Offset in the Input; the Column is pos + 1
 }
 
 func (t token) String() string {
@@ -57,61 +103,81 @@ func (t token) String() string {
 type stateFn func(*lexer) stateFn
 
 // Grammar:
-// file := expr eof
-// expr := expr * number
-// expr := expr + number
-// expr := number
-// number := digit+
+// file    := stmt eof
+// stmt    := ident = expr | expr
+// expr    := expr (+ | - | * | / | % | ^) expr
+// expr    := - expr | ( expr ) | number | ident | ident ( args )
+// args    := expr (, expr)*
+// number  := digit+ [. digit*] [(e | E) [+ | -] digit+]
+// number  := . digit+ [(e | E) [+ | -] digit+]
+//
+// Precedence, Loosest First: + -, * / %, Unary -, ^ (Right Associative)
 
 // State Machine Functions
 func lexNumber(l *lexer) stateFn {
-	fmt.Printf("lexNumber(): pos=%v, start=%v, r=%q\n", l.pos, l.start, l.peek())
-	if !l.acceptRun(digits) {
+	l.log("lexNumber(): pos=%v, start=%v, r=%q\n", l.pos, l.start, l.peek())
+	whole := l.acceptRun(digits)
+	frac := false
+	if l.accept(".") {
+		frac = l.acceptRun(digits)
+	}
+	if !whole && !frac {
+		l.pos = l.start
 		return l.errorf("illegal start of number: %q", l.peek())
 	}
+	if l.accept("eE") {
+		l.accept("+-")
+		if !l.acceptRun(digits) {
+			return l.errorf("missing exponent in %q", string(l.input[l.start:l.pos]))
+		}
+	}
+	if contains(identChars+".", l.peek()) {
+		return l.errorf("bad number syntax: %q", string(l.input[l.start:l.pos+1]))
+	}
 	l.emit(tokenNumber)
 	return lexExpr
 }
 
+func lexIdent(l *lexer) stateFn {
+	l.log("lexIdent(): pos=%v, start=%v, r=%q\n", l.pos, l.start, l.peek())
+	l.accept(identStart)
+	l.acceptRun(identChars)
+	l.emit(tokenIdent)
+	return lexExpr
+}
+
 func lexExpr(l *lexer) stateFn {
-	fmt.Printf("lexExpr(): pos=%v, start=%v, r=%q\n", l.pos, l.start, l.peek())
+	l.log("lexExpr(): pos=%v, start=%v, r=%q\n", l.pos, l.start, l.peek())
 	for {
-		switch {
-		case l.accept(digits):
-			l.backup()
+		switch r := l.peek(); {
+		case isDigit(r) || r == '.':
 			return lexNumber
+		case contains(identStart, r):
+			return lexIdent
 		case l.acceptRun(whitespace):
 			l.ignore()
-			continue
-		case l.accept("+"):
-			l.emit(tokenPlus)
-			continue
-		case l.accept("*"):
-			l.emit(tokenMul)
-			continue
-		case l.peek() == eof:
-			l.backup()
+		case isOperator(r):
+			l.next()
+			l.emit(opTokens[r])
+		case r == eof:
 			return lexFile
 		default:
-			return l.errorf("illegal char in expr: %q", l.peek())
+			return l.errorf("illegal char in expr: %q", r)
 		}
 	}
 }
 
 func lexFile(l *lexer) stateFn {
-	fmt.Printf("lexFile(): pos=%v, start=%v, r=%q\n", l.pos, l.start, l.peek())
+	l.log("lexFile(): pos=%v, start=%v, r=%q\n", l.pos, l.start, l.peek())
 	for {
 		switch {
 		case l.acceptRun(whitespace):
 			l.ignore()
-		case l.accept(digits):
-			l.backup()
-			return lexExpr
 		case l.peek() == eof:
-			// Done
+			l.emit(tokenEOF)
 			return nil
 		default:
-			return l.errorf("illegal character in file: %q", l.peek())
+			return lexExpr
 		}
 	}
 }
@@ -127,9 +193,16 @@ type lexer struct {
 	r     string
 }
 
+func (l *lexer) log(format string, args ...interface{}) {
+	if debug {
+		fmt.Printf(format, args...)
+	}
+}
+
+// errorf Emit an Error Token Pointing at the Rune Under l.pos
 func (l *lexer) errorf(format string, args ...interface{}) stateFn {
 	err := fmt.Sprintf(format, args...)
-	l.output <- token{tokenError, err}
+	l.output <- token{tokenError, err, l.pos}
 	return nil
 }
 
@@ -141,7 +214,7 @@ func (l *lexer) peek() rune {
 }
 
 func (l *lexer) emit(t tokenType) {
-	l.output <- token{t, string(l.input[l.start:l.pos])}
+	l.output <- token{t, string(l.input[l.start:l.pos]), l.start}
 	l.start = l.pos
 }
 
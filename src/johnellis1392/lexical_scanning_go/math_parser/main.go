@@ -1,7 +1,10 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"os"
+	"strings"
 )
 
 func withLexer() {
@@ -19,6 +22,52 @@ func withLexer() {
 	}
 }
 
+// disassemble Compile a Line over its Undefined Variables and Print the
+// Bytecode
+func disassemble(e *env, line string) error {
+	n, err := parse(line)
+	if err != nil {
+		return err
+	}
+	params := freeVars(n, e)
+	p, err := compile(n, params, e)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("params: %s\n%s", strings.Join(params, ", "), p)
+	return nil
+}
+
+// repl Evaluate Each Line of Input, Keeping Variables Between Lines; with
+// show, Print Each Line's Bytecode Instead
+func repl(show bool) {
+	e := newEnv()
+	sc := bufio.NewScanner(os.Stdin)
+	for fmt.Print("> "); sc.Scan(); fmt.Print("> ") {
+		line := sc.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if show {
+			if perr, ok := disassemble(e, line).(*Error); ok {
+				fmt.Println(perr.Show(line))
+			}
+			continue
+		}
+		v, err := e.evalString(line)
+		if perr, ok := err.(*Error); ok {
+			fmt.Println(perr.Show(line))
+			continue
+		}
+		fmt.Println(v)
+	}
+	fmt.Println()
+}
+
 func main() {
-	withLexer()
+	if len(os.Args) > 1 && os.Args[1] == "-tokens" {
+		withLexer()
+		return
+	}
+	repl(len(os.Args) > 1 && os.Args[1] == "-S")
 }
@@ -1,4 +1,211 @@
 package main
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Error Parse or Evaluation Error at a Column of the Input
+type Error struct {
+	Col int // 1-Based, in Runes
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Col, e.Msg)
+}
+
+// Show The Input with a Caret Under the Offending Column
+func (e *Error) Show(input string) string {
+	return fmt.Sprintf("%s\n%s^ %s", input, strings.Repeat(" ", e.Col-1), e.Msg)
+}
+
+func errorAt(pos int, format string, args ...interface{}) *Error {
+	return &Error{pos + 1, fmt.Sprintf(format, args...)}
+}
+
+// Binding Powers, Loosest First
+const (
+	bpNone = iota * 10
+	bpSum
+	bpProduct
+	bpPrefix
+	bpPower
+)
+
+// infixPower How Tightly Each Binary Operator Binds
+var infixPower = map[tokenType]int{
+	tokenPlus:  bpSum,
+	tokenMinus: bpSum,
+	tokenMul:   bpProduct,
+	tokenDiv:   bpProduct,
+	tokenMod:   bpProduct,
+	tokenPow:   bpPower,
+}
+
+// parser Pratt Parser Reading the Lexer's Token Channel
 type parser struct {
+	tokens chan token
+	tok    token // Lookahead
+}
+
+// parse Build the Tree for One Statement
+func parse(input string) (node, error) {
+	p := &parser{tokens: lex(input)}
+	defer p.drain()
+	p.advance()
+
+	n, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.typ != tokenEOF {
+		return nil, p.unexpected()
+	}
+	return n, nil
+}
+
+// drain Let the Lexer Finish if Parsing Stopped Early
+func (p *parser) drain() {
+	for range p.tokens {
+	}
+}
+
+func (p *parser) advance() {
+	t, ok := <-p.tokens
+	if !ok {
+		t = token{typ: tokenEOF, pos: p.tok.pos + len([]rune(p.tok.val))}
+	}
+	p.tok = t
+}
+
+// unexpected Error for the Lookahead Token
+func (p *parser) unexpected() error {
+	switch p.tok.typ {
+	case tokenError:
+		return errorAt(p.tok.pos, "%s", p.tok.val)
+	case tokenEOF:
+		return errorAt(p.tok.pos, "unexpected end of input")
+	}
+	return errorAt(p.tok.pos, "unexpected %q", p.tok.val)
+}
+
+func (p *parser) statement() (node, error) {
+	n, err := p.expr(bpNone)
+	if err != nil || p.tok.typ != tokenAssign {
+		return n, err
+	}
+	v, ok := n.(varNode)
+	if !ok {
+		return nil, errorAt(p.tok.pos, "can only assign to a variable")
+	}
+	at := p.tok.pos
+	p.advance()
+	x, err := p.expr(bpNone)
+	if err != nil {
+		return nil, err
+	}
+	return assignNode{at, v.name, x}, nil
+}
+
+// expr Parse Operators Binding Tighter than min
+func (p *parser) expr(min int) (node, error) {
+	left, err := p.prefix()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		bp, ok := infixPower[p.tok.typ]
+		if !ok || bp <= min {
+			return left, nil
+		}
+		op := p.tok
+		p.advance()
+		if op.typ == tokenPow {
+			bp-- // Right Associative
+		}
+		right, err := p.expr(bp)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op.pos, op.typ, left, right}
+	}
+}
+
+func (p *parser) prefix() (node, error) {
+	t := p.tok
+	switch t.typ {
+	case tokenNumber:
+		p.advance()
+		v, err := strconv.ParseFloat(t.val, 64)
+		if err != nil {
+			return nil, errorAt(t.pos, "bad number %q", t.val)
+		}
+		return numberNode{t.pos, v}, nil
+
+	case tokenIdent:
+		p.advance()
+		if p.tok.typ != tokenLParen {
+			return varNode{t.pos, t.val}, nil
+		}
+		p.advance()
+		args, err := p.args()
+		if err != nil {
+			return nil, err
+		}
+		return callNode{t.pos, t.val, args}, nil
+
+	case tokenMinus, tokenPlus:
+		p.advance()
+		x, err := p.expr(bpPrefix)
+		if err != nil {
+			return nil, err
+		}
+		if t.typ == tokenPlus {
+			return x, nil
+		}
+		return unaryNode{t.pos, t.typ, x}, nil
+
+	case tokenLParen:
+		p.advance()
+		x, err := p.expr(bpNone)
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.typ != tokenRParen {
+			if p.tok.typ == tokenEOF {
+				return nil, errorAt(t.pos, "unclosed (")
+			}
+			return nil, p.unexpected()
+		}
+		p.advance()
+		return x, nil
+	}
+	return nil, p.unexpected()
+}
+
+// args Call Arguments, After the (
+func (p *parser) args() ([]node, error) {
+	var args []node
+	if p.tok.typ == tokenRParen {
+		p.advance()
+		return args, nil
+	}
+	for {
+		x, err := p.expr(bpNone)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, x)
+		switch p.tok.typ {
+		case tokenComma:
+			p.advance()
+		case tokenRParen:
+			p.advance()
+			return args, nil
+		default:
+			return nil, p.unexpected()
+		}
+	}
 }
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParsePrecedence(t *testing.T) {
+	cases := []struct{ input, tree string }{
+		{"1 + 2 * 3", "(1 + (2 * 3))"},
+		{"1 - 2 - 3", "((1 - 2) - 3)"},
+		{"2 ^ 3 ^ 2", "(2 ^ (3 ^ 2))"},
+		{"-2 ^ 2", "(-(2 ^ 2))"},
+		{"(1 + 2) % x", "((1 + 2) % x)"},
+		{"max(1, .5e-1, f(y))", "max(1, 0.05, f(y))"},
+		{"x = 1.5E3 / 2", "x = (1500 / 2)"},
+	}
+	for _, c := range cases {
+		n, err := parse(c.input)
+		if err != nil {
+			t.Errorf("%q: %v", c.input, err)
+			continue
+		}
+		if n.String() != c.tree {
+			t.Errorf("%q: expected %s, got %v", c.input, c.tree, n)
+		}
+	}
+}
+
+func TestEval(t *testing.T) {
+	e := newEnv()
+	cases := []struct {
+		input string
+		want  float64
+	}{
+		{"7 % 4 + 10 / 4", 5.5},
+		{"-2^2", -4},
+		{"r = 3", 3},
+		{"floor(pi * r ^ 2)", 28},
+		{"max(1, r, 2) - min(4, -r)", 6},
+		{"pow(2, 10)", 1024},
+	}
+	for _, c := range cases {
+		v, err := e.evalString(c.input)
+		if err != nil || v != c.want {
+			t.Errorf("%q: expected %v, got %v (%v)", c.input, c.want, v, err)
+		}
+	}
+}
+
+func TestErrorColumns(t *testing.T) {
+	cases := []struct {
+		input string
+		col   int
+	}{
+		{"1 + * 2", 5},
+		{"1 + 2)", 6},
+		{"2 * (3 + 4", 5},
+		{"1 + 2 $", 7},
+		{"3.5.1", 4},
+		{"4 = 5", 3},
+		{"1 / (2 - 2)", 3},
+		{"2 * y", 5},
+		{"3 + nope(1)", 5},
+		{"sin(1, 2)", 1},
+		{"max()", 1},
+	}
+	for _, c := range cases {
+		_, err := newEnv().evalString(c.input)
+		perr, ok := err.(*Error)
+		if !ok {
+			t.Errorf("%q: expected an error, got %v", c.input, err)
+			continue
+		}
+		if perr.Col != c.col {
+			t.Errorf("%q: expected column %d, got %v", c.input, c.col, perr)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// vm Stack Machine Running Programs; Reusing One Between Runs Avoids
+// Allocating per Evaluation
+type vm struct {
+	stack []float64
+	temps []float64
+}
+
+// run Evaluate p with its Parameters Bound to args, in p.params Order
+func (m *vm) run(p *program, args []float64) (float64, error) {
+	if len(args) != len(p.params) {
+		return 0, fmt.Errorf("program takes %d parameter(s), got %d", len(p.params), len(args))
+	}
+	if cap(m.stack) < p.depth {
+		m.stack = make([]float64, p.depth)
+	}
+	if cap(m.temps) < p.temps {
+		m.temps = make([]float64, p.temps)
+	}
+	stack, temps, code := m.stack[:p.depth], m.temps[:p.temps], p.code
+
+	sp := 0
+	for pc := 0; pc < len(code); {
+		switch opcode(code[pc]) {
+		case opConst:
+			stack[sp] = p.consts[code[pc+1]]
+			sp++
+			pc += 2
+		case opParam:
+			stack[sp] = args[code[pc+1]]
+			sp++
+			pc += 2
+		case opLoad:
+			stack[sp] = temps[code[pc+1]]
+			sp++
+			pc += 2
+		case opTee:
+			temps[code[pc+1]] = stack[sp-1]
+			pc += 2
+		case opNeg:
+			stack[sp-1] = -stack[sp-1]
+			pc++
+		case opAdd:
+			sp--
+			stack[sp-1] += stack[sp]
+			pc++
+		case opSub:
+			sp--
+			stack[sp-1] -= stack[sp]
+			pc++
+		case opMul:
+			sp--
+			stack[sp-1] *= stack[sp]
+			pc++
+		case opDiv, opMod:
+			sp--
+			if stack[sp] == 0 {
+				return 0, errorAt(p.at[pc], "division by zero")
+			}
+			if opcode(code[pc]) == opDiv {
+				stack[sp-1] /= stack[sp]
+			} else {
+				stack[sp-1] = math.Mod(stack[sp-1], stack[sp])
+			}
+			pc++
+		case opPow:
+			sp--
+			stack[sp-1] = math.Pow(stack[sp-1], stack[sp])
+			pc++
+		case opCall:
+			n := int(code[pc+2])
+			v := p.funcs[code[pc+1]].fn(stack[sp-n : sp])
+			sp -= n
+			stack[sp] = v
+			sp++
+			pc += 3
+		default:
+			return 0, fmt.Errorf("bad opcode %d at %d", code[pc], pc)
+		}
+	}
+	return stack[0], nil
+}
+
+// runAll Evaluate p Once per Row of Bindings
+func (m *vm) runAll(p *program, rows [][]float64) ([]float64, error) {
+	out := make([]float64, len(rows))
+	for i, row := range rows {
+		v, err := m.run(p, row)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %v", i, err)
+		}
+		out[i] = v
+	}
+	return out, nil
+}